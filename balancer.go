@@ -0,0 +1,231 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LoadBalancingSettings configures the load balancing of Client.WithLoadBalancing.
+// Zero values are replaced by the documented defaults.
+type LoadBalancingSettings struct {
+	// Addresses, if set, are the "host:port" addresses requests are spread across,
+	// whatever the endpoint, instead of those the host of the endpoint resolves to.
+	Addresses []string
+	// Resolver resolves the hosts of the endpoints. It defaults to net.DefaultResolver.
+	Resolver *net.Resolver
+	// RefreshInterval is how long the resolved addresses of a host are used
+	// before resolving it again. It defaults to 30s.
+	RefreshInterval time.Duration
+	// EjectFor is how long an address which couldn't be connected to is skipped. It defaults to 30s.
+	EjectFor time.Duration
+}
+
+// WithLoadBalancing makes the client spread its requests across the addresses
+// the host of the endpoint resolves to, its A and AAAA records, or a static list
+// of addresses, in turn, e.g. for self-hosted GraphQL clusters without a load
+// balancer in front. The URL, and the server name of TLS connections, stay those
+// of the endpoint.
+//
+// Requests failing to connect to an address are sent to the next one, and the
+// address is ejected for a while, except for those with uploads, whose bodies
+// are streamed, and can't be sent again. Connections to each address are pooled.
+// It doesn't apply to requests sent through a proxy, nor to clients whose
+// transport doesn't apply (see WithDialer). The other transport options must
+// be applied before requests are sent.
+func (c *Client) WithLoadBalancing(settings LoadBalancingSettings) *Client {
+	if settings.Resolver == nil {
+		settings.Resolver = net.DefaultResolver
+	}
+	if settings.RefreshInterval <= 0 {
+		settings.RefreshInterval = 30 * time.Second
+	}
+	if settings.EjectFor <= 0 {
+		settings.EjectFor = 30 * time.Second
+	}
+	t := c.transport()
+	if t == nil {
+		return c
+	}
+	c.httpClient.(*http.Client).Transport = &balancer{
+		base:       t,
+		settings:   settings,
+		hosts:      make(map[string]*balancedHost),
+		transports: make(map[string]*http.Transport),
+	}
+	return c
+}
+
+// balancer sends requests through transports connecting to an address each.
+type balancer struct {
+	base     *http.Transport // Cloned for each address.
+	settings LoadBalancingSettings
+
+	mu         sync.Mutex
+	hosts      map[string]*balancedHost   // By "host:port" of the endpoints.
+	transports map[string]*http.Transport // By address.
+}
+
+// balancedHost holds the addresses of the host of an endpoint.
+type balancedHost struct {
+	addrs      []string
+	resolvedAt time.Time
+	next       int
+	ejected    map[string]time.Time // Until when addresses are ejected.
+}
+
+// RoundTrip implements http.RoundTripper.
+func (b *balancer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if b.base.Proxy != nil {
+		if proxyURL, err := b.base.Proxy(req); err != nil || proxyURL != nil {
+			return b.base.RoundTrip(req)
+		}
+	}
+	hostport := canonicalHostPort(req)
+	addrs, err := b.order(req.Context(), hostport)
+	if err != nil {
+		return nil, err
+	}
+	sent := req
+	for i, addr := range addrs {
+		resp, err := b.transport(addr).RoundTrip(sent)
+		var opErr *net.OpError
+		if err == nil || !errors.As(err, &opErr) || opErr.Op != "dial" || req.Context().Err() != nil {
+			return resp, err
+		}
+		// The request wasn't sent, and may be sent to another address.
+		b.eject(hostport, addr)
+		if i == len(addrs)-1 {
+			return nil, err
+		}
+		if req.Body != nil && req.Body != http.NoBody {
+			// The transport closed the body, which must be rewound, unlike
+			// the streamed bodies of uploads.
+			if req.GetBody == nil {
+				return nil, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, err
+			}
+			sent = req.Clone(req.Context())
+			sent.Body = body
+		}
+	}
+	return nil, errors.New("graphql: no addresses to connect to")
+}
+
+// CloseIdleConnections closes the idle connections to all the addresses.
+func (b *balancer) CloseIdleConnections() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.base.CloseIdleConnections()
+	for _, t := range b.transports {
+		t.CloseIdleConnections()
+	}
+}
+
+// order returns the addresses of hostport in the order they're to be tried,
+// starting with the next one in turn, and the ejected ones last.
+func (b *balancer) order(ctx context.Context, hostport string) ([]string, error) {
+	b.mu.Lock()
+	h, ok := b.hosts[hostport]
+	if !ok {
+		h = &balancedHost{ejected: make(map[string]time.Time)}
+		b.hosts[hostport] = h
+	}
+	if len(b.settings.Addresses) > 0 {
+		h.addrs = b.settings.Addresses
+	} else if time.Since(h.resolvedAt) > b.settings.RefreshInterval {
+		b.mu.Unlock()
+		addrs, err := b.resolve(ctx, hostport)
+		b.mu.Lock()
+		if err != nil && len(h.addrs) == 0 {
+			b.mu.Unlock()
+			return nil, err
+		}
+		// On failures, keep using the addresses resolved before.
+		if err == nil {
+			h.addrs, h.resolvedAt = addrs, time.Now()
+		}
+	}
+	defer b.mu.Unlock()
+	if len(h.addrs) == 0 {
+		return nil, errors.New("graphql: no addresses to connect to")
+	}
+	start := h.next % len(h.addrs)
+	h.next++
+	now := time.Now()
+	healthy := make([]string, 0, len(h.addrs))
+	var ejected []string
+	for i := range h.addrs {
+		addr := h.addrs[(start+i)%len(h.addrs)]
+		if now.Before(h.ejected[addr]) {
+			ejected = append(ejected, addr)
+		} else {
+			healthy = append(healthy, addr)
+		}
+	}
+	return append(healthy, ejected...), nil
+}
+
+// resolve returns the addresses of the host of hostport, with its port.
+func (b *balancer) resolve(ctx context.Context, hostport string) ([]string, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := b.settings.Resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]string, len(hosts))
+	for i, h := range hosts {
+		addrs[i] = net.JoinHostPort(h, port)
+	}
+	return addrs, nil
+}
+
+// eject skips addr for the host hostport for b.settings.EjectFor.
+func (b *balancer) eject(hostport, addr string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h, ok := b.hosts[hostport]; ok {
+		h.ejected[addr] = time.Now().Add(b.settings.EjectFor)
+	}
+}
+
+// transport returns the transport connecting to addr, cloned from b.base on first use.
+func (b *balancer) transport(addr string) *http.Transport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t, ok := b.transports[addr]
+	if !ok {
+		t = b.base.Clone()
+		dial := t.DialContext
+		if dial == nil {
+			var d net.Dialer
+			dial = d.DialContext
+		}
+		t.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dial(ctx, network, addr)
+		}
+		b.transports[addr] = t
+	}
+	return t
+}
+
+// canonicalHostPort returns the "host:port" of the URL of req, with the default port of its scheme.
+func canonicalHostPort(req *http.Request) string {
+	port := req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(req.URL.Hostname(), port)
+}
@@ -0,0 +1,101 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// newNamedServer starts a server responding with name as the name of the user.
+func newNamedServer(t *testing.T, name string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "`+name+`"}}}`)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_WithLoadBalancing(t *testing.T) {
+	a, b := newNamedServer(t, "a"), newNamedServer(t, "b")
+	// An address nothing listens on.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	// Without the proxies of the environment.
+	client := graphql.NewClient("http://graphql.example.com/graphql", &http.Client{Transport: &http.Transport{}}).
+		WithLoadBalancing(graphql.LoadBalancingSettings{
+			Addresses: []string{a.Listener.Addr().String(), down, b.Listener.Addr().String()},
+		})
+	var got []string
+	for i := 0; i < 5; i++ {
+		got = append(got, queryUserName(t, client))
+	}
+	// The request to the address down is sent to b, and the address is ejected.
+	if want := []string{"a", "b", "b", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got names: %q, want: %q", got, want)
+	}
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClient_WithLoadBalancing_resolved(t *testing.T) {
+	server := newNamedServer(t, "a")
+	// localhost may resolve to ::1 too, which the server doesn't listen on.
+	url := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/graphql"
+	client := graphql.NewClient(url, nil).WithLoadBalancing(graphql.LoadBalancingSettings{})
+	for i := 0; i < 3; i++ {
+		if got, want := queryUserName(t, client), "a"; got != want {
+			t.Errorf("got name: %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestClient_WithLoadBalancing_upload(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"upload": {"id": "1"}}}`)
+	}))
+	t.Cleanup(server.Close)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	client := graphql.NewClient("http://graphql.example.com/graphql", &http.Client{Transport: &http.Transport{}}).
+		WithLoadBalancing(graphql.LoadBalancingSettings{
+			Addresses: []string{down, server.Listener.Addr().String()},
+		})
+	var m struct {
+		Upload struct {
+			ID string
+		} `graphql:"upload(file: $file)"`
+	}
+	variables := map[string]interface{}{
+		"file": &graphql.Upload{File: strings.NewReader("report"), Name: "report.txt"},
+	}
+	// The upload, whose body can't be rewound, fails with the error of the first address.
+	var opErr *net.OpError
+	if err := client.Mutate(context.Background(), &m, variables); !errors.As(err, &opErr) || opErr.Op != "dial" {
+		t.Errorf("got error: %v, want a dial error", err)
+	}
+	if calls != 0 {
+		t.Errorf("got %d requests, want: 0", calls)
+	}
+}
@@ -0,0 +1,383 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batch collects operations to send them in a single request, as a JSON array,
+// to servers supporting batching such as Apollo Server and Hasura:
+//
+//	b := client.NewBatch()
+//	user := b.Query(&userQuery, userVariables)
+//	repo := b.Query(&repoQuery, repoVariables)
+//	err := b.Exec(ctx)
+//
+// The operations go through the middleware of the client one by one.
+// Operations with different endpoints or headers are sent in separate requests,
+// and operations with uploads, or sent as GET or application/graphql requests,
+// on their own.
+type Batch struct {
+	c   *Client
+	ops []*BatchOperation
+}
+
+// BatchOperation is an operation added to a Batch.
+type BatchOperation struct {
+	typ       OperationType
+	v         interface{}
+	variables map[string]interface{}
+	name      string
+	options   []Option
+
+	err error
+}
+
+// Err returns the error of the operation, once its Batch or BatchExecutor has been executed.
+func (o *BatchOperation) Err() error {
+	return o.err
+}
+
+// NewBatch returns an empty batch of operations executed by c.
+func (c *Client) NewBatch() *Batch {
+	return &Batch{c: c}
+}
+
+// Query adds a query derived from q to the batch, like Client.Query.
+// The response is populated into q by Exec.
+func (b *Batch) Query(q interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return b.add(QueryOperation, q, variables, "", options)
+}
+
+// NamedQuery adds a query with operation name to the batch, like Client.NamedQuery.
+func (b *Batch) NamedQuery(name string, q interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return b.add(QueryOperation, q, variables, name, options)
+}
+
+// Mutate adds a mutation derived from m to the batch, like Client.Mutate.
+// The response is populated into m by Exec.
+func (b *Batch) Mutate(m interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return b.add(MutationOperation, m, variables, "", options)
+}
+
+// NamedMutate adds a mutation with operation name to the batch, like Client.NamedMutate.
+func (b *Batch) NamedMutate(name string, m interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return b.add(MutationOperation, m, variables, name, options)
+}
+
+func (b *Batch) add(typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) *BatchOperation {
+	op := &BatchOperation{typ: typ, v: v, variables: variables, name: name, options: options}
+	b.ops = append(b.ops, op)
+	return op
+}
+
+// Exec executes the operations of the batch, populating their responses,
+// and returns the error of the first failed operation, in order of addition.
+// The errors of all operations are reported by BatchOperation.Err.
+func (b *Batch) Exec(ctx context.Context) error {
+	collector := &batchCollector{c: b.c, ctx: ctx, expected: len(b.ops)}
+	var wg sync.WaitGroup
+	for _, op := range b.ops {
+		wg.Add(1)
+		go func(op *BatchOperation) {
+			defer wg.Done()
+			slot := &batchSlot{collector: collector}
+			op.err = b.c.do(context.WithValue(ctx, batchSlotKey{}, slot), op.typ, op.v, op.variables, op.name, op.options)
+			// In case it didn't reach the collector, e.g. because a middleware responded.
+			collector.release(slot)
+		}(op)
+	}
+	wg.Wait()
+	for _, op := range b.ops {
+		if op.err != nil {
+			return op.err
+		}
+	}
+	return nil
+}
+
+// batchSlotKey is the context key of the *batchSlot of an operation executed in a Batch.
+type batchSlotKey struct{}
+
+// batchSlot tracks whether an operation of a Batch reached its collector.
+type batchSlot struct {
+	collector *batchCollector
+	added     bool // Guarded by collector.mu.
+}
+
+// batchCall is an operation waiting to be sent in a batched request.
+type batchCall struct {
+	ctx  context.Context
+	op   *Operation
+	resp *Response
+	err  error
+	done chan struct{}
+}
+
+func newBatchCall(ctx context.Context, op *Operation) *batchCall {
+	return &batchCall{ctx: ctx, op: op, done: make(chan struct{})}
+}
+
+// wait waits for the response of the call, unless ctx is done first.
+func (call *batchCall) wait(ctx context.Context) (*Response, error) {
+	select {
+	case <-call.done:
+		return call.resp, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// batchCollector collects the operations of a Batch, and sends them once
+// all of them have reached it or finished otherwise.
+type batchCollector struct {
+	c   *Client
+	ctx context.Context
+
+	mu       sync.Mutex
+	expected int // Number of operations not released without being collected.
+	pending  []*batchCall
+	flushed  bool
+}
+
+func (b *batchCollector) send(ctx context.Context, slot *batchSlot, op *Operation) (*Response, error) {
+	b.mu.Lock()
+	if b.flushed || slot.added {
+		// E.g., retried by a middleware.
+		b.mu.Unlock()
+		return b.c.sendSingle(ctx, op)
+	}
+	slot.added = true
+	call := newBatchCall(ctx, op)
+	b.pending = append(b.pending, call)
+	calls := b.takeIfComplete()
+	b.mu.Unlock()
+
+	if calls != nil {
+		go b.c.flushBatch(b.ctx, calls)
+	}
+	return call.wait(ctx)
+}
+
+// release records that the operation of slot won't reach the collector,
+// unless it already has.
+func (b *batchCollector) release(slot *batchSlot) {
+	b.mu.Lock()
+	if slot.added {
+		b.mu.Unlock()
+		return
+	}
+	slot.added = true
+	b.expected--
+	calls := b.takeIfComplete()
+	b.mu.Unlock()
+
+	if calls != nil {
+		go b.c.flushBatch(b.ctx, calls)
+	}
+}
+
+// takeIfComplete returns the pending calls if all expected operations have reached the collector.
+// b.mu must be held.
+func (b *batchCollector) takeIfComplete() []*batchCall {
+	if b.flushed || len(b.pending) == 0 || len(b.pending) < b.expected {
+		return nil
+	}
+	b.flushed = true
+	calls := b.pending
+	b.pending = nil
+	return calls
+}
+
+// WithBatching makes the client collect the operations executed within window
+// after a first one, up to maxSize of them, and send them in a single request
+// as a JSON array, to servers supporting batching. Operations are batched as by
+// Batch, and sent on their own when there's only one. A maxSize of 0 means no limit.
+//
+// The batched requests have a context of their own, not canceled by callers,
+// who stop waiting for the response when their context is done.
+func (c *Client) WithBatching(window time.Duration, maxSize int) *Client {
+	c.batcher = &windowBatcher{c: c, window: window, maxSize: maxSize}
+	return c
+}
+
+// windowBatcher collects operations over a time window.
+type windowBatcher struct {
+	c       *Client
+	window  time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	pending []*batchCall
+	timer   *time.Timer
+	closed  bool // Set by Client.Close, after which calls are sent right away.
+}
+
+func (b *windowBatcher) send(ctx context.Context, op *Operation) (*Response, error) {
+	call := newBatchCall(ctx, op)
+	var calls []*batchCall
+	b.mu.Lock()
+	b.pending = append(b.pending, call)
+	switch {
+	case b.closed || b.maxSize > 0 && len(b.pending) >= b.maxSize:
+		if b.timer != nil {
+			b.timer.Stop()
+			b.timer = nil
+		}
+		calls = b.take()
+	case len(b.pending) == 1:
+		b.timer = time.AfterFunc(b.window, b.flushPending)
+	}
+	b.mu.Unlock()
+
+	if calls != nil {
+		go b.c.flushBatch(context.Background(), calls)
+	}
+	return call.wait(ctx)
+}
+
+func (b *windowBatcher) flushPending() {
+	b.mu.Lock()
+	b.timer = nil
+	calls := b.take()
+	b.mu.Unlock()
+
+	if calls != nil {
+		go b.c.flushBatch(context.Background(), calls)
+	}
+}
+
+// take returns the pending calls. b.mu must be held.
+func (b *windowBatcher) take() []*batchCall {
+	calls := b.pending
+	b.pending = nil
+	return calls
+}
+
+// batchable reports whether op may be sent in a batched request.
+func (c *Client) batchable(op *Operation) bool {
+	if c.graphQLBodies || c.getQueries && op.Type == QueryOperation {
+		return false
+	}
+	return len(extractUploads(op.Variables)) == 0
+}
+
+// flushBatch sends calls, grouped by endpoint and headers,
+// and hands the responses to their callers.
+func (c *Client) flushBatch(ctx context.Context, calls []*batchCall) {
+	groups := make(map[string][]*batchCall)
+	var keys []string
+	for _, call := range calls {
+		key := batchKey(call.op)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], call)
+	}
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		group := groups[key]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if len(group) == 1 {
+				call := group[0]
+				call.resp, call.err = c.sendSingle(call.ctx, call.op)
+				close(call.done)
+				return
+			}
+			ops := make([]*Operation, len(group))
+			for i, call := range group {
+				ops[i] = call.op
+			}
+			results, err := c.sendBatch(ctx, ops)
+			for i, call := range group {
+				if err != nil {
+					call.err = err
+				} else {
+					call.resp, call.err = results[i].resp, results[i].err
+				}
+				close(call.done)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// batchKey returns the key grouping op with the operations that may share its request.
+func batchKey(op *Operation) string {
+	var b strings.Builder
+	b.WriteString(op.URL)
+	keys := make([]string, 0, len(op.Header))
+	for k := range op.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s: %q", k, op.Header[k])
+	}
+	if params := op.queryParams(); len(params) > 0 {
+		b.WriteString("\n?" + params.Encode())
+	}
+	return b.String()
+}
+
+// batchResult is the result of an operation of a batched request.
+type batchResult struct {
+	resp *Response
+	err  error
+}
+
+// sendBatch sends ops, which share their endpoint and headers,
+// in a single request as a JSON array.
+func (c *Client) sendBatch(ctx context.Context, ops []*Operation) ([]batchResult, error) {
+	ctx, cancel := c.withTimeout(ctx, nil)
+	defer cancel()
+	in := make([]requestPayload, len(ops))
+	for i, op := range ops {
+		var err error
+		if in[i], err = c.payload(op); err != nil {
+			return nil, err
+		}
+	}
+	req, body, err := c.newJSONRequest(ctx, ops[0].URL, in)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.prepareRequest(req, ops[0].Header, ops[0].queryParams(), requestIDs(ops), body); err != nil {
+		return nil, err
+	}
+	var results []batchResult
+	err = c.roundTrip(ctx, ops, req, func(resp *http.Response, body []byte, duration time.Duration) error {
+		if resp.StatusCode != http.StatusOK {
+			return newStatusError(resp, body)
+		}
+		if err := checkContentType(resp, body); err != nil {
+			return &DecodeError{err: err}
+		}
+		var out []json.RawMessage
+		if err := c.codec.Unmarshal(body, &out); err != nil {
+			return &DecodeError{err: newMalformedResponseError(body, err)}
+		}
+		if len(out) != len(ops) {
+			return &DecodeError{err: fmt.Errorf("got %d responses to a batch of %d operations", len(out), len(ops))}
+		}
+		results = make([]batchResult, len(ops))
+		for i, raw := range out {
+			result, err := c.unmarshalGraphQLResult(raw)
+			if err != nil {
+				results[i].err = &DecodeError{err: err}
+				continue
+			}
+			results[i].resp = result.withHTTP(resp, duration)
+		}
+		return nil
+	})
+	return results, err
+}
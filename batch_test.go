@@ -0,0 +1,133 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// batchHandler responds to batched requests with the user names given in the variables,
+// recording the number of operations of each request.
+func batchHandler(t *testing.T, mu *sync.Mutex, sizes *[]int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var in []struct {
+			Query     string
+			Variables map[string]string
+		}
+		body := mustRead(req.Body)
+		if body[0] != '[' {
+			var single struct {
+				Variables map[string]string
+			}
+			if err := json.Unmarshal([]byte(body), &single); err != nil {
+				t.Fatal(err)
+			}
+			mu.Lock()
+			*sizes = append(*sizes, 1)
+			mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, `{"data": {"user": {"name": "`+single.Variables["name"]+`"}}}`)
+			return
+		}
+		if err := json.Unmarshal([]byte(body), &in); err != nil {
+			t.Fatal(err)
+		}
+		mu.Lock()
+		*sizes = append(*sizes, len(in))
+		mu.Unlock()
+		out := make([]interface{}, len(in))
+		for i, op := range in {
+			if op.Variables["name"] == "" {
+				out[i] = map[string]interface{}{"errors": []map[string]string{{"message": "no name"}}}
+				continue
+			}
+			out[i] = map[string]interface{}{"data": map[string]interface{}{"user": map[string]string{"name": op.Variables["name"]}}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(out)
+	})
+}
+
+type batchUserQuery struct {
+	User struct {
+		Name string
+	} `graphql:"user(name: $name)"`
+}
+
+func TestBatch(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: batchHandler(t, &mu, &sizes)}})
+
+	var q1, q2, q3, q4 batchUserQuery
+	b := client.NewBatch()
+	op1 := b.Query(&q1, map[string]interface{}{"name": graphql.String("Gopher")})
+	op2 := b.Query(&q2, map[string]interface{}{"name": graphql.String("Ferris")})
+	op3 := b.Query(&q3, map[string]interface{}{"name": graphql.String("")})
+	op4 := b.Query(&q4, map[string]interface{}{"name": graphql.String("Duke")}, graphql.WithRequestHeader("x-hasura-role", "editor"))
+	err := b.Exec(context.Background())
+	if err == nil || op3.Err() == nil || err.Error() != op3.Err().Error() {
+		t.Errorf("got error: %v, want the one of the third operation: %v", err, op3.Err())
+	}
+	for i, op := range []*graphql.BatchOperation{op1, op2, op4} {
+		if op.Err() != nil {
+			t.Errorf("got error of operation %d: %v", i, op.Err())
+		}
+	}
+	if got, want := q1.User.Name, "Gopher"; got != want {
+		t.Errorf("got q1.User.Name: %q, want: %q", got, want)
+	}
+	if got, want := q2.User.Name, "Ferris"; got != want {
+		t.Errorf("got q2.User.Name: %q, want: %q", got, want)
+	}
+	if got, want := q4.User.Name, "Duke"; got != want {
+		t.Errorf("got q4.User.Name: %q, want: %q", got, want)
+	}
+	// The operation with other headers is sent on its own.
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sizes) != 2 || sizes[0]+sizes[1] != 4 || (sizes[0] != 3 && sizes[1] != 3) {
+		t.Errorf("got request sizes: %v, want 3 and 1", sizes)
+	}
+}
+
+func TestClient_WithBatching(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: batchHandler(t, &mu, &sizes)}}).
+		WithBatching(time.Hour, 3)
+
+	names := []string{"Gopher", "Ferris", "Duke"}
+	var wg sync.WaitGroup
+	for _, name := range names {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			var q batchUserQuery
+			if err := client.Query(context.Background(), &q, map[string]interface{}{"name": graphql.String(name)}); err != nil {
+				t.Error(err)
+				return
+			}
+			if got, want := q.User.Name, name; got != want {
+				t.Errorf("got q.User.Name: %q, want: %q", got, want)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	client.WithBatching(time.Millisecond, 0)
+	var q batchUserQuery
+	if err := client.Query(context.Background(), &q, map[string]interface{}{"name": graphql.String("Gopher")}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got, want := sizes, []int{3, 1}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got request sizes: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for operations rejected by an open CircuitBreaker.
+var ErrCircuitOpen = errors.New("graphql: circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker for an endpoint.
+type CircuitState uint8
+
+const (
+	// CircuitClosed lets operations through, counting their failures.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects operations with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probing operation through,
+	// whose outcome closes or reopens the circuit.
+	CircuitHalfOpen
+)
+
+// String returns the name of s, e.g. "open".
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerSettings configures a CircuitBreaker.
+// Zero values are replaced by the documented defaults.
+type CircuitBreakerSettings struct {
+	// FailureRatio is the ratio of failed operations within Window,
+	// between 0 and 1, which opens the circuit. It defaults to 0.5.
+	FailureRatio float64
+	// MinOperations is the number of operations within Window needed
+	// before the failure ratio is considered. It defaults to 10.
+	MinOperations int
+	// Window is the interval over which failures are counted. It defaults to 10s.
+	Window time.Duration
+	// OpenTimeout is how long the circuit stays open before letting
+	// a probing operation through. It defaults to 30s.
+	OpenTimeout time.Duration
+	// IsFailure reports whether err counts as a failure of the server.
+	// It defaults to IsRetryable, so that network errors and 5xx status codes
+	// count, while GraphQL errors and canceled contexts don't.
+	IsFailure func(err error) bool
+	// OnStateChange, if set, is called when the circuit of an endpoint changes state.
+	// It must not call the breaker, which is locked meanwhile.
+	OnStateChange func(url string, from, to CircuitState)
+}
+
+// CircuitBreaker stops sending operations to an endpoint having failed
+// too often recently, so that a struggling server isn't hammered by every
+// caller. The circuit of every endpoint is tracked independently. A breaker
+// may be shared by several clients, and the zero value isn't usable.
+type CircuitBreaker struct {
+	settings CircuitBreakerSettings
+
+	mu        sync.Mutex
+	endpoints map[string]*circuit
+}
+
+// circuit is the state of a CircuitBreaker for an endpoint.
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	operations  int
+	failures    int
+	openedAt    time.Time
+	probing     bool // Whether a probing operation is in flight, when half-open.
+}
+
+// NewCircuitBreaker returns a CircuitBreaker configured with settings.
+func NewCircuitBreaker(settings CircuitBreakerSettings) *CircuitBreaker {
+	if settings.FailureRatio <= 0 {
+		settings.FailureRatio = 0.5
+	}
+	if settings.MinOperations <= 0 {
+		settings.MinOperations = 10
+	}
+	if settings.Window <= 0 {
+		settings.Window = 10 * time.Second
+	}
+	if settings.OpenTimeout <= 0 {
+		settings.OpenTimeout = 30 * time.Second
+	}
+	if settings.IsFailure == nil {
+		settings.IsFailure = IsRetryable
+	}
+	return &CircuitBreaker{
+		settings:  settings,
+		endpoints: make(map[string]*circuit),
+	}
+}
+
+// State returns the state of the circuit of the endpoint url.
+func (b *CircuitBreaker) State(url string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.endpoints[url]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= b.settings.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// WithCircuitBreaker guards every attempt to send an operation with b, so
+// that the circuit of each endpoint added by WithFailover is tracked, and
+// operations rejected by an open circuit are sent to the next endpoint.
+func (c *Client) WithCircuitBreaker(b *CircuitBreaker) *Client {
+	c.breaker = b
+	return c
+}
+
+// Middleware returns the middleware guarding the execution of operations with b, e.g.:
+//
+//	client.Use(graphql.NewCircuitBreaker(graphql.CircuitBreakerSettings{}).Middleware())
+//
+// Middlewares run before failover, so operations are tracked by the URL of the
+// client, whatever the endpoint they're sent to. Client.WithCircuitBreaker
+// tracks the endpoints of a client with failover.
+//
+// Operations whose context is done before they complete are neither successes
+// nor failures, as the caller gave up.
+func (b *CircuitBreaker) Middleware() Middleware {
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, op *Operation) (*Response, error) {
+			url := op.URL
+			probe, err := b.allow(url)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := next(ctx, op)
+			if err != nil && ctx.Err() != nil {
+				b.release(url, probe)
+			} else {
+				b.record(url, probe, err != nil && b.settings.IsFailure(err))
+			}
+			return resp, err
+		}
+	}
+}
+
+// allow returns ErrCircuitOpen if an operation may not be sent to url,
+// and otherwise whether the operation probes the half-open circuit.
+func (b *CircuitBreaker) allow(url string) (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.endpoints[url]
+	if !ok {
+		c = &circuit{windowStart: time.Now()}
+		b.endpoints[url] = c
+	}
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < b.settings.OpenTimeout {
+			return false, ErrCircuitOpen
+		}
+		b.setState(url, c, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probing {
+			return false, ErrCircuitOpen
+		}
+		c.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// record records the outcome of an operation sent to url, probing the circuit if
+// probe is true. The outcomes of the operations allowed before the circuit opened
+// and completing afterwards are ignored, so that only the probe closes it.
+func (b *CircuitBreaker) record(url string, probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c := b.endpoints[url]
+	now := time.Now()
+	switch {
+	case probe:
+		c.probing = false
+		if failed {
+			c.openedAt = now
+			b.setState(url, c, CircuitOpen)
+			return
+		}
+		c.windowStart, c.operations, c.failures = now, 0, 0
+		b.setState(url, c, CircuitClosed)
+	case c.state == CircuitClosed:
+		if now.Sub(c.windowStart) >= b.settings.Window {
+			c.windowStart, c.operations, c.failures = now, 0, 0
+		}
+		c.operations++
+		if failed {
+			c.failures++
+		}
+		if c.operations >= b.settings.MinOperations &&
+			float64(c.failures) >= b.settings.FailureRatio*float64(c.operations) {
+			c.openedAt = now
+			b.setState(url, c, CircuitOpen)
+		}
+	}
+}
+
+// release ends an operation sent to url without recording its outcome,
+// letting another probe through if it was the probe.
+func (b *CircuitBreaker) release(url string, probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.endpoints[url].probing = false
+}
+
+func (b *CircuitBreaker) setState(url string, c *circuit, state CircuitState) {
+	from := c.state
+	c.state = state
+	if b.settings.OnStateChange != nil {
+		b.settings.OnStateChange(url, from, state)
+	}
+}
@@ -0,0 +1,189 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	fail := true
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerSettings{
+		MinOperations: 2,
+		OpenTimeout:   20 * time.Millisecond,
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).Use(breaker.Middleware())
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	for i := 0; i < 2; i++ {
+		var netErr *graphql.NetworkError
+		if err := client.Query(context.Background(), &q, nil); !errors.As(err, &netErr) {
+			t.Fatalf("got error: %v, want a *graphql.NetworkError", err)
+		}
+	}
+	if got, want := breaker.State("/graphql"), graphql.CircuitOpen; got != want {
+		t.Fatalf("got state: %v, want: %v", got, want)
+	}
+	if err := client.Query(context.Background(), &q, nil); !errors.Is(err, graphql.ErrCircuitOpen) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrCircuitOpen)
+	}
+	if got, want := calls, 2; got != want {
+		t.Errorf("got %d calls, want: %d", got, want)
+	}
+
+	// A failed probe reopens the circuit.
+	time.Sleep(20 * time.Millisecond)
+	if got, want := breaker.State("/graphql"), graphql.CircuitHalfOpen; got != want {
+		t.Fatalf("got state: %v, want: %v", got, want)
+	}
+	if err := client.Query(context.Background(), &q, nil); errors.Is(err, graphql.ErrCircuitOpen) {
+		t.Fatalf("got error: %v, want the probe to be sent", err)
+	}
+	if got, want := breaker.State("/graphql"), graphql.CircuitOpen; got != want {
+		t.Fatalf("got state: %v, want: %v", got, want)
+	}
+
+	// A successful probe closes it.
+	fail = false
+	time.Sleep(20 * time.Millisecond)
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := breaker.State("/graphql"), graphql.CircuitClosed; got != want {
+		t.Errorf("got state: %v, want: %v", got, want)
+	}
+	if got, want := breaker.State("/other"), graphql.CircuitClosed; got != want {
+		t.Errorf("got state of other endpoint: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_WithCircuitBreaker(t *testing.T) {
+	h := &endpointsHandler{down: map[string]bool{"/a": true}}
+	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerSettings{MinOperations: 1, OpenTimeout: 20 * time.Millisecond})
+	client := graphql.NewClient("/a", &http.Client{Transport: localRoundTripper{handler: h}}).
+		WithFailover(graphql.FailoverPriority, "/b").
+		WithCircuitBreaker(breaker)
+
+	if got, want := queryUserName(t, client), "/b"; got != want {
+		t.Errorf("got response of: %q, want: %q", got, want)
+	}
+	if got, want := breaker.State("/a"), graphql.CircuitOpen; got != want {
+		t.Errorf("got state of /a: %v, want: %v", got, want)
+	}
+	if got, want := breaker.State("/b"), graphql.CircuitClosed; got != want {
+		t.Errorf("got state of /b: %v, want: %v", got, want)
+	}
+	// The open endpoint is skipped.
+	h.requested()
+	if got, want := queryUserName(t, client), "/b"; got != want {
+		t.Errorf("got response of: %q, want: %q", got, want)
+	}
+	if got, want := h.requested(), []string{"/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+
+	// A probe canceled by its caller doesn't close the circuit.
+	time.Sleep(20 * time.Millisecond)
+	h.down, h.slow = nil, map[string]bool{"/a": true}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(ctx, &q, nil); err == nil {
+		t.Error("got no error, want the error of the context")
+	}
+	if got, want := breaker.State("/a"), graphql.CircuitHalfOpen; got != want {
+		t.Errorf("got state of /a: %v, want: %v", got, want)
+	}
+}
+
+func TestCircuitBreaker_probe(t *testing.T) {
+	breaker := graphql.NewCircuitBreaker(graphql.CircuitBreakerSettings{
+		MinOperations: 1,
+		OpenTimeout:   20 * time.Millisecond,
+		IsFailure:     func(err error) bool { return true },
+	})
+	results := map[string]chan error{"stale": make(chan error), "canceled": make(chan error), "probe": make(chan error)}
+	started := make(chan struct{})
+	handler := breaker.Middleware()(func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+		switch op.Name {
+		case "failing":
+			return nil, errors.New("unavailable")
+		case "other":
+			return &graphql.Response{}, nil
+		}
+		started <- struct{}{}
+		select {
+		case err := <-results[op.Name]:
+			return &graphql.Response{}, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	execute := func(ctx context.Context, name string) <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := handler(ctx, &graphql.Operation{Name: name, URL: "/graphql"})
+			done <- err
+		}()
+		return done
+	}
+
+	// Operations allowed while the circuit is closed complete once it's half-open.
+	stale := execute(context.Background(), "stale")
+	<-started
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	canceled := execute(ctx, "canceled")
+	<-started
+	if err := <-execute(context.Background(), "failing"); err == nil {
+		t.Fatal("got no error, want the failure")
+	}
+	time.Sleep(20 * time.Millisecond)
+	probe := execute(context.Background(), "probe")
+	<-started
+
+	results["stale"] <- nil
+	if err := <-stale; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := breaker.State("/graphql"), graphql.CircuitHalfOpen; got != want {
+		t.Errorf("got state after a stale success: %v, want: %v", got, want)
+	}
+	cancel()
+	<-canceled
+	if err := <-execute(context.Background(), "other"); !errors.Is(err, graphql.ErrCircuitOpen) {
+		t.Errorf("got error: %v, want: %v while probing", err, graphql.ErrCircuitOpen)
+	}
+
+	results["probe"] <- nil
+	if err := <-probe; err != nil {
+		t.Fatal(err)
+	}
+	if got, want := breaker.State("/graphql"), graphql.CircuitClosed; got != want {
+		t.Errorf("got state after the probe: %v, want: %v", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package graphql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"sync"
+)
+
+// maxPooledBufferSize is the capacity above which buffers aren't returned
+// to the pool, so that a single huge response doesn't stay in memory for good.
+const maxPooledBufferSize = 1 << 20
+
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// putBuffer returns buf to the pool. buf must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// gzipWriterPool holds the writers compressing requests, whose compression
+// state takes hundreds of kilobytes.
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
@@ -0,0 +1,312 @@
+package graphql
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Cache stores responses to queries, for Client.WithCache.
+// Implementations must be safe for concurrent use.
+type Cache interface {
+	// Get returns the entry stored with key, if any.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores entry with key, to be kept for ttl.
+	Set(key string, entry *CacheEntry, ttl time.Duration)
+}
+
+// CacheEntry is a response stored in a Cache. It must not be modified.
+type CacheEntry struct {
+	Response *Response
+	StoredAt time.Time
+	// ETag is the entity tag of the response, if the server sent one,
+	// used to revalidate the entry once it's stale.
+	ETag string
+}
+
+// age returns the time elapsed since e was stored.
+func (e *CacheEntry) age() time.Duration {
+	return time.Since(e.StoredAt)
+}
+
+// WithCache makes the client look up the responses to queries in cache,
+// and store the successful ones, without errors, for ttl. Responses are
+// only shared by queries having the same endpoint, document, variables
+// and headers, including the Authorization header set by WithTokenProvider.
+// Mutations are never cached.
+//
+// WithCacheBypass, WithCacheRefresh and WithCacheMaxAge control the cache for a single call.
+func (c *Client) WithCache(cache Cache, ttl time.Duration) *Client {
+	c.cache = &responseCache{
+		cache:         cache,
+		ttl:           ttl,
+		revalidateFor: c.revalidateFor,
+		staleFor:      c.staleFor,
+		refreshing:    new(sync.Map),
+	}
+	return c
+}
+
+// WithStaleWhileRevalidate makes the client keep cached responses for maxStale
+// once they're stale, and use them right away during that time, while refreshing
+// them in the background, e.g. for dashboards tolerating slightly old data.
+// A query is refreshed once at a time, and failed refreshes leave the stale
+// response in the cache. WithCacheMaxStale bounds the staleness for a single call.
+// It has no effect without WithCache.
+func (c *Client) WithStaleWhileRevalidate(maxStale time.Duration) *Client {
+	c.staleFor = maxStale
+	if c.cache != nil {
+		c.cache.staleFor = maxStale
+	}
+	return c
+}
+
+// WithConditionalRequests makes the client keep cached responses having
+// an ETag for keep once they're stale, and revalidate them by sending
+// If-None-Match. On 304 Not Modified, the cached response is used and
+// considered fresh again. It has no effect without WithCache.
+func (c *Client) WithConditionalRequests(keep time.Duration) *Client {
+	c.revalidateFor = keep
+	if c.cache != nil {
+		c.cache.revalidateFor = keep
+	}
+	return c
+}
+
+// cacheMode controls the use of the cache by a single call.
+type cacheMode uint8
+
+const (
+	cacheDefault cacheMode = iota
+	cacheBypass            // Neither read nor write the cache.
+	cacheRefresh           // Write but don't read the cache.
+)
+
+// WithCacheBypass makes a single call neither use nor store cached responses.
+func WithCacheBypass() Option {
+	return func(opts *requestOptions) {
+		opts.cacheMode = cacheBypass
+	}
+}
+
+// WithCacheRefresh makes a single call skip the cached response,
+// and store the fresh one in its place.
+func WithCacheRefresh() Option {
+	return func(opts *requestOptions) {
+		opts.cacheMode = cacheRefresh
+	}
+}
+
+// WithCacheMaxAge makes a single call only use a cached response stored
+// at most maxAge ago, and keep the fresh one at least as long.
+func WithCacheMaxAge(maxAge time.Duration) Option {
+	return func(opts *requestOptions) {
+		opts.cacheMaxAge = maxAge
+	}
+}
+
+// WithCacheMaxStale makes a single call use a stale cached response, while
+// refreshing it in the background, only if it's been stale for at most maxStale,
+// instead of the duration set by Client.WithStaleWhileRevalidate. A maxStale
+// longer than that keeps the fresh response longer.
+func WithCacheMaxStale(maxStale time.Duration) Option {
+	return func(opts *requestOptions) {
+		opts.cacheMaxStale = maxStale
+	}
+}
+
+// responseCache looks up and stores the responses to queries.
+type responseCache struct {
+	cache Cache
+	ttl   time.Duration
+	// revalidateFor is how long stale entries with an ETag are kept for revalidation.
+	revalidateFor time.Duration
+	// staleFor is how long stale entries are used while they're refreshed.
+	staleFor time.Duration
+	// refreshing holds the keys of the entries being refreshed in the background.
+	refreshing *sync.Map
+}
+
+// handler returns the handler caching the responses of next.
+func (rc *responseCache) handler(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		opts := op.options
+		if opts == nil {
+			opts = &requestOptions{}
+		}
+		if op.Type != QueryOperation || opts.cacheMode == cacheBypass || opts.stream {
+			return next(ctx, op)
+		}
+		key, err := cacheKey(op)
+		if err != nil {
+			return next(ctx, op)
+		}
+		maxAge, ttl := rc.ttl, rc.ttl
+		if opts.cacheMaxAge > 0 {
+			maxAge = opts.cacheMaxAge
+			if maxAge > ttl {
+				ttl = maxAge
+			}
+		}
+		maxStale := rc.staleFor
+		if opts.cacheMaxStale > 0 {
+			maxStale = opts.cacheMaxStale
+			if maxStale > rc.staleFor {
+				ttl += maxStale - rc.staleFor
+			}
+		}
+		var stale *CacheEntry
+		if opts.cacheMode != cacheRefresh {
+			if entry, ok := rc.cache.Get(key); ok {
+				age := entry.age()
+				if age <= maxAge {
+					return entry.Response, nil
+				}
+				if age <= maxAge+maxStale {
+					rc.refresh(ctx, next, op, key, entry, ttl)
+					return entry.Response, nil
+				}
+				if entry.ETag != "" && rc.revalidateFor > 0 {
+					stale = entry
+				}
+			}
+		}
+		return rc.fetch(ctx, next, op, key, stale, ttl)
+	}
+}
+
+// fetch sends op with next, revalidating the stale entry if it's not nil,
+// and stores the response with key for ttl if it's successful.
+func (rc *responseCache) fetch(ctx context.Context, next OperationHandler, op *Operation, key string, stale *CacheEntry, ttl time.Duration) (*Response, error) {
+	sent := op
+	if stale != nil {
+		conditional := *op
+		conditional.Header = op.Header.Clone()
+		if conditional.Header == nil {
+			conditional.Header = make(http.Header)
+		}
+		conditional.Header.Set("If-None-Match", stale.ETag)
+		sent = &conditional
+	}
+	resp, err := next(ctx, sent)
+	var netErr *NetworkError
+	if stale != nil && errors.As(err, &netErr) && netErr.StatusCode() == http.StatusNotModified {
+		rc.store(key, &CacheEntry{Response: stale.Response, StoredAt: time.Now(), ETag: stale.ETag}, ttl)
+		return stale.Response, nil
+	}
+	if err == nil && resp.Data != nil && len(resp.Errors) == 0 {
+		rc.store(key, &CacheEntry{Response: resp, StoredAt: time.Now(), ETag: resp.Header.Get("ETag")}, ttl)
+	}
+	return resp, err
+}
+
+// refresh fetches the stale entry with key again in the background, revalidating
+// it if it has an ETag, unless it's being refreshed already. The refresh isn't
+// canceled with ctx, but keeps its values.
+func (rc *responseCache) refresh(ctx context.Context, next OperationHandler, op *Operation, key string, stale *CacheEntry, ttl time.Duration) {
+	if _, loaded := rc.refreshing.LoadOrStore(key, true); loaded {
+		return
+	}
+	// The caller keeps using op.
+	refreshed := *op
+	refreshed.Header = op.Header.Clone()
+	if stale.ETag == "" {
+		stale = nil
+	}
+	go func() {
+		defer rc.refreshing.Delete(key)
+		_, _ = rc.fetch(context.WithoutCancel(ctx), next, &refreshed, key, stale, ttl)
+	}()
+}
+
+// store stores entry with key for ttl, and longer for revalidation if it has
+// an ETag, and to be used while it's refreshed.
+func (rc *responseCache) store(key string, entry *CacheEntry, ttl time.Duration) {
+	if entry.ETag != "" {
+		ttl += rc.revalidateFor
+	}
+	rc.cache.Set(key, entry, ttl+rc.staleFor)
+}
+
+// cacheKey returns the key of the responses to queries identical to op.
+func cacheKey(op *Operation) (string, error) {
+	key, err := dedupKey(op)
+	if err != nil {
+		return "", err
+	}
+	return DocumentID(key), nil
+}
+
+// MemoryCache is an in-memory Cache, evicting the least recently used
+// entries beyond its capacity, and expired entries.
+type MemoryCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Of *memoryCacheItem, most recently used first.
+}
+
+type memoryCacheItem struct {
+	key     string
+	entry   *CacheEntry
+	expires time.Time
+}
+
+// NewMemoryCache returns a MemoryCache holding up to maxEntries entries.
+// A maxEntries of 0 means no limit.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Get implements Cache.
+func (m *MemoryCache) Get(key string) (*CacheEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	item := e.Value.(*memoryCacheItem)
+	if time.Now().After(item.expires) {
+		m.remove(e)
+		return nil, false
+	}
+	m.lru.MoveToFront(e)
+	return item.entry, true
+}
+
+// Set implements Cache.
+func (m *MemoryCache) Set(key string, entry *CacheEntry, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item := &memoryCacheItem{key: key, entry: entry, expires: time.Now().Add(ttl)}
+	if e, ok := m.entries[key]; ok {
+		e.Value = item
+		m.lru.MoveToFront(e)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(item)
+	if m.maxEntries > 0 && m.lru.Len() > m.maxEntries {
+		m.remove(m.lru.Back())
+	}
+}
+
+// Len returns the number of entries, including expired ones not evicted yet.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lru.Len()
+}
+
+func (m *MemoryCache) remove(e *list.Element) {
+	m.lru.Remove(e)
+	delete(m.entries, e.Value.(*memoryCacheItem).key)
+}
@@ -0,0 +1,207 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithCache(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	cache := graphql.NewMemoryCache(10)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithCache(cache, time.Hour)
+
+	query := func(options ...graphql.Option) {
+		t.Helper()
+		var q struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := client.Query(context.Background(), &q, nil, options...); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := q.User.Name, "Gopher"; got != want {
+			t.Errorf("got q.User.Name: %q, want: %q", got, want)
+		}
+	}
+	tests := []struct {
+		name      string
+		options   []graphql.Option
+		wantCalls int
+	}{
+		{"miss", nil, 1},
+		{"hit", nil, 1},
+		{"bypass", []graphql.Option{graphql.WithCacheBypass()}, 2},
+		{"refresh", []graphql.Option{graphql.WithCacheRefresh()}, 3},
+		{"fresh enough", []graphql.Option{graphql.WithCacheMaxAge(time.Minute)}, 3},
+		{"too old", []graphql.Option{graphql.WithCacheMaxAge(time.Nanosecond)}, 4},
+		{"other headers", []graphql.Option{graphql.WithRequestHeader("Authorization", "Bearer other")}, 5},
+	}
+	for _, tc := range tests {
+		query(tc.options...)
+		if calls != tc.wantCalls {
+			t.Errorf("%s: got %d requests, want: %d", tc.name, calls, tc.wantCalls)
+		}
+	}
+}
+
+func TestClient_WithCache_tokenProvider(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, fmt.Sprintf(`{"data": {"user": {"name": %q}}}`, req.Header.Get("Authorization")))
+	})
+	type userKey struct{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.NewMemoryCache(10), time.Hour).
+		WithTokenProvider(func(ctx context.Context, refresh bool) (string, error) {
+			return ctx.Value(userKey{}).(string), nil
+		})
+
+	for _, user := range []string{"alice", "bob", "alice"} {
+		var q struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := client.Query(context.WithValue(context.Background(), userKey{}, user), &q, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := q.User.Name, "Bearer "+user; got != want {
+			t.Errorf("got response for %q, want: %q", got, want)
+		}
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache := graphql.NewMemoryCache(2)
+	entry := &graphql.CacheEntry{StoredAt: time.Now()}
+	cache.Set("a", entry, time.Hour)
+	cache.Set("b", entry, time.Hour)
+	cache.Get("a")
+	cache.Set("c", entry, time.Hour)
+	if _, ok := cache.Get("b"); ok {
+		t.Error("got least recently used entry b, want it evicted")
+	}
+	if _, ok := cache.Get("a"); !ok {
+		t.Error("got no entry a")
+	}
+	cache.Set("d", entry, -time.Second)
+	if _, ok := cache.Get("d"); ok {
+		t.Error("got expired entry d")
+	}
+	if got, want := cache.Len(), 1; got != want {
+		t.Errorf("got %d entries, want: %d", got, want)
+	}
+}
+
+func TestClient_WithConditionalRequests(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("If-None-Match"))
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(graphql.NewMemoryCache(10), time.Millisecond).
+		WithConditionalRequests(time.Hour)
+
+	for i := 0; i < 2; i++ {
+		var q struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := client.Query(context.Background(), &q, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := q.User.Name, "Gopher"; got != want {
+			t.Errorf("got q.User.Name: %q, want: %q", got, want)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	if want := []string{"", `"v1"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got If-None-Match headers: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithStaleWhileRevalidate(t *testing.T) {
+	var mu sync.Mutex
+	sent := 0
+	refreshed := make(chan struct{}, 10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		sent++
+		n := sent
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, fmt.Sprintf(`{"data": {"user": {"name": "v%d"}}}`, n))
+		refreshed <- struct{}{}
+	})
+	cache := graphql.NewMemoryCache(10)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithCache(cache, 20*time.Millisecond).
+		WithStaleWhileRevalidate(time.Hour)
+	query := func(options ...graphql.Option) string {
+		t.Helper()
+		var q struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := client.Query(context.Background(), &q, nil, options...); err != nil {
+			t.Fatal(err)
+		}
+		return q.User.Name
+	}
+
+	if got, want := query(), "v1"; got != want {
+		t.Errorf("got name: %q, want: %q", got, want)
+	}
+	<-refreshed
+	time.Sleep(30 * time.Millisecond)
+	// The stale response is used, and refreshed in the background.
+	if got, want := query(), "v1"; got != want {
+		t.Errorf("got stale name: %q, want: %q", got, want)
+	}
+	<-refreshed
+	deadline := time.Now().Add(10 * time.Second)
+	for query() != "v2" {
+		if time.Now().After(deadline) {
+			t.Fatal("the stale response wasn't refreshed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Bounded staleness for a single call.
+	time.Sleep(30 * time.Millisecond)
+	if got, want := query(graphql.WithCacheMaxStale(time.Millisecond)), "v3"; got != want {
+		t.Errorf("got name of the call bounding staleness: %q, want: %q", got, want)
+	}
+	<-refreshed
+	mu.Lock()
+	defer mu.Unlock()
+	if sent != 3 {
+		t.Errorf("got %d requests, want: 3", sent)
+	}
+}
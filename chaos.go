@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ChaosSettings configures the faults injected by ChaosMiddleware. Each fault
+// is injected with its probability, between 0 and 1, drawn independently for
+// every operation. Zero values are replaced by the documented defaults.
+type ChaosSettings struct {
+	// LatencyProbability is the probability of delaying an operation by Latency
+	// before executing it.
+	LatencyProbability float64
+	// Latency is the maximum delay of delayed operations, which are delayed
+	// by a random duration up to it. It defaults to 1s.
+	Latency time.Duration
+	// TimeoutProbability is the probability of an operation hanging, as on an
+	// unresponsive server, until its context is done or Timeout elapsed.
+	// It fails with a *NetworkError wrapping the error of the context, or
+	// context.DeadlineExceeded.
+	TimeoutProbability float64
+	// Timeout is how long hanging operations hang at most. It defaults to 10s.
+	Timeout time.Duration
+	// ServerErrorProbability is the probability of failing an operation with the
+	// *NetworkError of a response with the status code ServerErrorStatus.
+	ServerErrorProbability float64
+	// ServerErrorStatus is the status code of injected server errors. It defaults to 503.
+	ServerErrorStatus int
+	// MalformedProbability is the probability of failing an operation with the
+	// *MalformedResponseError of a truncated JSON body.
+	MalformedProbability float64
+	// Rand returns the random numbers in [0, 1) drawing the faults and latencies,
+	// e.g. from a seeded *rand.Rand for reproducible runs, made safe for
+	// concurrent use. It defaults to rand.Float64.
+	Rand func() float64
+}
+
+// malformedBody is the body of the responses injected by ChaosMiddleware as malformed.
+const malformedBody = `{"data":{"`
+
+// ChaosMiddleware returns a middleware injecting latency, timeouts, server errors
+// and malformed responses into operations at random, as configured by settings,
+// for resilience testing of the code using the client:
+//
+//	client.Use(graphql.ChaosMiddleware(graphql.ChaosSettings{
+//		LatencyProbability:     0.2,
+//		ServerErrorProbability: 0.05,
+//	}))
+//
+// Failed operations aren't sent, and fail with the errors of real failures.
+// As the middlewares of Use wrap the retries, failover and circuit breaker of
+// the client, the faults reach the calling code without being retried; use
+// Client.WithChaos to inject them into every attempt instead.
+func ChaosMiddleware(settings ChaosSettings) Middleware {
+	if settings.Latency <= 0 {
+		settings.Latency = time.Second
+	}
+	if settings.Timeout <= 0 {
+		settings.Timeout = 10 * time.Second
+	}
+	if settings.ServerErrorStatus == 0 {
+		settings.ServerErrorStatus = http.StatusServiceUnavailable
+	}
+	if settings.Rand == nil {
+		settings.Rand = rand.Float64
+	}
+	return func(next OperationHandler) OperationHandler {
+		return func(ctx context.Context, op *Operation) (*Response, error) {
+			if settings.Rand() < settings.LatencyProbability {
+				latency := time.Duration(settings.Rand() * float64(settings.Latency))
+				if err := sleep(ctx, latency); err != nil {
+					return nil, err
+				}
+			}
+			if settings.Rand() < settings.TimeoutProbability {
+				ctx, cancel := context.WithTimeout(ctx, settings.Timeout)
+				defer cancel()
+				<-ctx.Done()
+				return nil, &NetworkError{err: ctx.Err()}
+			}
+			if settings.Rand() < settings.ServerErrorProbability {
+				status := strconv.Itoa(settings.ServerErrorStatus) + " " + http.StatusText(settings.ServerErrorStatus)
+				return nil, &NetworkError{statusCode: settings.ServerErrorStatus, status: status, header: make(http.Header)}
+			}
+			if settings.Rand() < settings.MalformedProbability {
+				var v interface{}
+				return nil, newMalformedResponseError([]byte(malformedBody), json.Unmarshal([]byte(malformedBody), &v))
+			}
+			return next(ctx, op)
+		}
+	}
+}
+
+// WithChaos makes the client inject the faults of ChaosMiddleware into every
+// attempt to send an operation, as configured by settings, so that they're
+// retried, fail over and trip the circuit breaker of WithCircuitBreaker as
+// real failures would, e.g. to test the resilience settings of the client.
+func (c *Client) WithChaos(settings ChaosSettings) *Client {
+	c.chaos = ChaosMiddleware(settings)
+	return c
+}
@@ -0,0 +1,185 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestChaosMiddleware(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	always := func() float64 { return 0 }
+
+	tests := []struct {
+		name     string
+		settings graphql.ChaosSettings
+		check    func(t *testing.T, err error)
+	}{
+		{
+			name:     "server error",
+			settings: graphql.ChaosSettings{ServerErrorProbability: 0.5, Rand: always},
+			check: func(t *testing.T, err error) {
+				var netErr *graphql.NetworkError
+				if !errors.As(err, &netErr) || netErr.StatusCode() != http.StatusServiceUnavailable || !graphql.IsRetryable(err) {
+					t.Errorf("got error: %v, want a retryable 503 *graphql.NetworkError", err)
+				}
+			},
+		},
+		{
+			name:     "server error status",
+			settings: graphql.ChaosSettings{ServerErrorProbability: 1, ServerErrorStatus: http.StatusBadGateway, Rand: always},
+			check: func(t *testing.T, err error) {
+				var netErr *graphql.NetworkError
+				if !errors.As(err, &netErr) || netErr.StatusCode() != http.StatusBadGateway {
+					t.Errorf("got error: %v, want a 502 *graphql.NetworkError", err)
+				}
+			},
+		},
+		{
+			name:     "malformed",
+			settings: graphql.ChaosSettings{MalformedProbability: 1, Rand: always},
+			check: func(t *testing.T, err error) {
+				var malformed *graphql.MalformedResponseError
+				if !errors.As(err, &malformed) {
+					t.Errorf("got error: %v, want a *graphql.MalformedResponseError", err)
+				}
+			},
+		},
+		{
+			name:     "timeout",
+			settings: graphql.ChaosSettings{TimeoutProbability: 1, Timeout: 10 * time.Millisecond, Rand: always},
+			check: func(t *testing.T, err error) {
+				var netErr *graphql.NetworkError
+				if !errors.As(err, &netErr) || !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("got error: %v, want a *graphql.NetworkError wrapping context.DeadlineExceeded", err)
+				}
+			},
+		},
+		{
+			name:     "no faults",
+			settings: graphql.ChaosSettings{ServerErrorProbability: 0.5, Rand: func() float64 { return 0.5 }},
+			check: func(t *testing.T, err error) {
+				if err != nil {
+					t.Error(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = 0
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).Use(graphql.ChaosMiddleware(tt.settings))
+			var q struct {
+				User struct{ Name string }
+			}
+			err := client.Query(context.Background(), &q, nil)
+			tt.check(t, err)
+			// Injected faults aren't sent.
+			want := 0
+			if err == nil {
+				want = 1
+			}
+			if calls != want {
+				t.Errorf("got %d requests, want: %d", calls, want)
+			}
+		})
+	}
+}
+
+func TestChaosMiddleware_latency(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	// The latency is drawn as the second random number.
+	draws := 0
+	rand := func() float64 {
+		draws++
+		if draws == 2 {
+			return 0.5
+		}
+		return 0.1
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).Use(graphql.ChaosMiddleware(graphql.ChaosSettings{
+		LatencyProbability: 0.2,
+		Latency:            40 * time.Millisecond,
+		Rand:               rand,
+	}))
+	var q struct {
+		User struct{ Name string }
+	}
+	start := time.Now()
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := time.Since(start), 20*time.Millisecond; got < want {
+		t.Errorf("got latency: %v, want at least: %v", got, want)
+	}
+
+	// The delay is canceled with the context.
+	draws = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := client.Query(ctx, &q, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestClient_WithChaos(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	// The first attempt fails with a server error, at the third draw.
+	newSettings := func() graphql.ChaosSettings {
+		draws := 0
+		return graphql.ChaosSettings{ServerErrorProbability: 0.5, Rand: func() float64 {
+			draws++
+			if draws <= 3 {
+				return 0
+			}
+			return 0.9
+		}}
+	}
+	retries := graphql.RetrySettings{Backoff: time.Millisecond}
+	var q struct {
+		User struct{ Name string }
+	}
+
+	// Faults injected by the middleware aren't retried.
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithRetries(retries).
+		Use(graphql.ChaosMiddleware(newSettings()))
+	var netErr *graphql.NetworkError
+	if err := client.Query(context.Background(), &q, nil); !errors.As(err, &netErr) || netErr.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("got error: %v, want a 503 *graphql.NetworkError", err)
+	}
+	if calls != 0 {
+		t.Errorf("got %d requests, want: 0", calls)
+	}
+
+	// Faults injected into attempts are.
+	client = graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithRetries(retries).
+		WithChaos(newSettings())
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d requests, want: 1", calls)
+	}
+}
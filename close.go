@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// ErrClientClosed is returned for the operations executed by a client after Client.Close.
+var ErrClientClosed = errors.New("graphql: client is closed")
+
+// Close releases the resources of the client, for a clean shutdown: it sends
+// the operations waiting in the window of WithBatching, closes the idle
+// connections of the transport the client created for its transport options,
+// if any, but not those of the HTTP client it was given, which may be shared,
+// e.g. http.DefaultClient, the SubscriptionClient of WithWebSocketTransport,
+// and the cache of WithCache if it implements io.Closer, e.g. to flush it.
+// Operations executed afterwards fail with ErrClientClosed.
+//
+// Copies made by Clone and With share these resources, so Close is to be called
+// once the copies aren't used anymore either. They don't share the closed state
+// though: copies made before Close aren't closed by it, while those made after are.
+func (c *Client) Close() error {
+	if !atomic.CompareAndSwapUint32(&c.closed, 0, 1) {
+		return nil
+	}
+	if c.batcher != nil {
+		c.batcher.flush()
+	}
+	if c.ownTransport != nil {
+		// Closes the connections of the address transports of WithLoadBalancing too.
+		c.httpClient.(*http.Client).CloseIdleConnections()
+	}
+	var errs []error
+	if c.websocket != nil {
+		errs = append(errs, c.websocket.Close())
+	}
+	if c.cache != nil {
+		if closer, ok := c.cache.cache.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// isClosed reports whether Close was called.
+func (c *Client) isClosed() bool {
+	return atomic.LoadUint32(&c.closed) == 1
+}
+
+// flush sends the pending calls of b right away, and waits for their responses.
+// The calls added afterwards are sent right away too.
+func (b *windowBatcher) flush() {
+	b.mu.Lock()
+	b.closed = true
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	calls := b.take()
+	b.mu.Unlock()
+
+	if calls != nil {
+		b.c.flushBatch(context.Background(), calls)
+	}
+}
@@ -0,0 +1,128 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type closerCache struct {
+	*graphql.MemoryCache
+	closed bool
+}
+
+func (c *closerCache) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClient_Close(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}))
+	defer server.Close()
+	cache := &closerCache{MemoryCache: graphql.NewMemoryCache(0)}
+	started := make(chan struct{})
+	client := graphql.NewClient(server.URL, nil).
+		WithBatching(time.Hour, 0).
+		WithCache(cache, time.Minute)
+	client.Use(func(next graphql.OperationHandler) graphql.OperationHandler {
+		return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+			close(started)
+			return next(ctx, op)
+		}
+	})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Query(context.Background(), &q, nil)
+	}()
+	<-started
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the batched query wasn't sent by Close")
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got name: %q, want: %q", got, want)
+	}
+	if !cache.closed {
+		t.Error("the cache wasn't closed")
+	}
+
+	if err := client.Query(context.Background(), &q, nil); !errors.Is(err, graphql.ErrClientClosed) {
+		t.Errorf("got error after Close: %v, want: %v", err, graphql.ErrClientClosed)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("got error of the second Close: %v", err)
+	}
+}
+
+func TestClient_Close_webSocketTransport(t *testing.T) {
+	// The subscription client is closed before it runs.
+	sc := graphql.NewSubscriptionClient("ws://localhost:0")
+	client := graphql.NewClient("/graphql", nil).WithWebSocketTransport(sc)
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); !errors.Is(err, graphql.ErrClientClosed) {
+		t.Errorf("got error after Close: %v, want: %v", err, graphql.ErrClientClosed)
+	}
+}
+
+func TestClient_Close_sharedHTTPClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}))
+	defer server.Close()
+	// reused reports whether a request of hc to server reuses an idle connection.
+	reused := func(hc *http.Client) bool {
+		var reused bool
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { reused = info.Reused }}
+		req, err := http.NewRequestWithContext(httptrace.WithClientTrace(context.Background(), trace), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hc.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return reused
+	}
+
+	reused(http.DefaultClient)
+	if err := graphql.NewClient(server.URL, nil).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !reused(http.DefaultClient) {
+		t.Error("the idle connections of http.DefaultClient were closed")
+	}
+	http.DefaultClient.CloseIdleConnections()
+}
@@ -0,0 +1,66 @@
+// gqlclientgen generates Go query structs, variables structs and Execute methods
+// for the GraphQL operations of .graphql files, validated against a schema:
+//
+//	gqlclientgen -schema schema.graphql -package api -o operations.go user.graphql repository.graphql
+//
+// The schema is read from SDL, or from an introspection result if it has the
+// .json extension. It's meant to be run by go:generate directives.
+//
+// With -types, it generates every enum, input object and custom scalar of
+// the schema, along with the code of any operations:
+//
+//	gqlclientgen -schema schema.graphql -package types -o types.go -types
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/runtimeracer/go-graphql-client/codegen"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+func main() {
+	schemaPath := flag.String("schema", "schema.graphql", "schema file, in SDL or as introspection result (.json)")
+	output := flag.String("o", "operations.go", "output file, or - for standard output")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name of the generated code (default $GOPACKAGE)")
+	allTypes := flag.Bool("types", false, "generate every enum, input object and custom scalar of the schema")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: gqlclientgen [flags] operations.graphql...\n       gqlclientgen [flags] -types [operations.graphql...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 && !*allTypes || *pkg == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	log.SetFlags(0)
+	log.SetPrefix("gqlclientgen: ")
+
+	s, err := schema.Load(*schemaPath)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	var sources []codegen.Source
+	for _, path := range flag.Args() {
+		body, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		sources = append(sources, codegen.Source{Name: path, Body: string(body)})
+	}
+	code, err := codegen.Generate(s, sources, codegen.Config{Package: *pkg, AllTypes: *allTypes})
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if *output == "-" {
+		os.Stdout.Write(code)
+		return
+	}
+	if err := ioutil.WriteFile(*output, code, 0644); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,70 @@
+// graphql-schema downloads the schema of a GraphQL server by introspection,
+// and writes it in the GraphQL schema definition language:
+//
+//	graphql-schema -o schema.graphql -H "Authorization: Bearer $TOKEN" https://example.com/graphql
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+// headers is a flag.Value collecting "Key: Value" headers.
+type headers []string
+
+func (h *headers) String() string { return strings.Join(*h, ", ") }
+
+func (h *headers) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("header %q isn't of the form \"Key: Value\"", value)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+func main() {
+	var header headers
+	flag.Var(&header, "H", "header sent with the introspection query, as \"Key: Value\" (repeatable)")
+	output := flag.String("o", "schema.graphql", "output file, or - for standard output")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout of the introspection query")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: graphql-schema [flags] endpoint")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var options []graphql.Option
+	for _, h := range header {
+		kv := strings.SplitN(h, ":", 2)
+		options = append(options, graphql.WithRequestHeader(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])))
+	}
+	client := graphql.NewClient(flag.Arg(0), nil, options...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	var buf bytes.Buffer
+	if err := schema.Download(ctx, client, &buf); err != nil {
+		log.Fatalln(err)
+	}
+	if *output == "-" {
+		os.Stdout.Write(buf.Bytes())
+		return
+	}
+	if err := ioutil.WriteFile(*output, buf.Bytes(), 0644); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,18 @@
+// graphql-schemacheck checks the query structs of packages using package graphql
+// against a schema, as described by package schemacheck:
+//
+//	graphql-schemacheck -schema schema.graphql ./...
+//
+// It also runs as a go vet tool:
+//
+//	go vet -vettool=$(which graphql-schemacheck) -schema schema.graphql ./...
+package main
+
+import (
+	"github.com/runtimeracer/go-graphql-client/schemacheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(schemacheck.Analyzer)
+}
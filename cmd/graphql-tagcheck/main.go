@@ -0,0 +1,18 @@
+// graphql-tagcheck checks the graphql struct tags and query structs of packages
+// using package graphql, as described by package tagcheck:
+//
+//	graphql-tagcheck ./...
+//
+// It also runs as a go vet tool:
+//
+//	go vet -vettool=$(which graphql-tagcheck) ./...
+package main
+
+import (
+	"github.com/runtimeracer/go-graphql-client/tagcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(tagcheck.Analyzer)
+}
@@ -0,0 +1,54 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes request bodies and decodes the envelope of responses, for
+// Client.WithCodec. Codecs must handle json.RawMessage, json.Marshaler and
+// json.Unmarshaler like encoding/json does, e.g. jsoniter or sonic configured
+// to be compatible with the standard library.
+//
+// The data of responses is still populated into queries by the decoder of
+// the package, which follows the GraphQL selection rather than plain JSON.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// WithCodec makes the client encode and decode JSON with codec instead of encoding/json.
+func (c *Client) WithCodec(codec Codec) *Client {
+	c.codec = codec
+	return c
+}
+
+// jsonCodec is the default Codec, using encoding/json.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// encoder is implemented by codecs which write their encoding to a writer,
+// sparing the slice returned by Marshal.
+type encoder interface {
+	encode(w io.Writer, v interface{}) error
+}
+
+func (jsonCodec) encode(w io.Writer, v interface{}) error { return json.NewEncoder(w).Encode(v) }
+
+// encodeJSON writes v, encoded by codec, to w, followed by a newline.
+func encodeJSON(w io.Writer, codec Codec, v interface{}) error {
+	if e, ok := codec.(encoder); ok {
+		return e.encode(w, v)
+	}
+	b, err := codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		return err
+	}
+	_, err = w.Write([]byte{'\n'})
+	return err
+}
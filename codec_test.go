@@ -0,0 +1,54 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// countingCodec is a graphql.Codec using encoding/json, counting its calls.
+type countingCodec struct {
+	marshals, unmarshals int
+}
+
+func (c *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshals++
+	return json.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	c.unmarshals++
+	return json.Unmarshal(data, v)
+}
+
+func TestClient_WithCodec(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := mustRead(req.Body), `{"query":"query ($id:ID!){user(id: $id){name}}","variables":{"id":"1"}}`+"\n"; got != want {
+			t.Errorf("got body: %v, want %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	codec := &countingCodec{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithCodec(codec)
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	err := client.Query(context.Background(), &q, map[string]interface{}{"id": graphql.ID("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	if codec.marshals != 1 || codec.unmarshals != 1 {
+		t.Errorf("got %d marshals and %d unmarshals, want 1 and 1", codec.marshals, codec.unmarshals)
+	}
+}
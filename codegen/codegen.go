@@ -0,0 +1,734 @@
+// Package codegen generates Go code for the GraphQL operations of .graphql files:
+// query structs with the graphql tags selecting the fields of each operation,
+// typed variables structs, and Execute methods running them with a graphql.Client.
+// Operations are validated against a schema first, so that the generated code
+// only selects fields which exist, with arguments of the right types.
+//
+// It's used by the gqlclientgen command.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/ident"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+// Source is a file of GraphQL operations and fragments.
+type Source struct {
+	// Name is the name of the file, used in errors and comments.
+	Name string
+	Body string
+}
+
+// Config configures the generated code.
+type Config struct {
+	// Package is the name of the package of the generated code.
+	Package string
+	// AllTypes makes Generate generate every enum, input object and custom scalar
+	// of the schema, not only those used by the operations. It allows generating
+	// them without operations, e.g. into a package shared by others.
+	AllTypes bool
+}
+
+// graphqlPath is the import path of package graphql.
+const graphqlPath = "github.com/runtimeracer/go-graphql-client"
+
+// builtinScalars maps the scalars defined by the GraphQL specification to their Go types.
+var builtinScalars = map[string]string{
+	"String":  "graphql.String",
+	"Int":     "graphql.Int",
+	"Float":   "graphql.Float",
+	"Boolean": "graphql.Boolean",
+	"ID":      "graphql.ID",
+}
+
+// Generate returns the formatted Go code for the named queries and mutations of
+// sources, which are validated against s. Fragments may be shared by sources.
+//
+// For an operation GetUser, it generates the struct GetUserQuery selecting its fields,
+// the struct GetUserVariables holding its variables, with the Map method returning
+// them as passed to graphql.Client.NamedQuery, and the method GetUserQuery.Execute.
+// Enums, input objects and custom scalars used by the operations are generated
+// as named types, as the client derives the types of variables from the names
+// of their Go types. Enums have a constant for each value, e.g. OrderAsc,
+// and the nullable fields of input objects are graphql.Optional values.
+// Custom scalars are decoded as strings.
+func Generate(s *schema.Schema, sources []Source, cfg Config) ([]byte, error) {
+	introspection, err := s.Introspection()
+	if err != nil {
+		return nil, err
+	}
+	g := &generator{
+		validator: s,
+		schema:    introspection,
+		fragments: make(map[string]*fragment),
+		named:     make(map[string]bool),
+		declared:  make(map[string]string),
+		imports:   make(map[string]bool),
+	}
+
+	type sourceOperation struct {
+		*operation
+		source *Source
+	}
+	var operations []sourceOperation
+	for i := range sources {
+		src := &sources[i]
+		doc, err := parse(src.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%v", src.Name, err)
+		}
+		for _, op := range doc.operations {
+			operations = append(operations, sourceOperation{op, src})
+		}
+		for _, f := range doc.fragments {
+			if g.fragments[f.name] != nil {
+				line, column := position(src.Body, f.pos)
+				return nil, fmt.Errorf("%s:%d:%d: fragment %s is defined more than once", src.Name, line, column, f.name)
+			}
+			g.fragments[f.name] = f
+		}
+	}
+	if len(operations) == 0 && !cfg.AllTypes {
+		return nil, fmt.Errorf("no operations to generate code for")
+	}
+
+	for _, op := range operations {
+		if err := g.operation(op.source, op.operation); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.AllTypes {
+		for i := range introspection.Types {
+			t := &introspection.Types[i]
+			if !strings.HasPrefix(t.Name, "__") && (t.Kind == graphql.TypeKindEnum || t.Kind == graphql.TypeKindInputObject || t.Kind == graphql.TypeKindScalar) {
+				g.namedType(t)
+			}
+		}
+	}
+	if err := g.namedTypes(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gqlclientgen. DO NOT EDIT.\n\npackage %s\n", cfg.Package)
+	if len(g.imports) > 0 {
+		var std, other []string
+		for path := range g.imports {
+			if strings.Contains(path, ".") {
+				other = append(other, path)
+			} else {
+				std = append(std, path)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(other)
+		b.WriteString("\nimport (\n")
+		for _, path := range std {
+			fmt.Fprintf(&b, "%q\n", path)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			b.WriteString("\n")
+		}
+		for _, path := range other {
+			fmt.Fprintf(&b, "%q\n", path)
+		}
+		b.WriteString(")\n")
+	}
+	b.Write(g.b.Bytes())
+
+	code, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return code, nil
+}
+
+type generator struct {
+	validator *schema.Schema
+	schema    *graphql.Schema
+	fragments map[string]*fragment
+	// named holds the enums, input objects and custom scalars to generate.
+	named map[string]bool
+	// declared maps the declared Go type names to what they were declared for.
+	declared map[string]string
+	// imports holds the import paths used by the generated code.
+	imports map[string]bool
+	b       bytes.Buffer
+}
+
+func (g *generator) operation(src *Source, op *operation) error {
+	line, column := position(src.Body, op.pos)
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%s:%d:%d: %s", src.Name, line, column, fmt.Sprintf(format, args...))
+	}
+
+	if op.name == "" {
+		return errorf("operations must be named")
+	}
+	var root *graphql.TypeRef
+	var method, suffix string
+	switch op.typ {
+	case graphql.QueryOperation:
+		root, method, suffix = g.schema.QueryType, "NamedQuery", "Query"
+	case graphql.MutationOperation:
+		root, method, suffix = g.schema.MutationType, "NamedMutate", "Mutation"
+	default:
+		return errorf("%s operations are not supported", op.typ)
+	}
+	if root == nil {
+		return errorf("the schema has no %s type", op.typ)
+	}
+	if len(op.directives) > 0 {
+		return errorf("directives on operations are not supported")
+	}
+	g.imports["context"] = true
+	g.imports[graphqlPath] = true
+
+	document, err := g.validationDocument(op)
+	if err != nil {
+		return errorf("%v", err)
+	}
+	if err := g.validator.Validate(document); err != nil {
+		return errorf("%s %s: %v", op.typ, op.name, err)
+	}
+
+	base := strings.TrimSuffix(goName(op.name), suffix)
+	structName := base + suffix
+	variablesName := base + "Variables"
+	if err := g.declare(structName, op.typ.String()+" "+op.name); err != nil {
+		return errorf("%v", err)
+	}
+	if len(op.variables) > 0 {
+		if err := g.declare(variablesName, "variables of "+op.typ.String()+" "+op.name); err != nil {
+			return errorf("%v", err)
+		}
+	}
+
+	fmt.Fprintf(&g.b, "\n// %s is the result of the %s %s of %s.\n", structName, op.name, op.typ, filepath.Base(src.Name))
+	typ, err := g.selectionStruct(root.Name, op.selectionSet)
+	if err != nil {
+		return errorf("%s %s: %v", op.typ, op.name, err)
+	}
+	fmt.Fprintf(&g.b, "type %s %s\n", structName, typ)
+
+	variables := "nil"
+	var variablesParam string
+	if len(op.variables) > 0 {
+		if err := g.variables(variablesName, op.typ.String()+" "+op.name, method, op.variables); err != nil {
+			return errorf("%s %s: %v", op.typ, op.name, err)
+		}
+		variables = "variables.Map()"
+		variablesParam = "variables " + variablesName + ", "
+	}
+	fmt.Fprintf(&g.b, "\n// Execute executes the %s %s with client, storing the result in q.\n", op.name, op.typ)
+	fmt.Fprintf(&g.b, "func (q *%s) Execute(ctx context.Context, client *graphql.Client, %soptions ...graphql.Option) error {\n", structName, variablesParam)
+	fmt.Fprintf(&g.b, "return client.%s(ctx, %q, q, %s, options...)\n}\n", method, op.name, variables)
+	return nil
+}
+
+// declare records the declaration of the Go type name for what,
+// failing if it's already declared for something else.
+func (g *generator) declare(name, what string) error {
+	if other, ok := g.declared[name]; ok {
+		return fmt.Errorf("the Go type %s generated for %s is already generated for %s", name, what, other)
+	}
+	g.declared[name] = what
+	return nil
+}
+
+// validationDocument returns the document of op with the fragments it uses.
+func (g *generator) validationDocument(op *operation) (string, error) {
+	used := make(map[string]bool)
+	var walk func(selections []selection) error
+	walk = func(selections []selection) error {
+		for _, sel := range selections {
+			switch sel := sel.(type) {
+			case *field:
+				if err := walk(sel.selectionSet); err != nil {
+					return err
+				}
+			case *inlineFragment:
+				if err := walk(sel.selectionSet); err != nil {
+					return err
+				}
+			case *fragmentSpread:
+				f := g.fragments[sel.name]
+				if f == nil {
+					return fmt.Errorf("unknown fragment %s", sel.name)
+				}
+				if !used[sel.name] {
+					used[sel.name] = true
+					if err := walk(f.selectionSet); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(op.selectionSet); err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := []string{op.source}
+	for _, name := range names {
+		parts = append(parts, g.fragments[name].source)
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// member is a field or fragment of a selection set, with the selections merged
+// from every occurrence of the same response key or fragment.
+type member struct {
+	field *field
+	// typeCondition and directives describe fragments, for which field is nil.
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+}
+
+// key returns the response key of a field, or the selection of a fragment.
+func (m *member) key() string {
+	if m.field != nil {
+		return m.field.responseKey()
+	}
+	s := "..."
+	if m.typeCondition != "" {
+		s += " on " + m.typeCondition
+	}
+	return s + directivesString(m.directives)
+}
+
+// selection returns what's written into the query for m.
+func (m *member) selection() string {
+	if m.field == nil {
+		return m.key()
+	}
+	f := m.field
+	s := f.name
+	if f.alias != "" {
+		s = f.alias + ": " + s
+	}
+	if len(f.arguments) > 0 {
+		s += "(" + argumentsString(f.arguments) + ")"
+	}
+	return s + directivesString(f.directives)
+}
+
+// members returns the members of the selection set on the type parentType,
+// inlining fragments on parentType without directives.
+func (g *generator) members(parentType string, selections []selection) []*member {
+	var members []*member
+	index := make(map[string]*member)
+	var add func(selections []selection)
+	add = func(selections []selection) {
+		for _, sel := range selections {
+			m := &member{}
+			switch sel := sel.(type) {
+			case *field:
+				m.field, m.selectionSet = sel, sel.selectionSet
+			case *inlineFragment:
+				if (sel.typeCondition == "" || sel.typeCondition == parentType) && len(sel.directives) == 0 {
+					add(sel.selectionSet)
+					continue
+				}
+				m.typeCondition, m.directives, m.selectionSet = sel.typeCondition, sel.directives, sel.selectionSet
+			case *fragmentSpread:
+				f := g.fragments[sel.name]
+				if f.typeCondition == parentType && len(sel.directives) == 0 {
+					add(f.selectionSet)
+					continue
+				}
+				m.typeCondition, m.directives, m.selectionSet = f.typeCondition, sel.directives, f.selectionSet
+			}
+			if existing := index[m.key()]; existing != nil {
+				existing.selectionSet = append(existing.selectionSet[:len(existing.selectionSet):len(existing.selectionSet)], m.selectionSet...)
+				continue
+			}
+			index[m.key()] = m
+			members = append(members, m)
+		}
+	}
+	add(selections)
+	return members
+}
+
+// selectionStruct returns the Go struct type selecting selections on the type parentType.
+func (g *generator) selectionStruct(parentType string, selections []selection) (string, error) {
+	parent := g.schema.Type(parentType)
+	if parent == nil {
+		return "", fmt.Errorf("unknown type %s", parentType)
+	}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	names := make(map[string]bool)
+	for _, m := range g.members(parentType, selections) {
+		var name, typ string
+		var err error
+		if m.field == nil {
+			name = "Fragment"
+			fragmentType := parentType
+			if m.typeCondition != "" {
+				name, fragmentType = "On"+goName(m.typeCondition), m.typeCondition
+			}
+			typ, err = g.selectionStruct(fragmentType, m.selectionSet)
+		} else {
+			name = goName(m.field.responseKey())
+			var t graphql.TypeRef
+			t, err = fieldType(parent, m.field.name)
+			if err == nil {
+				typ, err = g.outputType(t, m.selectionSet, false)
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+
+		unique := name
+		for i := 2; names[unique]; i++ {
+			unique = name + strconv.Itoa(i)
+		}
+		names[unique] = true
+		b.WriteString(unique + " " + typ)
+		if selection := m.selection(); !untagged(unique, selection) {
+			b.WriteString(" " + tag("graphql", selection))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String(), nil
+}
+
+// untagged reports whether the Go field name selects selection without a graphql tag.
+func untagged(name, selection string) bool {
+	if name == "Typename" {
+		return selection == "__typename"
+	}
+	return ident.ParseMixedCaps(name).ToLowerCamelCase() == selection
+}
+
+// tag returns the struct tag with key and value.
+func tag(key, value string) string {
+	t := key + ":" + strconv.Quote(value)
+	if strings.Contains(t, "`") {
+		return strconv.Quote(t)
+	}
+	return "`" + t + "`"
+}
+
+// fieldType returns the type of the field name of parent.
+func fieldType(parent *graphql.Type, name string) (graphql.TypeRef, error) {
+	if name == "__typename" {
+		return graphql.TypeRef{Kind: graphql.TypeKindNonNull, OfType: &graphql.TypeRef{Kind: graphql.TypeKindScalar, Name: "String"}}, nil
+	}
+	for _, f := range parent.Fields {
+		if f.Name == name {
+			return f.Type, nil
+		}
+	}
+	return graphql.TypeRef{}, fmt.Errorf("no field %s on type %s", name, parent.Name)
+}
+
+// outputType returns the Go type decoding values of type t, selecting selections.
+// Nullable values are decoded to pointers, except lists, whose nil slices represent null.
+func (g *generator) outputType(t graphql.TypeRef, selections []selection, nonNull bool) (string, error) {
+	switch t.Kind {
+	case graphql.TypeKindNonNull:
+		return g.outputType(*t.OfType, selections, true)
+	case graphql.TypeKindList:
+		elem, err := g.outputType(*t.OfType, selections, false)
+		return "[]" + elem, err
+	}
+	named := g.schema.Type(t.Name)
+	if named == nil {
+		return "", fmt.Errorf("unknown type %s", t.Name)
+	}
+	var typ string
+	switch named.Kind {
+	case graphql.TypeKindObject, graphql.TypeKindInterface, graphql.TypeKindUnion:
+		var err error
+		if typ, err = g.selectionStruct(named.Name, selections); err != nil {
+			return "", err
+		}
+	default:
+		typ = g.namedType(named)
+	}
+	if !nonNull {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// inputType returns the Go type of variables and input fields of type t.
+// Nullable values are pointers, so that the client declares nullable variables.
+func (g *generator) inputType(t graphql.TypeRef, nonNull bool) (string, error) {
+	var typ string
+	switch t.Kind {
+	case graphql.TypeKindNonNull:
+		return g.inputType(*t.OfType, true)
+	case graphql.TypeKindList:
+		elem, err := g.inputType(*t.OfType, false)
+		if err != nil {
+			return "", err
+		}
+		typ = "[]" + elem
+	default:
+		named := g.schema.Type(t.Name)
+		if named == nil {
+			return "", fmt.Errorf("unknown type %s", t.Name)
+		}
+		typ = g.namedType(named)
+	}
+	if !nonNull {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// namedType returns the Go type of the scalar, enum or input object t,
+// recording it to be generated unless it's a built-in scalar.
+func (g *generator) namedType(t *graphql.Type) string {
+	if typ, ok := builtinScalars[t.Name]; ok && t.Kind == graphql.TypeKindScalar {
+		g.imports[graphqlPath] = true
+		return typ
+	}
+	g.named[t.Name] = true
+	return t.Name
+}
+
+func (g *generator) variables(name, what, method string, variables []*variable) error {
+	type goVariable struct {
+		*variable
+		field, typ, zero string
+	}
+	var vars []goVariable
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n// %s holds the variables of the %s.\n", name, what)
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, v := range variables {
+		typ, err := g.inputType(v.typ, false)
+		if err != nil {
+			return err
+		}
+		gv := goVariable{variable: v, field: goName(v.name), typ: typ}
+		if v.defaultValue != nil && v.defaultValue.kind != nullValue {
+			if v.typ.Kind == graphql.TypeKindNonNull {
+				return fmt.Errorf("default value of non-null variable $%s is not supported", v.name)
+			}
+			if gv.zero, err = g.literal(strings.TrimPrefix(typ, "*"), v.defaultValue); err != nil {
+				return fmt.Errorf("default value of variable $%s: %v", v.name, err)
+			}
+			fmt.Fprintf(&b, "// %s defaults to %s.\n", gv.field, v.defaultValue)
+		}
+		fmt.Fprintf(&b, "%s %s\n", gv.field, typ)
+		vars = append(vars, gv)
+	}
+	b.WriteString("}\n")
+
+	fmt.Fprintf(&b, "\n// Map returns the variables as passed to graphql.Client.%s.\n", method)
+	fmt.Fprintf(&b, "func (v %s) Map() map[string]interface{} {\n", name)
+	b.WriteString("m := map[string]interface{}{\n")
+	for _, v := range vars {
+		fmt.Fprintf(&b, "%q: v.%s,\n", v.name, v.field)
+	}
+	b.WriteString("}\n")
+	for _, v := range vars {
+		if v.zero != "" {
+			fmt.Fprintf(&b, "if v.%s == nil {\nvalue := %s\nm[%q] = &value\n}\n", v.field, v.zero, v.name)
+		}
+	}
+	b.WriteString("return m\n}\n")
+	g.b.WriteString(b.String())
+	return nil
+}
+
+// literal returns the Go expression of the constant value v of the Go type typ.
+func (g *generator) literal(typ string, v *value) (string, error) {
+	switch v.kind {
+	case intValue, floatValue, booleanValue:
+		return typ + "(" + v.raw + ")", nil
+	case stringValue:
+		return typ + "(" + strconv.Quote(v.raw) + ")", nil
+	case enumValue:
+		return typ + "(" + strconv.Quote(v.raw) + ")", nil
+	}
+	return "", fmt.Errorf("%s is not supported", v)
+}
+
+// namedTypes generates the recorded enums, input objects and custom scalars,
+// along with the types used by the input objects, sorted by name.
+func (g *generator) namedTypes() error {
+	for n := 0; n != len(g.named); {
+		n = len(g.named)
+		for name := range g.named {
+			for _, f := range g.schema.Type(name).InputFields {
+				if t := g.schema.Type(f.Type.NamedType()); t != nil {
+					g.namedType(t)
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(g.named))
+	for name := range g.named {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := g.namedTypeDecl(g.schema.Type(name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *generator) namedTypeDecl(t *graphql.Type) error {
+	kind := map[graphql.TypeKind]string{
+		graphql.TypeKindScalar:      "scalar",
+		graphql.TypeKindEnum:        "enum",
+		graphql.TypeKindInputObject: "input object",
+	}[t.Kind]
+	if err := g.declare(t.Name, kind+" "+t.Name); err != nil {
+		return err
+	}
+	fmt.Fprintf(&g.b, "\n// %s is the %s %s.\n", t.Name, kind, t.Name)
+	if t.Description != "" {
+		g.b.WriteString("//\n")
+		writeDescription(&g.b, t.Description)
+	}
+	switch t.Kind {
+	case graphql.TypeKindScalar:
+		fmt.Fprintf(&g.b, "type %s string\n", t.Name)
+	case graphql.TypeKindEnum:
+		return g.enum(t)
+	case graphql.TypeKindInputObject:
+		return g.inputObject(t)
+	}
+	return nil
+}
+
+// enum generates the enum t, with a constant for each value.
+func (g *generator) enum(t *graphql.Type) error {
+	fmt.Fprintf(&g.b, "type %s string\n", t.Name)
+	if len(t.EnumValues) == 0 {
+		return nil
+	}
+	fmt.Fprintf(&g.b, "\n// Values of %s.\nconst (\n", t.Name)
+	for _, v := range t.EnumValues {
+		name := t.Name + ident.ParseScreamingSnakeCase(v.Name).ToMixedCaps()
+		if err := g.declare(name, "value "+v.Name+" of enum "+t.Name); err != nil {
+			return err
+		}
+		writeDescription(&g.b, v.Description)
+		writeDeprecation(&g.b, v.Description, v.IsDeprecated, v.DeprecationReason)
+		fmt.Fprintf(&g.b, "%s %s = %q\n", name, t.Name, v.Name)
+	}
+	g.b.WriteString(")\n")
+	return nil
+}
+
+// inputObject generates the input object t. Its nullable fields are optional,
+// and left out when unset by a MarshalJSON method.
+func (g *generator) inputObject(t *graphql.Type) error {
+	type goField struct {
+		name, field string
+		optional    bool
+	}
+	var fields []goField
+	fmt.Fprintf(&g.b, "type %s struct {\n", t.Name)
+	for _, f := range t.InputFields {
+		gf := goField{name: f.Name, field: goName(f.Name), optional: f.Type.Kind != graphql.TypeKindNonNull}
+		var typ string
+		var err error
+		if gf.optional {
+			typ, err = g.inputType(f.Type, true)
+			typ = "graphql.Optional[" + typ + "]"
+			g.imports[graphqlPath] = true
+		} else {
+			typ, err = g.inputType(f.Type, false)
+		}
+		if err != nil {
+			return err
+		}
+		writeDescription(&g.b, f.Description)
+		if f.DefaultValue != nil {
+			fmt.Fprintf(&g.b, "// %s defaults to %s.\n", gf.field, *f.DefaultValue)
+		}
+		fmt.Fprintf(&g.b, "%s %s %s\n", gf.field, typ, tag("json", f.Name))
+		fields = append(fields, gf)
+	}
+	g.b.WriteString("}\n")
+
+	optional := false
+	for _, f := range fields {
+		optional = optional || f.optional
+	}
+	if !optional {
+		return nil
+	}
+	g.imports["encoding/json"] = true
+	g.b.WriteString("\n// MarshalJSON implements json.Marshaler, leaving out the unset optional fields.\n")
+	fmt.Fprintf(&g.b, "func (v %s) MarshalJSON() ([]byte, error) {\n", t.Name)
+	fmt.Fprintf(&g.b, "m := make(map[string]interface{}, %d)\n", len(fields))
+	for _, f := range fields {
+		if f.optional {
+			fmt.Fprintf(&g.b, "if v.%s.IsSet() {\nm[%q] = v.%[1]s\n}\n", f.field, f.name)
+		} else {
+			fmt.Fprintf(&g.b, "m[%q] = v.%s\n", f.name, f.field)
+		}
+	}
+	g.b.WriteString("return json.Marshal(m)\n}\n")
+	return nil
+}
+
+// writeDeprecation writes the deprecation of a schema element as a comment paragraph.
+func writeDeprecation(b *bytes.Buffer, description string, deprecated bool, reason *string) {
+	if !deprecated {
+		return
+	}
+	if description != "" {
+		b.WriteString("//\n")
+	}
+	message := "Deprecated."
+	if reason != nil && *reason != "" {
+		message = "Deprecated: " + *reason
+	}
+	b.WriteString("// " + message + "\n")
+}
+
+// writeDescription writes the description of a schema element as a comment paragraph.
+func writeDescription(b *bytes.Buffer, description string) {
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(description, "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+}
+
+// goName returns the exported Go name for the GraphQL name, e.g. "DatabaseID" for "databaseId".
+func goName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(ident.ParseLowerCamelCase(part).ToMixedCaps())
+		}
+	}
+	s := b.String()
+	if s == "" || '0' <= s[0] && s[0] <= '9' {
+		s = "X" + s
+	}
+	return s
+}
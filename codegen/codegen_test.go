@@ -0,0 +1,145 @@
+package codegen_test
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/codegen"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+func loadSchema(t *testing.T) *schema.Schema {
+	t.Helper()
+	s, err := schema.Load("testdata/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// TestGenerate checks that the generated package internal/testapi is up to date.
+func TestGenerate(t *testing.T) {
+	operations, err := ioutil.ReadFile("testdata/operations.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := codegen.Generate(loadSchema(t), []codegen.Source{{Name: "testdata/operations.graphql", Body: string(operations)}}, codegen.Config{Package: "testapi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("internal/testapi/operations.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated code differs from internal/testapi/operations.go, run go generate ./...:\n%s", got)
+	}
+}
+
+func TestGenerate_tags(t *testing.T) {
+	source := `query Tags($withName: Boolean!) {
+  me: user(id: "1") {
+    name @include(if: $withName)
+    repositories(first: 1, orderBy: DESC) { name }
+    ... on Node { id }
+  }
+}`
+	got, err := codegen.Generate(loadSchema(t), []codegen.Source{{Name: "tags.graphql", Body: source}}, codegen.Config{Package: "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Me *struct {",
+		"`graphql:\"me: user(id: \\\"1\\\")\"`",
+		"graphql.String `graphql:\"name @include(if: $withName)\"`",
+		"`graphql:\"repositories(first: 1, orderBy: DESC)\"`",
+		"OnNode struct {",
+		"`graphql:\"... on Node\"`",
+		"WithName graphql.Boolean\n",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("generated code doesn't contain %s:\n%s", want, got)
+		}
+	}
+}
+
+func TestGenerate_errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "syntax error",
+			source: "query Q {\n  user(id: 1 {\n}",
+			want:   `ops.graphql:2:14: expected name, found "{"`,
+		},
+		{
+			name:   "unknown field",
+			source: `query Q { user(id: "1") { email } }`,
+			want:   `ops.graphql:1:1: query Q: Cannot query field "email" on type "User".`,
+		},
+		{
+			name:   "anonymous operation",
+			source: `{ now }`,
+			want:   "ops.graphql:1:1: operations must be named",
+		},
+		{
+			name:   "subscription",
+			source: `subscription S { now }`,
+			want:   "ops.graphql:1:1: subscription operations are not supported",
+		},
+		{
+			name:   "unknown fragment",
+			source: `query Q { user(id: "1") { ...Missing } }`,
+			want:   "ops.graphql:1:1: unknown fragment Missing",
+		},
+		{
+			name:   "name collision",
+			source: "query Order { now }\nquery OrderQuery { now }",
+			want:   "ops.graphql:2:1: the Go type OrderQuery generated for query OrderQuery is already generated for query Order",
+		},
+		{
+			name:   "no operations",
+			source: "fragment F on User { id }",
+			want:   "no operations to generate code for",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := codegen.Generate(loadSchema(t), []codegen.Source{{Name: "ops.graphql", Body: tc.source}}, codegen.Config{Package: "api"})
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if got := err.Error(); got != tc.want {
+				t.Errorf("got error:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerate_allTypes(t *testing.T) {
+	got, err := codegen.Generate(loadSchema(t), nil, codegen.Config{Package: "types", AllTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"type DateTime string",
+		"type Order string",
+		`OrderDesc Order = "DESC"`,
+		"// Deprecated: Use ASC.\n",
+		"type RenameInput struct {",
+		"Reason graphql.Optional[graphql.String]",
+		"func (v RenameInput) MarshalJSON() ([]byte, error) {",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("generated code doesn't contain %s:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{`"context"`, "type User", "SearchResult"} {
+		if strings.Contains(string(got), unwanted) {
+			t.Errorf("generated code contains %s:\n%s", unwanted, got)
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package testapi holds the code generated for the operations of codegen/testdata,
+// to check that it compiles and works with the client.
+package testapi
+
+//go:generate go run ../../../cmd/gqlclientgen -schema ../../testdata/schema.graphql -o operations.go ../../testdata/operations.graphql
@@ -0,0 +1,80 @@
+package testapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/codegen/internal/testapi"
+)
+
+func TestGetUserQuery_Execute(t *testing.T) {
+	var got struct {
+		Query         string
+		Variables     map[string]interface{}
+		OperationName string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data": {"user": {"id": "1", "name": "Gopher", "repositories": [{"id": "2", "name": "go"}]}}}`)
+	}))
+	defer server.Close()
+	client := graphql.NewClient(server.URL, server.Client())
+
+	var q testapi.GetUserQuery
+	if err := q.Execute(context.Background(), client, testapi.GetUserVariables{ID: graphql.ID("1")}); err != nil {
+		t.Fatal(err)
+	}
+	if want := `query GetUser($first:Int$id:ID!$order:Order){user(id: $id){id,name,repositories(first: $first, orderBy: $order){id,name}}}`; got.Query != want {
+		t.Errorf("got query:\n%s\nwant:\n%s", got.Query, want)
+	}
+	if got.Variables["first"] != 10.0 || got.Variables["id"] != "1" || got.Variables["order"] != nil {
+		t.Errorf("got variables %v, want first 10, id 1 and order null", got.Variables)
+	}
+	if q.User == nil || q.User.Name != "Gopher" || len(q.User.Repositories) != 1 || q.User.Repositories[0].Name != "go" {
+		t.Errorf("got user %+v", q.User)
+	}
+}
+
+func TestSearchQuery_Execute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data": {"search": [{"__typename": "User", "name": "Gopher"}, {"__typename": "Repository", "repositoryName": "go"}], "now": "2020-01-02T03:04:05Z"}}`)
+	}))
+	defer server.Close()
+	client := graphql.NewClient(server.URL, server.Client())
+
+	var q testapi.SearchQuery
+	if err := q.Execute(context.Background(), client, testapi.SearchVariables{Text: "go"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Search) != 2 || q.Search[0].Typename != "User" || q.Search[0].OnUser.Name != "Gopher" || q.Search[1].OnRepository.RepositoryName != "go" {
+		t.Errorf("got search results %+v", q.Search)
+	}
+	if q.Now != "2020-01-02T03:04:05Z" {
+		t.Errorf("got now %q", q.Now)
+	}
+}
+
+func TestRenameInput_MarshalJSON(t *testing.T) {
+	input := testapi.RenameInput{
+		ID:     graphql.ID("1"),
+		Name:   "Gopher",
+		Reason: graphql.OptionalNull[graphql.String](),
+		Order:  graphql.OptionalOf(testapi.OrderDesc),
+	}
+	got, err := json.Marshal(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":"1","name":"Gopher","order":"DESC","reason":null}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,676 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// document is a parsed executable GraphQL document.
+type document struct {
+	operations []*operation
+	fragments  []*fragment
+}
+
+// operation is an operation definition, e.g. `query GetUser($login: String!) { ... }`.
+type operation struct {
+	typ          graphql.OperationType
+	name         string
+	variables    []*variable
+	directives   []*directive
+	selectionSet []selection
+	pos          int
+	// source is the text of the operation in the document.
+	source string
+}
+
+// variable is a variable definition of an operation.
+type variable struct {
+	name         string
+	typ          graphql.TypeRef
+	defaultValue *value
+	pos          int
+}
+
+// fragment is a fragment definition, e.g. `fragment UserFields on User { ... }`.
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+	pos           int
+	source        string
+}
+
+// selection is a *field, *fragmentSpread or *inlineFragment.
+type selection interface{}
+
+// field is a field selection, e.g. `owner: user(login: $login) @include(if: $withOwner) { ... }`.
+type field struct {
+	alias        string
+	name         string
+	arguments    []*argument
+	directives   []*directive
+	selectionSet []selection
+}
+
+// responseKey returns the key of the field in the response.
+func (f *field) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// fragmentSpread is a named fragment spread, e.g. `...UserFields`.
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	pos        int
+}
+
+// inlineFragment is an inline fragment, e.g. `... on User { ... }`.
+type inlineFragment struct {
+	typeCondition string
+	directives    []*directive
+	selectionSet  []selection
+}
+
+type argument struct {
+	name  string
+	value *value
+}
+
+type directive struct {
+	name      string
+	arguments []*argument
+}
+
+type valueKind int
+
+const (
+	variableValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	booleanValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+// value is an input value. raw holds the variable name, the number, the decoded
+// string, "true" or "false", or the enum value, depending on kind.
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []*value
+	fields []*argument
+}
+
+// String returns v in GraphQL syntax.
+func (v *value) String() string {
+	switch v.kind {
+	case variableValue:
+		return "$" + v.raw
+	case stringValue:
+		return quote(v.raw)
+	case nullValue:
+		return "null"
+	case listValue:
+		items := make([]string, len(v.list))
+		for i, item := range v.list {
+			items[i] = item.String()
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case objectValue:
+		return "{" + argumentsString(v.fields) + "}"
+	}
+	return v.raw
+}
+
+func argumentsString(args []*argument) string {
+	s := make([]string, len(args))
+	for i, arg := range args {
+		s[i] = arg.name + ": " + arg.value.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func directivesString(directives []*directive) string {
+	var b strings.Builder
+	for _, d := range directives {
+		b.WriteString(" @" + d.name)
+		if len(d.arguments) > 0 {
+			b.WriteString("(" + argumentsString(d.arguments) + ")")
+		}
+	}
+	return b.String()
+}
+
+// quote returns s as a GraphQL string literal.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// syntaxError is a syntax error at byte offset pos of a document.
+type syntaxError struct {
+	pos int
+	msg string
+}
+
+// position returns the 1-based line and column of byte offset pos in src.
+func position(src string, pos int) (line, column int) {
+	line = 1 + strings.Count(src[:pos], "\n")
+	column = 1 + utf8.RuneCountInString(src[strings.LastIndex(src[:pos], "\n")+1:pos])
+	return line, column
+}
+
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	punctuatorToken
+	nameToken
+	intToken
+	floatToken
+	stringToken
+)
+
+type token struct {
+	kind tokenKind
+	// value is the punctuator, name or number, or the decoded string.
+	value string
+	pos   int
+}
+
+// parser is a recursive descent parser of executable documents.
+// It panics with a *syntaxError on errors, recovered by parse.
+type parser struct {
+	src string
+	// pos is the offset of the next token to be scanned.
+	pos int
+	tok token
+	// end is the offset of the end of the last consumed token.
+	end int
+}
+
+// parse parses the executable GraphQL document src.
+func parse(src string) (doc *document, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*syntaxError)
+			if !ok {
+				panic(r)
+			}
+			line, column := position(src, e.pos)
+			err = fmt.Errorf("%d:%d: %s", line, column, e.msg)
+		}
+	}()
+	p := &parser{src: src}
+	p.next()
+	doc = &document{}
+	for p.tok.kind != eofToken {
+		start := p.tok.pos
+		switch {
+		case p.peek(punctuatorToken, "{"):
+			op := &operation{typ: graphql.QueryOperation, pos: start}
+			op.selectionSet = p.parseSelectionSet()
+			op.source = src[start:p.end]
+			doc.operations = append(doc.operations, op)
+		case p.peek(nameToken, "query"), p.peek(nameToken, "mutation"), p.peek(nameToken, "subscription"):
+			op := p.parseOperation()
+			op.source = src[start:p.end]
+			doc.operations = append(doc.operations, op)
+		case p.peek(nameToken, "fragment"):
+			f := p.parseFragment()
+			f.source = src[start:p.end]
+			doc.fragments = append(doc.fragments, f)
+		default:
+			p.errorf("expected operation or fragment definition, found %s", p.describe())
+		}
+	}
+	return doc, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) {
+	panic(&syntaxError{pos: p.tok.pos, msg: fmt.Sprintf(format, args...)})
+}
+
+// describe describes the current token for error messages.
+func (p *parser) describe() string {
+	switch p.tok.kind {
+	case eofToken:
+		return "end of document"
+	case stringToken:
+		return "string " + quote(p.tok.value)
+	}
+	return strconv.Quote(p.tok.value)
+}
+
+// peek reports whether the current token is of kind with value.
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+// skip consumes the current token if it's the punctuator value, and reports whether it was.
+func (p *parser) skip(value string) bool {
+	if !p.peek(punctuatorToken, value) {
+		return false
+	}
+	p.next()
+	return true
+}
+
+func (p *parser) expect(value string) {
+	if !p.skip(value) {
+		p.errorf("expected %q, found %s", value, p.describe())
+	}
+}
+
+func (p *parser) expectKeyword(keyword string) {
+	if !p.peek(nameToken, keyword) {
+		p.errorf("expected %q, found %s", keyword, p.describe())
+	}
+	p.next()
+}
+
+func (p *parser) parseName() string {
+	if p.tok.kind != nameToken {
+		p.errorf("expected name, found %s", p.describe())
+	}
+	name := p.tok.value
+	p.next()
+	return name
+}
+
+var operationTypes = map[string]graphql.OperationType{
+	"query":        graphql.QueryOperation,
+	"mutation":     graphql.MutationOperation,
+	"subscription": graphql.SubscriptionOperation,
+}
+
+func (p *parser) parseOperation() *operation {
+	op := &operation{typ: operationTypes[p.tok.value], pos: p.tok.pos}
+	p.next()
+	if p.tok.kind == nameToken {
+		op.name = p.parseName()
+	}
+	if p.skip("(") {
+		for !p.skip(")") {
+			v := &variable{pos: p.tok.pos}
+			p.expect("$")
+			v.name = p.parseName()
+			p.expect(":")
+			v.typ = p.parseType()
+			if p.skip("=") {
+				v.defaultValue = p.parseValue(true)
+			}
+			p.parseDirectives() // Directives on variables don't affect the generated code.
+			op.variables = append(op.variables, v)
+		}
+	}
+	op.directives = p.parseDirectives()
+	op.selectionSet = p.parseSelectionSet()
+	return op
+}
+
+func (p *parser) parseFragment() *fragment {
+	f := &fragment{pos: p.tok.pos}
+	p.expectKeyword("fragment")
+	if p.peek(nameToken, "on") {
+		p.errorf("expected fragment name, found %s", p.describe())
+	}
+	f.name = p.parseName()
+	p.expectKeyword("on")
+	f.typeCondition = p.parseName()
+	f.directives = p.parseDirectives()
+	f.selectionSet = p.parseSelectionSet()
+	return f
+}
+
+func (p *parser) parseType() graphql.TypeRef {
+	var t graphql.TypeRef
+	if p.skip("[") {
+		elem := p.parseType()
+		p.expect("]")
+		t = graphql.TypeRef{Kind: graphql.TypeKindList, OfType: &elem}
+	} else {
+		t = graphql.TypeRef{Name: p.parseName()}
+	}
+	if p.skip("!") {
+		nullable := t
+		t = graphql.TypeRef{Kind: graphql.TypeKindNonNull, OfType: &nullable}
+	}
+	return t
+}
+
+func (p *parser) parseSelectionSet() []selection {
+	p.expect("{")
+	var selections []selection
+	for !p.skip("}") {
+		selections = append(selections, p.parseSelection())
+	}
+	if len(selections) == 0 {
+		p.errorf("empty selection set")
+	}
+	return selections
+}
+
+func (p *parser) parseSelection() selection {
+	if pos := p.tok.pos; p.skip("...") {
+		if p.tok.kind == nameToken && p.tok.value != "on" {
+			return &fragmentSpread{name: p.parseName(), directives: p.parseDirectives(), pos: pos}
+		}
+		f := &inlineFragment{}
+		if p.peek(nameToken, "on") {
+			p.next()
+			f.typeCondition = p.parseName()
+		}
+		f.directives = p.parseDirectives()
+		f.selectionSet = p.parseSelectionSet()
+		return f
+	}
+	f := &field{name: p.parseName()}
+	if p.skip(":") {
+		f.alias, f.name = f.name, p.parseName()
+	}
+	f.arguments = p.parseArguments(false)
+	f.directives = p.parseDirectives()
+	if p.peek(punctuatorToken, "{") {
+		f.selectionSet = p.parseSelectionSet()
+	}
+	return f
+}
+
+func (p *parser) parseArguments(constant bool) []*argument {
+	if !p.skip("(") {
+		return nil
+	}
+	var args []*argument
+	for !p.skip(")") {
+		arg := &argument{name: p.parseName()}
+		p.expect(":")
+		arg.value = p.parseValue(constant)
+		args = append(args, arg)
+	}
+	return args
+}
+
+func (p *parser) parseDirectives() []*directive {
+	var directives []*directive
+	for p.skip("@") {
+		d := &directive{name: p.parseName()}
+		d.arguments = p.parseArguments(false)
+		directives = append(directives, d)
+	}
+	return directives
+}
+
+// parseValue parses a value, which mustn't contain variables if constant is true.
+func (p *parser) parseValue(constant bool) *value {
+	tok := p.tok
+	switch tok.kind {
+	case intToken, floatToken, stringToken:
+		p.next()
+		kind := intValue
+		if tok.kind == floatToken {
+			kind = floatValue
+		} else if tok.kind == stringToken {
+			kind = stringValue
+		}
+		return &value{kind: kind, raw: tok.value}
+	case nameToken:
+		p.next()
+		switch tok.value {
+		case "true", "false":
+			return &value{kind: booleanValue, raw: tok.value}
+		case "null":
+			return &value{kind: nullValue}
+		}
+		return &value{kind: enumValue, raw: tok.value}
+	}
+	switch {
+	case !constant && p.skip("$"):
+		return &value{kind: variableValue, raw: p.parseName()}
+	case p.skip("["):
+		v := &value{kind: listValue}
+		for !p.skip("]") {
+			v.list = append(v.list, p.parseValue(constant))
+		}
+		return v
+	case p.skip("{"):
+		v := &value{kind: objectValue}
+		for !p.skip("}") {
+			f := &argument{name: p.parseName()}
+			p.expect(":")
+			f.value = p.parseValue(constant)
+			v.fields = append(v.fields, f)
+		}
+		return v
+	}
+	p.errorf("expected value, found %s", p.describe())
+	return nil
+}
+
+// next scans the next token into p.tok.
+func (p *parser) next() {
+	p.end = p.pos // The end of the current token, as ignored tokens are skipped before tokens.
+	p.skipIgnored()
+	start := p.pos
+	if p.pos >= len(p.src) {
+		p.tok = token{kind: eofToken, pos: start}
+		return
+	}
+	c := p.src[p.pos]
+	switch {
+	case strings.IndexByte("!$&()...:=@[]{}|", c) >= 0:
+		if c == '.' {
+			if !strings.HasPrefix(p.src[p.pos:], "...") {
+				panic(&syntaxError{pos: start, msg: `unexpected ".", expected "..."`})
+			}
+			p.pos += 3
+		} else {
+			p.pos++
+		}
+		p.tok = token{kind: punctuatorToken, value: p.src[start:p.pos], pos: start}
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		for p.pos < len(p.src) && isNameByte(p.src[p.pos]) {
+			p.pos++
+		}
+		p.tok = token{kind: nameToken, value: p.src[start:p.pos], pos: start}
+	case c == '-' || '0' <= c && c <= '9':
+		p.scanNumber()
+	case c == '"':
+		p.scanString()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		panic(&syntaxError{pos: start, msg: fmt.Sprintf("unexpected character %q", r)})
+	}
+}
+
+func isNameByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// skipIgnored skips white space, line terminators, commas and comments.
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '\r' {
+				p.pos++
+			}
+		case strings.HasPrefix(p.src[p.pos:], "\ufeff"): // Byte order mark.
+			p.pos += len("\ufeff")
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) scanNumber() {
+	start := p.pos
+	kind := intToken
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() {
+		n := p.pos
+		for p.pos < len(p.src) && '0' <= p.src[p.pos] && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		if p.pos == n {
+			panic(&syntaxError{pos: p.pos, msg: "invalid number, expected digit"})
+		}
+	}
+	digits()
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		kind = floatToken
+		p.pos++
+		digits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		kind = floatToken
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		digits()
+	}
+	p.tok = token{kind: kind, value: p.src[start:p.pos], pos: start}
+}
+
+func (p *parser) scanString() {
+	start := p.pos
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		p.pos += 3
+		var raw strings.Builder
+		for {
+			switch {
+			case p.pos >= len(p.src):
+				panic(&syntaxError{pos: start, msg: "unterminated block string"})
+			case strings.HasPrefix(p.src[p.pos:], `\"""`):
+				raw.WriteString(`"""`)
+				p.pos += 4
+			case strings.HasPrefix(p.src[p.pos:], `"""`):
+				p.pos += 3
+				p.tok = token{kind: stringToken, value: blockStringValue(raw.String()), pos: start}
+				return
+			default:
+				raw.WriteByte(p.src[p.pos])
+				p.pos++
+			}
+		}
+	}
+	p.pos++
+	var b strings.Builder
+	for {
+		if p.pos >= len(p.src) || p.src[p.pos] == '\n' || p.src[p.pos] == '\r' {
+			panic(&syntaxError{pos: start, msg: "unterminated string"})
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			p.tok = token{kind: stringToken, value: b.String(), pos: start}
+			return
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				panic(&syntaxError{pos: start, msg: "unterminated string"})
+			}
+			switch e := p.src[p.pos+1]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if p.pos+6 > len(p.src) {
+					panic(&syntaxError{pos: p.pos, msg: "invalid unicode escape sequence"})
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+2:p.pos+6], 16, 32)
+				if err != nil {
+					panic(&syntaxError{pos: p.pos, msg: "invalid unicode escape sequence"})
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				panic(&syntaxError{pos: p.pos, msg: fmt.Sprintf("invalid escape sequence \\%c", e)})
+			}
+			p.pos += 2
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// blockStringValue returns the value of a block string with the raw content,
+// removing the common indentation and the leading and trailing blank lines.
+// See https://spec.graphql.org/June2018/#BlockStringValue().
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(raw), "\n")
+	indent := -1
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" {
+			continue
+		}
+		if n := len(line) - len(trimmed); indent < 0 || n < indent {
+			indent = n
+		}
+	}
+	if indent > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= indent {
+				lines[i] = lines[i][indent:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,93 @@
+package graphql
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Decompressor returns a reader decompressing r,
+// which is encoded with a content coding such as "br".
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+// decompression negotiates compressed responses and decompresses them.
+type decompression struct {
+	encodings     []string // In order of registration.
+	decompressors map[string]Decompressor
+}
+
+func (d *decompression) add(encoding string, decompressor Decompressor) {
+	encoding = strings.ToLower(encoding)
+	if _, ok := d.decompressors[encoding]; !ok {
+		d.encodings = append(d.encodings, encoding)
+	}
+	d.decompressors[encoding] = decompressor
+}
+
+// acceptEncoding returns the value of the Accept-Encoding header.
+func (d *decompression) acceptEncoding() string {
+	return strings.Join(d.encodings, ", ")
+}
+
+// body returns the decompressed body of resp, encoded as given by its Content-Encoding header.
+func (d *decompression) body(resp *http.Response) (io.ReadCloser, error) {
+	var codings []string
+	for _, v := range resp.Header.Values("Content-Encoding") {
+		for _, coding := range strings.Split(v, ",") {
+			coding = strings.ToLower(strings.TrimSpace(coding))
+			if coding != "" && coding != "identity" {
+				codings = append(codings, coding)
+			}
+		}
+	}
+	body := resp.Body
+	// Codings are listed in the order they were applied.
+	for i := len(codings) - 1; i >= 0; i-- {
+		decompressor, ok := d.decompressors[codings[i]]
+		if !ok {
+			return nil, fmt.Errorf("graphql: unsupported Content-Encoding %q", codings[i])
+		}
+		r, err := decompressor(body)
+		if err != nil {
+			return nil, err
+		}
+		body = r
+	}
+	return body, nil
+}
+
+// WithCompressedResponses makes the client ask for gzip or deflate compressed responses
+// and decompress them, as some gateways only compress when explicitly asked to.
+// Further encodings are added with WithDecompressor.
+//
+// It replaces the transparent gzip decompression of http.Transport,
+// which is only done when no Accept-Encoding header is set.
+func (c *Client) WithCompressedResponses() *Client {
+	if c.decompression == nil {
+		c.decompression = &decompression{decompressors: make(map[string]Decompressor)}
+		c.decompression.add("gzip", func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		})
+		c.decompression.add("deflate", func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		})
+	}
+	return c
+}
+
+// WithDecompressor makes the client accept responses compressed with encoding,
+// and decompress them with decompressor, e.g. for brotli:
+//
+//	client.WithDecompressor("br", func(r io.Reader) (io.ReadCloser, error) {
+//		return ioutil.NopCloser(brotli.NewReader(r)), nil
+//	})
+//
+// It implies WithCompressedResponses.
+func (c *Client) WithDecompressor(encoding string, decompressor Decompressor) *Client {
+	c.WithCompressedResponses()
+	c.decompression.add(encoding, decompressor)
+	return c
+}
@@ -0,0 +1,92 @@
+package graphql_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_Query_compressedResponses(t *testing.T) {
+	const response = `{"data": {"user": {"name": "Gopher"}}}`
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		mustWrite(zw, s)
+		zw.Close()
+		return buf.String()
+	}
+	deflated := func(s string) string {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		mustWrite(zw, s)
+		zw.Close()
+		return buf.String()
+	}
+	b64 := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	tests := []struct {
+		encoding string
+		body     string
+	}{
+		{"gzip", gzipped(response)},
+		{"deflate", deflated(response)},
+		{"b64", b64(response)},
+		{"gzip, b64", b64(gzipped(response))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.encoding, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				if got, want := req.Header.Get("Accept-Encoding"), "gzip, deflate, b64"; got != want {
+					t.Errorf("got Accept-Encoding: %q, want: %q", got, want)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Content-Encoding", tc.encoding)
+				mustWrite(w, tc.body)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+				WithDecompressor("b64", func(r io.Reader) (io.ReadCloser, error) {
+					return ioutil.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+				})
+
+			var q struct {
+				User struct {
+					Name string
+				}
+			}
+			if err := client.Query(context.Background(), &q, nil); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := q.User.Name, "Gopher"; got != want {
+				t.Errorf("got q.User.Name: %q, want: %q", got, want)
+			}
+		})
+	}
+}
+
+func TestClient_Query_unsupportedContentEncoding(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		mustWrite(w, "\x0b\x02\x80")
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithCompressedResponses()
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err == nil {
+		t.Error("got no error, want one")
+	}
+}
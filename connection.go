@@ -0,0 +1,42 @@
+package graphql
+
+// Connection is a Relay-style connection to a list of nodes of type T,
+// the fields of a query struct selecting a page of it, e.g.:
+//
+//	var q struct {
+//		Repository struct {
+//			Issues graphql.Connection[Issue] `graphql:"issues(first: $first, after: $after)"`
+//		} `graphql:"repository(owner: $owner, name: $name)"`
+//	}
+//
+// Connections are paginated by Client.Paginate, Pages and Nodes.
+//
+// Specification: https://relay.dev/graphql/connections.htm.
+type Connection[T any] struct {
+	Edges    []Edge[T]
+	PageInfo PageInfo
+}
+
+// Nodes returns the nodes of the edges of c.
+func (c *Connection[T]) Nodes() []T {
+	nodes := make([]T, len(c.Edges))
+	for i := range c.Edges {
+		nodes[i] = c.Edges[i].Node
+	}
+	return nodes
+}
+
+// Edge is an edge of a Connection, to a node of type T.
+type Edge[T any] struct {
+	Cursor String
+	Node   T
+}
+
+// PageInfo is the page info of a Connection, telling whether there are
+// pages before and after it, and their cursors, which are null for empty pages.
+type PageInfo struct {
+	HasNextPage     Boolean
+	HasPreviousPage Boolean
+	StartCursor     *String
+	EndCursor       *String
+}
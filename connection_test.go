@@ -0,0 +1,63 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestConnection(t *testing.T) {
+	type issue struct {
+		Title graphql.String
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		if got, want := in.Query, "query ($after:String$first:Int!){issues(first: $first, after: $after){edges{cursor,node{title}},pageInfo{hasNextPage,hasPreviousPage,startCursor,endCursor}}}"; got != want {
+			t.Errorf("got query: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if in.Variables["after"] == nil {
+			mustWrite(w, `{"data": {"issues": {"edges": [{"cursor": "1", "node": {"title": "Issue 1"}}, {"cursor": "2", "node": {"title": "Issue 2"}}], "pageInfo": {"hasNextPage": true, "hasPreviousPage": false, "startCursor": "1", "endCursor": "2"}}}}`)
+			return
+		}
+		mustWrite(w, `{"data": {"issues": {"edges": [], "pageInfo": {"hasNextPage": false, "hasPreviousPage": true, "startCursor": null, "endCursor": null}}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Issues graphql.Connection[issue] `graphql:"issues(first: $first, after: $after)"`
+	}
+	p := client.Paginate(&q, nil, graphql.PaginationSettings{PageSize: 2})
+	if !p.Next(context.Background()) {
+		t.Fatal(p.Err())
+	}
+	if got, want := q.Issues.Nodes(), []issue{{"Issue 1"}, {"Issue 2"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got nodes: %v, want: %v", got, want)
+	}
+	if got, want := q.Issues.PageInfo, (graphql.PageInfo{HasNextPage: true, StartCursor: graphql.NewString("1"), EndCursor: graphql.NewString("2")}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got page info: %+v, want: %+v", got, want)
+	}
+	if !p.Next(context.Background()) {
+		t.Fatal(p.Err())
+	}
+	if got := len(q.Issues.Nodes()); got != 0 {
+		t.Errorf("got %d nodes, want none", got)
+	}
+	if p.Next(context.Background()) {
+		t.Error("got a third page, want none")
+	}
+	if err := p.Err(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextOverridesKey is the context key of the *contextOverrides set by WithHeader,
+// WithOperationName and WithURL.
+type contextOverridesKey struct{}
+
+// contextOverrides configures the operations executed with a context. It's never
+// modified once in a context, so that derived contexts don't affect their parent.
+type contextOverrides struct {
+	header http.Header
+	name   string
+	url    string
+}
+
+// overridesFrom returns a copy of the overrides of ctx, to be modified.
+func overridesFrom(ctx context.Context) *contextOverrides {
+	o := &contextOverrides{}
+	if parent, ok := ctx.Value(contextOverridesKey{}).(*contextOverrides); ok {
+		*o = *parent
+		o.header = parent.header.Clone()
+	}
+	return o
+}
+
+// WithHeader returns a copy of ctx making the operations executed with it send
+// the header key with value, replacing any value set by the client. Call options
+// set by WithRequestHeader take precedence.
+// It lets frameworks passing only contexts down influence individual requests.
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	o := overridesFrom(ctx)
+	if o.header == nil {
+		o.header = make(http.Header)
+	}
+	o.header.Set(key, value)
+	return context.WithValue(ctx, contextOverridesKey{}, o)
+}
+
+// WithOperationName returns a copy of ctx naming the operations executed with it,
+// unless they're named by the call, as by Client.NamedQuery.
+func WithOperationName(ctx context.Context, name string) context.Context {
+	o := overridesFrom(ctx)
+	o.name = name
+	return context.WithValue(ctx, contextOverridesKey{}, o)
+}
+
+// WithURL returns a copy of ctx making the operations executed with it
+// be sent to url instead of the URL of the client.
+func WithURL(ctx context.Context, url string) context.Context {
+	o := overridesFrom(ctx)
+	o.url = url
+	return context.WithValue(ctx, contextOverridesKey{}, o)
+}
@@ -0,0 +1,63 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestContextOverrides(t *testing.T) {
+	type request struct {
+		path, role, tenant, query string
+	}
+	var got request
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		body := mustRead(req.Body)
+		got = request{req.URL.Path, req.Header.Get("X-Hasura-Role"), req.Header.Get("X-Tenant"), body}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}
+	mux.HandleFunc("/graphql", handler)
+	mux.HandleFunc("/shard", handler)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}},
+		graphql.WithRequestHeader("X-Hasura-Role", "user"))
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	parent := graphql.WithHeader(context.Background(), "X-Tenant", "acme")
+	ctx := graphql.WithHeader(parent, "X-Hasura-Role", "editor")
+	ctx = graphql.WithOperationName(ctx, "GetUser")
+	ctx = graphql.WithURL(ctx, "/shard")
+
+	if err := client.Query(ctx, &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := request{"/shard", "editor", "acme", `{"query":"query GetUser{user{name}}"}` + "\n"}
+	if got != want {
+		t.Errorf("got request: %+v, want: %+v", got, want)
+	}
+
+	// The call takes precedence.
+	if err := client.NamedQuery(ctx, "Named", &q, nil, graphql.WithRequestHeader("X-Hasura-Role", "admin")); err != nil {
+		t.Fatal(err)
+	}
+	want = request{"/shard", "admin", "acme", `{"query":"query Named{user{name}}"}` + "\n"}
+	if got != want {
+		t.Errorf("got request: %+v, want: %+v", got, want)
+	}
+
+	// Derived contexts don't affect their parent.
+	if err := client.Query(parent, &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	want = request{"/graphql", "user", "acme", `{"query":"{user{name}}"}` + "\n"}
+	if got != want {
+		t.Errorf("got request: %+v, want: %+v", got, want)
+	}
+}
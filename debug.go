@@ -0,0 +1,96 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DebugDump describes an operation sent by the client and the raw response, for Client.WithDebug.
+type DebugDump struct {
+	Operation *Operation
+	// Variables holds the variables of the operation, redacted as in logs (see Client.WithRedactor).
+	Variables map[string]interface{}
+	// StatusCode, Header and Body describe the response, shared by the operations
+	// of a batched request. Body is only valid until the DebugFunc returns.
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Duration   time.Duration
+	// Err is the error sending the request, in which case there's no response.
+	Err error
+}
+
+// DebugFunc receives the dump of each operation sent by the client.
+type DebugFunc func(ctx context.Context, dump *DebugDump)
+
+// WithDebug makes the client call debug with the document, redacted variables
+// and raw response of each operation it sends, which helps diagnosing decode errors.
+// SetDebug turns it off and on at runtime.
+func (c *Client) WithDebug(debug DebugFunc) *Client {
+	c.debug = debug
+	c.SetDebug(true)
+	return c
+}
+
+// SetDebug turns the debug dumps set by WithDebug on or off.
+// It's safe to call concurrently with operations.
+func (c *Client) SetDebug(enabled bool) {
+	var v uint32
+	if enabled && c.debug != nil {
+		v = 1
+	}
+	atomic.StoreUint32(&c.debugEnabled, v)
+}
+
+// DebugWriter returns a DebugFunc writing dumps to w in a human readable format.
+// Writes are serialized, so w needn't be safe for concurrent use.
+func DebugWriter(w io.Writer) DebugFunc {
+	var mu sync.Mutex
+	return func(_ context.Context, dump *DebugDump) {
+		op := dump.Operation
+		variables, err := json.Marshal(dump.Variables)
+		if err != nil {
+			variables = []byte(err.Error())
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "--- graphql %s %s %s", op.Type, op.Name, op.URL)
+		if op.RequestID != "" {
+			fmt.Fprintf(w, " (request ID: %s)", op.RequestID)
+		}
+		fmt.Fprintf(w, "\n%s\nvariables: %s\n", op.Query, variables)
+		if dump.Err != nil {
+			fmt.Fprintf(w, "error after %v: %v\n", dump.Duration, dump.Err)
+			return
+		}
+		fmt.Fprintf(w, "response %d after %v:\n%s\n", dump.StatusCode, dump.Duration, dump.Body)
+	}
+}
+
+// dumpDebug dumps ops with their response, if enabled by WithDebug.
+// resp and body are nil if sending the request failed with err.
+func (c *Client) dumpDebug(ctx context.Context, ops []*Operation, resp *http.Response, body []byte, duration time.Duration, err error) {
+	if atomic.LoadUint32(&c.debugEnabled) == 0 {
+		return
+	}
+	for _, op := range ops {
+		dump := &DebugDump{
+			Operation: op,
+			Variables: c.redactVariables(op.Variables),
+			Body:      body,
+			Duration:  duration,
+			Err:       err,
+		}
+		if resp != nil {
+			dump.StatusCode = resp.StatusCode
+			dump.Header = resp.Header
+		}
+		c.debug(ctx, dump)
+	}
+}
@@ -0,0 +1,67 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithDebug(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	var buf bytes.Buffer
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDebug(graphql.DebugWriter(&buf))
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(token: $token)"`
+	}
+	variables := map[string]interface{}{"token": graphql.String("secret")}
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.String()
+	for _, want := range []string{
+		"--- graphql query GetUser /graphql\n",
+		"query GetUser($token:String!){user(token: $token){name}}\n",
+		`variables: {"token":"[REDACTED]"}`,
+		"response 200 after ",
+		`{"data": {"user": {"name": "Gopher"}}}`,
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("got dump: %q, want it to contain %q", dump, want)
+		}
+	}
+	if strings.Contains(dump, "secret") {
+		t.Errorf("got dump: %q, want the variables redacted", dump)
+	}
+
+	buf.Reset()
+	client.SetDebug(false)
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got dump: %q, want none once disabled", buf.String())
+	}
+
+	var dumps []*graphql.DebugDump
+	client.WithDebug(func(_ context.Context, dump *graphql.DebugDump) {
+		dumps = append(dumps, dump)
+	})
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || dumps[0].Operation.Name != "GetUser" || dumps[0].StatusCode != http.StatusOK {
+		t.Errorf("got dumps: %+v, want one of GetUser", dumps)
+	}
+}
@@ -0,0 +1,105 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// WithDeduplication makes the client coalesce concurrent identical queries,
+// having the same endpoint, document, variables and headers, including the
+// Authorization header set by WithTokenProvider, into a single request, whose
+// response is shared by all callers. Mutations are never coalesced.
+//
+// The shared request is canceled once all of its callers are gone.
+func (c *Client) WithDeduplication() *Client {
+	c.dedup = &deduplicator{calls: make(map[string]*dedupCall)}
+	return c
+}
+
+// deduplicator coalesces concurrent identical queries.
+type deduplicator struct {
+	mu    sync.Mutex
+	calls map[string]*dedupCall
+}
+
+// dedupCall is a query in flight, shared by its waiters.
+type dedupCall struct {
+	done    chan struct{}
+	resp    *Response
+	err     error
+	waiters int // Guarded by deduplicator.mu.
+	cancel  context.CancelFunc
+}
+
+// handler returns the handler coalescing the queries executed by next.
+func (d *deduplicator) handler(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		if op.Type != QueryOperation || op.streamed() {
+			return next(ctx, op)
+		}
+		if _, ok := ctx.Value(batchSlotKey{}).(*batchSlot); ok {
+			// Waiting for another operation would hold back its batch.
+			return next(ctx, op)
+		}
+		key, err := dedupKey(op)
+		if err != nil {
+			return next(ctx, op)
+		}
+
+		d.mu.Lock()
+		call, ok := d.calls[key]
+		if !ok {
+			callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			call = &dedupCall{done: make(chan struct{}), cancel: cancel}
+			d.calls[key] = call
+			go func() {
+				call.resp, call.err = next(callCtx, op)
+				d.mu.Lock()
+				if d.calls[key] == call {
+					delete(d.calls, key)
+				}
+				d.mu.Unlock()
+				cancel()
+				close(call.done)
+			}()
+		}
+		call.waiters++
+		d.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.resp, call.err
+		case <-ctx.Done():
+			d.mu.Lock()
+			call.waiters--
+			if call.waiters == 0 {
+				// Later queries mustn't join the canceled call.
+				if d.calls[key] == call {
+					delete(d.calls, key)
+				}
+				call.cancel()
+			}
+			d.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// dedupKey returns the key of the queries identical to op.
+func dedupKey(op *Operation) (string, error) {
+	variables, err := json.Marshal(op.Variables)
+	if err != nil {
+		return "", err
+	}
+	// Documents differing only in formatting are the same operation.
+	key := batchKey(op) + "\n" + CanonicalDocument(op.Query) + "\n" + string(variables)
+	if len(op.Extensions) > 0 {
+		extensions, err := json.Marshal(op.Extensions)
+		if err != nil {
+			return "", err
+		}
+		key += "\n" + string(extensions)
+	}
+	return key, nil
+}
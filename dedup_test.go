@@ -0,0 +1,109 @@
+package graphql_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithDeduplication(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithDeduplication()
+
+	const n = 5
+	started := make(chan struct{}, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var q struct {
+				User struct {
+					Name string
+				}
+			}
+			started <- struct{}{}
+			if err := client.Query(context.Background(), &q, nil); err != nil {
+				t.Error(err)
+				return
+			}
+			if got, want := q.User.Name, "Gopher"; got != want {
+				t.Errorf("got q.User.Name: %q, want: %q", got, want)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		<-started
+	}
+	// Give the queries time to join the one in flight.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got, want := atomic.LoadInt32(&calls), int32(1); got != want {
+		t.Errorf("got %d requests, want: %d", got, want)
+	}
+}
+
+func TestClient_WithDeduplication_tokenProvider(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, fmt.Sprintf(`{"data": {"user": {"name": %q}}}`, req.Header.Get("Authorization")))
+	})
+	type userKey struct{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDeduplication().
+		WithTokenProvider(func(ctx context.Context, refresh bool) (string, error) {
+			return ctx.Value(userKey{}).(string), nil
+		})
+
+	users := []string{"alice", "bob", "alice", "bob"}
+	started := make(chan struct{}, len(users))
+	var wg sync.WaitGroup
+	for _, user := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var q struct {
+				User struct {
+					Name string
+				}
+			}
+			started <- struct{}{}
+			if err := client.Query(context.WithValue(context.Background(), userKey{}, user), &q, nil); err != nil {
+				t.Error(err)
+				return
+			}
+			if got, want := q.User.Name, "Bearer "+user; got != want {
+				t.Errorf("got response for %q, want: %q", got, want)
+			}
+		}()
+	}
+	for range users {
+		<-started
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if got, want := atomic.LoadInt32(&calls), int32(2); got != want {
+		t.Errorf("got %d requests, want: %d", got, want)
+	}
+}
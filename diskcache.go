@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// diskCacheExt is the extension of the files of the entries of a DiskCache.
+const diskCacheExt = ".json"
+
+// DiskCache is a Cache storing its entries as files of a directory, so that
+// CLIs and batch jobs reuse responses across restarts. Beyond its capacity, it
+// evicts the least recently used entries, and it evicts expired entries.
+//
+// Directories may be shared by the caches of several processes. Failing
+// to read or write an entry is a cache miss.
+type DiskCache struct {
+	dir      string
+	maxBytes int64
+
+	mu   sync.Mutex
+	size int64 // Bytes of the entries, as of the last scan and the writes since.
+}
+
+// diskCacheEntry is the encoding of a CacheEntry in the file of a DiskCache.
+type diskCacheEntry struct {
+	Data       *json.RawMessage `json:"data,omitempty"`
+	Errors     Errors           `json:"errors,omitempty"`
+	Extensions interface{}      `json:"extensions,omitempty"`
+	StatusCode int              `json:"status,omitempty"`
+	Header     http.Header      `json:"header,omitempty"`
+	Duration   time.Duration    `json:"duration,omitempty"`
+	StoredAt   time.Time        `json:"storedAt"`
+	ETag       string           `json:"etag,omitempty"`
+	Expires    time.Time        `json:"expires"`
+}
+
+// NewDiskCache returns a DiskCache storing up to maxBytes bytes of entries in dir,
+// which is created if needed. A maxBytes of 0 means no limit.
+func NewDiskCache(dir string, maxBytes int64) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	d := &DiskCache{dir: dir, maxBytes: maxBytes}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.scan(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Get implements Cache.
+func (d *DiskCache) Get(key string) (*CacheEntry, bool) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var e diskCacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || time.Now().After(e.Expires) {
+		d.remove(path, int64(len(data)))
+		return nil, false
+	}
+	// The modification time orders the entries for eviction.
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return &CacheEntry{
+		Response: &Response{
+			Data:       e.Data,
+			Errors:     e.Errors,
+			Extensions: e.Extensions,
+			StatusCode: e.StatusCode,
+			Header:     e.Header,
+			Duration:   e.Duration,
+		},
+		StoredAt: e.StoredAt,
+		ETag:     e.ETag,
+	}, true
+}
+
+// Set implements Cache.
+func (d *DiskCache) Set(key string, entry *CacheEntry, ttl time.Duration) {
+	resp := entry.Response
+	data, err := json.Marshal(diskCacheEntry{
+		Data:       resp.Data,
+		Errors:     resp.Errors,
+		Extensions: resp.Extensions,
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Duration:   resp.Duration,
+		StoredAt:   entry.StoredAt,
+		ETag:       entry.ETag,
+		Expires:    time.Now().Add(ttl),
+	})
+	if err != nil {
+		return
+	}
+	// Write a temporary file renamed into place, so that readers never see partial entries.
+	f, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	path := d.path(key)
+	var replaced int64
+	if info, statErr := os.Stat(path); statErr == nil {
+		replaced = info.Size()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.size += int64(len(data)) - replaced
+	if d.maxBytes > 0 && d.size > d.maxBytes {
+		d.evict()
+	}
+}
+
+// Size returns the number of bytes of the entries, including expired ones not evicted yet.
+func (d *DiskCache) Size() int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.size
+}
+
+// path returns the path of the file of the entry with key, named after its
+// hash, as keys may not be valid file names.
+func (d *DiskCache) path(key string) string {
+	return filepath.Join(d.dir, DocumentID(key)+diskCacheExt)
+}
+
+// remove removes the file of an entry of size bytes at path.
+func (d *DiskCache) remove(path string, size int64) {
+	if os.Remove(path) != nil {
+		return
+	}
+	d.mu.Lock()
+	d.size -= size
+	d.mu.Unlock()
+}
+
+// evict removes the least recently used entries until they fit in d.maxBytes.
+// d.mu must be held.
+func (d *DiskCache) evict() {
+	files, err := d.scan()
+	if err != nil {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files {
+		if d.size <= d.maxBytes {
+			break
+		}
+		if os.Remove(filepath.Join(d.dir, f.Name())) == nil {
+			d.size -= f.Size()
+		}
+	}
+}
+
+// scan returns the files of the entries of d, and updates d.size with their
+// sizes, as other processes may have changed them. d.mu must be held.
+func (d *DiskCache) scan() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]os.FileInfo, 0, len(entries))
+	d.size = 0
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !strings.HasSuffix(entry.Name(), diskCacheExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed meanwhile.
+		}
+		files = append(files, info)
+		d.size += info.Size()
+	}
+	return files, nil
+}
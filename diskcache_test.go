@@ -0,0 +1,123 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestDiskCache(t *testing.T) {
+	dir := t.TempDir()
+	cache, err := graphql.NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := json.RawMessage(`{"user":{"name":"Gopher"}}`)
+	stored := time.Now().Add(-time.Second).Round(0)
+	cache.Set("a", &graphql.CacheEntry{
+		Response: &graphql.Response{Data: &data, StatusCode: http.StatusOK, Header: http.Header{"Etag": {`"v1"`}}},
+		StoredAt: stored,
+		ETag:     `"v1"`,
+	}, time.Minute)
+	cache.Set("b", &graphql.CacheEntry{Response: &graphql.Response{Data: &data}, StoredAt: stored}, -time.Second)
+
+	// Entries outlive the cache.
+	cache, err = graphql.NewDiskCache(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := cache.Get("a")
+	if !ok {
+		t.Fatal("entry a is missing")
+	}
+	if got, want := string(*entry.Response.Data), string(data); got != want {
+		t.Errorf("got data: %s, want: %s", got, want)
+	}
+	if entry.ETag != `"v1"` || entry.Response.Header.Get("ETag") != `"v1"` || entry.Response.StatusCode != http.StatusOK {
+		t.Errorf("got entry: %+v, response: %+v", entry, entry.Response)
+	}
+	if !entry.StoredAt.Equal(stored) {
+		t.Errorf("got StoredAt: %v, want: %v", entry.StoredAt, stored)
+	}
+	if _, ok := cache.Get("b"); ok {
+		t.Error("got expired entry b")
+	}
+	if _, ok := cache.Get("c"); ok {
+		t.Error("got missing entry c")
+	}
+}
+
+func TestDiskCache_eviction(t *testing.T) {
+	data := json.RawMessage(`{"user":{"name":"Gopher"}}`)
+	entry := &graphql.CacheEntry{Response: &graphql.Response{Data: &data}, StoredAt: time.Now()}
+	probe, err := graphql.NewDiskCache(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probe.Set("a", entry, time.Minute)
+	size := probe.Size()
+
+	// The cache holds two entries, whose sizes vary with their expiry times.
+	maxBytes := 2*size + size/2
+	cache, err := graphql.NewDiskCache(t.TempDir(), maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("a", entry, time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	cache.Set("b", entry, time.Minute)
+	time.Sleep(10 * time.Millisecond)
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("entry a is missing")
+	}
+	time.Sleep(10 * time.Millisecond)
+	cache.Set("c", entry, time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.Get(key); ok != want {
+			t.Errorf("got entry %s: %v, want: %v", key, ok, want)
+		}
+	}
+	if got := cache.Size(); got > maxBytes {
+		t.Errorf("got size: %d, want at most: %d", got, maxBytes)
+	}
+}
+
+func TestClient_WithCache_diskCache(t *testing.T) {
+	dir := t.TempDir()
+	sent := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		sent++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+
+	// As if the process restarted.
+	for i := 0; i < 2; i++ {
+		cache, err := graphql.NewDiskCache(dir, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+			WithCache(cache, time.Hour)
+		var q struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := client.Query(context.Background(), &q, nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := q.User.Name, "Gopher"; got != want {
+			t.Errorf("got name: %q, want: %q", got, want)
+		}
+	}
+	if sent != 1 {
+		t.Errorf("got %d requests, want: 1", sent)
+	}
+}
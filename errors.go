@@ -0,0 +1,511 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// NetworkError is returned when the GraphQL server couldn't be reached,
+// or responded with a non-200 status code. Retrying may help.
+type NetworkError struct {
+	err        error  // Transport error, if the server couldn't be reached.
+	statusCode int    // HTTP status code of the response, otherwise.
+	status     string // E.g., "500 Internal Server Error".
+	header     http.Header
+	body       []byte
+}
+
+// maxErrorBodySize is the maximum number of bytes of a non-200 response body retained in NetworkError.
+const maxErrorBodySize = 64 << 10
+
+// newStatusError returns a NetworkError describing the non-200 response resp
+// with body, retaining up to maxErrorBodySize bytes of it.
+func newStatusError(resp *http.Response, body []byte) *NetworkError {
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+	}
+	return &NetworkError{
+		statusCode: resp.StatusCode,
+		status:     resp.Status,
+		header:     resp.Header,
+		body:       append([]byte(nil), body...),
+	}
+}
+
+// Error implements error interface.
+func (e *NetworkError) Error() string {
+	if e.err != nil {
+		return e.err.Error()
+	}
+	return fmt.Sprintf("non-200 OK status code: %v body: %q", e.status, e.body)
+}
+
+// Unwrap returns the underlying transport error, if any.
+func (e *NetworkError) Unwrap() error {
+	return e.err
+}
+
+// StatusCode returns the HTTP status code of the response,
+// or 0 if the server couldn't be reached.
+func (e *NetworkError) StatusCode() int {
+	return e.statusCode
+}
+
+// Header returns the header of the response, e.g. to inspect Retry-After
+// when the status code is 429, or nil if the server couldn't be reached.
+func (e *NetworkError) Header() http.Header {
+	return e.header
+}
+
+// Body returns the body of the response, truncated to its first 64 KiB.
+func (e *NetworkError) Body() string {
+	return string(e.body)
+}
+
+// Retryable reports whether retrying the request may succeed. That's the case
+// for timeouts, connection failures, and the 408, 429 and 5xx status codes
+// (except 501 Not Implemented).
+func (e *NetworkError) Retryable() bool {
+	if e.err != nil {
+		return isTransientError(e.err)
+	}
+	switch e.statusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented:
+		return false
+	}
+	return e.statusCode >= 500
+}
+
+// isTransientError reports whether the transport error err is likely temporary.
+func isTransientError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		// The caller gave up.
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsRetryable reports whether err, as returned by Client, is worth retrying.
+// Network failures are classified by NetworkError.Retryable; GraphQL errors,
+// such as validation or authorization failures, and decoding errors are permanent.
+func IsRetryable(err error) bool {
+	var r interface{ Retryable() bool }
+	return errors.As(err, &r) && r.Retryable()
+}
+
+// isConnectionError reports whether err is a failure to reach the server, a
+// *NetworkError without status code, rather than an error response, which the
+// server may send after executing the operation.
+func isConnectionError(err error) bool {
+	var netErr *NetworkError
+	return errors.As(err, &netErr) && netErr.StatusCode() == 0
+}
+
+// DecodeError is returned when the response of the GraphQL server couldn't
+// be decoded, either because it's not a valid GraphQL response or because
+// the data doesn't fit into the provided query struct.
+type DecodeError struct {
+	err error
+}
+
+// Retryable reports false, as decoding the same response again fails the same way.
+func (e *DecodeError) Retryable() bool {
+	return false
+}
+
+// Error implements error interface.
+func (e *DecodeError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.err
+}
+
+// ResponseTooLargeError is returned when the response body of the GraphQL
+// server exceeds the limit set by Client.WithMaxResponseSize.
+type ResponseTooLargeError struct {
+	Limit int64 // Maximum number of bytes of a response body.
+}
+
+// Error implements error interface.
+func (e *ResponseTooLargeError) Error() string {
+	return fmt.Sprintf("response body exceeds the limit of %d bytes", e.Limit)
+}
+
+// Retryable reports false, as the server is expected to send the same response again.
+func (e *ResponseTooLargeError) Retryable() bool {
+	return false
+}
+
+// DocumentTooLargeError is returned for operations whose documents exceed
+// the limits set by Client.WithDocumentLimits.
+type DocumentTooLargeError struct {
+	Depth, MaxDepth int // Nesting of the selection sets of the document, and its limit.
+	Size, MaxSize   int // Number of bytes of the document, and its limit.
+}
+
+// Error implements error interface.
+func (e *DocumentTooLargeError) Error() string {
+	if e.MaxDepth > 0 && e.Depth > e.MaxDepth {
+		return fmt.Sprintf("graphql: document is nested %d levels deep, exceeding the limit of %d", e.Depth, e.MaxDepth)
+	}
+	return fmt.Sprintf("graphql: document is %d bytes long, exceeding the limit of %d bytes", e.Size, e.MaxSize)
+}
+
+// Retryable reports false, as the same document exceeds the limits again.
+func (e *DocumentTooLargeError) Retryable() bool {
+	return false
+}
+
+// Errors represents the "errors" array in a response from a GraphQL server,
+// i.e., the GraphQL errors of an operation. If returned via error interface, the slice is expected to contain at least 1 element.
+//
+// Use errors.As to access all entries of a returned error:
+//
+//	var errs graphql.Errors
+//	if errors.As(err, &errs) {
+//		for _, e := range errs {
+//			// Handle e.
+//		}
+//	}
+//
+// Specification: https://facebook.github.io/graphql/#sec-Errors.
+type Errors []Error
+
+// Error is a single entry of the "errors" array in a response from a GraphQL server.
+type Error struct {
+	Message   string
+	Locations []Location
+
+	// Path identifies the response field that failed, e.g. ["hero", "friends", 1, "name"].
+	// Data in that part of the response is unreliable.
+	Path Path
+
+	// Extensions holds additional, server-specific information about the error,
+	// such as Apollo's and Hasura's "code" or graphql-java's "classification".
+	Extensions map[string]interface{}
+}
+
+// Location is a position in the GraphQL document associated with an error.
+type Location struct {
+	Line   int
+	Column int
+}
+
+// Path is the path of a response field, from the root of the response.
+// Its elements are field names (string) and list indices (int).
+type Path []interface{}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding list indices as int.
+func (p *Path) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var elems []interface{}
+	if err := dec.Decode(&elems); err != nil {
+		return err
+	}
+	for i, elem := range elems {
+		n, ok := elem.(json.Number)
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(n.String())
+		if err != nil {
+			return fmt.Errorf("invalid path element %v: %w", n, err)
+		}
+		elems[i] = index
+	}
+	*p = elems
+	return nil
+}
+
+// String returns the path in dotted notation, e.g. "hero.friends.1.name".
+func (p Path) String() string {
+	elems := make([]string, len(p))
+	for i := range p {
+		elems[i] = fmt.Sprint(p[i])
+	}
+	return strings.Join(elems, ".")
+}
+
+// Error implements error interface.
+func (e Error) Error() string {
+	return e.Message
+}
+
+// Code returns the "code" entry of the error extensions (e.g., "validation-failed"
+// or "access-denied" on Hasura, "UNAUTHENTICATED" on Apollo Server),
+// or "" if there is none.
+func (e Error) Code() string {
+	return e.extensionString("code")
+}
+
+// Classification returns the "classification" entry of the error extensions
+// (e.g., "ValidationError" on graphql-java), or "" if there is none.
+func (e Error) Classification() string {
+	return e.extensionString("classification")
+}
+
+// extensionString returns the extensions entry with key as a string.
+func (e Error) extensionString(key string) string {
+	switch v := e.Extensions[key].(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// HasCode reports whether any entry has the given extensions code.
+func (e Errors) HasCode(code string) bool {
+	for i := range e {
+		if e[i].Code() == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable reports false, as GraphQL errors, such as validation
+// or authorization failures, don't go away on their own.
+func (e Errors) Retryable() bool {
+	return false
+}
+
+// Error implements error interface.
+// It joins the messages of all entries with ",".
+func (e Errors) Error() string {
+	if len(e) == 0 {
+		return ""
+	}
+	var stringOutput = make([]string, len(e))
+	for i := range e {
+		stringOutput[i] = fmt.Sprintf("%v", e[i].Message)
+	}
+	return strings.Join(stringOutput, ",")
+}
+
+// Unwrap returns each entry as a separate error,
+// so errors.Is and errors.As can match any of them.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = e[i]
+	}
+	return errs
+}
+
+// String returns a multi-line description of all entries,
+// one per line, including their locations in the document and paths.
+func (e Errors) String() string {
+	var sb strings.Builder
+	for i := range e {
+		if i != 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(e[i].Message)
+		for j, loc := range e[i].Locations {
+			if j == 0 {
+				sb.WriteString(" at ")
+			} else {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "%d:%d", loc.Line, loc.Column)
+		}
+		if len(e[i].Path) > 0 {
+			sb.WriteString(" (path: ")
+			sb.WriteString(e[i].Path.String())
+			sb.WriteString(")")
+		}
+	}
+	return sb.String()
+}
+
+// MalformedResponseError is returned, wrapped in DecodeError, when the response body
+// isn't a GraphQL response at all: it's either not JSON, such as an HTML error
+// page of a proxy, or has neither data nor errors. Successful responses whose
+// Content-Type isn't JSON fail before they're decoded.
+type MalformedResponseError struct {
+	snippet     []byte
+	err         error
+	contentType string
+}
+
+// maxSnippetSize is the maximum number of bytes of the body included in MalformedResponseError.
+const maxSnippetSize = 512
+
+func newMalformedResponseError(body []byte, err error) *MalformedResponseError {
+	if len(body) > maxSnippetSize {
+		body = body[:maxSnippetSize]
+	}
+	return &MalformedResponseError{snippet: append([]byte(nil), body...), err: err}
+}
+
+// Error implements error interface.
+func (e *MalformedResponseError) Error() string {
+	if e.contentType != "" {
+		return fmt.Sprintf("malformed GraphQL response: unexpected Content-Type %q, body: %q", e.contentType, e.snippet)
+	}
+	if e.err != nil {
+		return fmt.Sprintf("malformed GraphQL response: %v, body: %q", e.err, e.snippet)
+	}
+	return fmt.Sprintf("malformed GraphQL response: no data and no errors, body: %q", e.snippet)
+}
+
+// Unwrap returns the underlying JSON decoding error, if any.
+func (e *MalformedResponseError) Unwrap() error {
+	return e.err
+}
+
+// ContentType returns the Content-Type of the response, if it isn't JSON.
+func (e *MalformedResponseError) ContentType() string {
+	return e.contentType
+}
+
+// Snippet returns the first 512 bytes of the response body.
+func (e *MalformedResponseError) Snippet() string {
+	return string(e.snippet)
+}
+
+// OperationError annotates an error with the operation that caused it.
+// It's returned instead of the plain error if enabled by Client.WithQueryInErrors.
+type OperationError struct {
+	// Query is the constructed GraphQL document.
+	Query string
+	// Variables holds the sorted names of the variables sent with the query.
+	// Their values are redacted.
+	Variables []string
+	// Values holds the values of the variables as redacted by the Redactor set
+	// by Client.WithRedactor, or is nil without one.
+	Values map[string]interface{}
+
+	Err error
+}
+
+func newOperationError(err error, query string, variables map[string]interface{}, redactor Redactor) *OperationError {
+	names := make([]string, 0, len(variables))
+	for k := range variables {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	opErr := &OperationError{Query: query, Variables: names, Err: err}
+	if redactor != nil {
+		opErr.Values = redactVariables(redactor, variables)
+	}
+	return opErr
+}
+
+// Error implements error interface.
+func (e *OperationError) Error() string {
+	if e.Values != nil {
+		if values, err := json.Marshal(e.Values); err == nil {
+			return fmt.Sprintf("%v (query: %s, variables: %s)", e.Err, e.Query, values)
+		}
+	}
+	return fmt.Sprintf("%v (query: %s, variables: [%s])", e.Err, e.Query, strings.Join(e.Variables, " "))
+}
+
+// Unwrap returns the underlying error.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorDecoder decodes the errors of a response from a GraphQL server
+// that deviates from the standard error format.
+type ErrorDecoder interface {
+	// DecodeErrors decodes the errors from the response body. It's called when
+	// the response fails to decode in the standard format, or has neither
+	// data nor errors. It must return an error if body isn't in the format
+	// it handles, so that the next decoder can be tried.
+	// body must not be retained after DecodeErrors returns.
+	DecodeErrors(body []byte) (Errors, error)
+}
+
+// ErrorDecoderFunc is an adapter to allow the use of ordinary functions as ErrorDecoder.
+type ErrorDecoderFunc func(body []byte) (Errors, error)
+
+// DecodeErrors calls f(body).
+func (f ErrorDecoderFunc) DecodeErrors(body []byte) (Errors, error) {
+	return f(body)
+}
+
+// messageListErrorDecoder decodes errors whose message is a list of messages,
+// as sent by some non-standard server implementations.
+type messageListErrorDecoder struct{}
+
+func (messageListErrorDecoder) DecodeErrors(body []byte) (Errors, error) {
+	var out struct {
+		Errors errorsExt
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Errors) == 0 {
+		return nil, errors.New("no errors in response")
+	}
+	return out.Errors.ConvertToStandard(), nil
+}
+
+// errorsExt represents the "errors" array in a response from a GraphQL server.
+// If returned via error interface, the slice is expected to contain at least 1 element.
+// The "Ext" variant of this struct is able to handle non-standard implementations of the error message
+type errorsExt []errorsExtStruct
+type errorsExtStruct struct {
+	Message    []interface{}
+	Locations  []Location
+	Extensions map[string]interface{}
+}
+
+// Error implements error interface.
+func (e errorsExt) Error() string {
+	if len(e) == 0 {
+		return ""
+	}
+
+	var stringOutput = make([]string, len(e[0].Message))
+	for i := range e[0].Message {
+		stringOutput[i] = fmt.Sprintf("%v", e[0].Message[i])
+	}
+	return strings.Join(stringOutput, ",")
+}
+
+// ConvertToStandard translates extended error structs into structs matching the GraphQL Standard
+func (e errorsExt) ConvertToStandard() Errors {
+	if len(e) == 0 {
+		return nil
+	}
+
+	standardError := make(Errors, len(e[0].Message))
+	for i := range e[0].Message {
+		standardError[i] = Error{
+			Message:    fmt.Sprintf("%v", e[0].Message[i]),
+			Locations:  e[0].Locations,
+			Extensions: e[0].Extensions,
+		}
+	}
+
+	return standardError
+}
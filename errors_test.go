@@ -0,0 +1,498 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_Query_allErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{
+			"errors": [
+				{
+					"message": "Field 'user' is missing required arguments: login",
+					"locations": [{"line": 7, "column": 3}]
+				},
+				{
+					"message": "Field 'nme' doesn't exist on type 'User'",
+					"locations": [{"line": 8, "column": 5}, {"line": 9, "column": 1}]
+				}
+			]
+		}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := len(errs), 2; got != want {
+		t.Fatalf("got %d errors, want: %d", got, want)
+	}
+	if got, want := errs[1].Locations[0], (graphql.Location{Line: 8, Column: 5}); got != want {
+		t.Errorf("got location: %v, want: %v", got, want)
+	}
+	if got, want := errs.String(), "Field 'user' is missing required arguments: login at 7:3\n"+
+		"Field 'nme' doesn't exist on type 'User' at 8:5, 9:1"; got != want {
+		t.Errorf("got String():\n%v\nwant:\n%v", got, want)
+	}
+
+	var e graphql.Error
+	if !errors.As(err, &e) {
+		t.Fatal("got no graphql.Error in unwrapped errors")
+	}
+	if got, want := e.Message, "Field 'user' is missing required arguments: login"; got != want {
+		t.Errorf("got message: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_Query_errorExtensions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{
+			"errors": [
+				{
+					"message": "field \"nme\" not found in type: 'user'",
+					"extensions": {"path": "$.selectionSet.user.selectionSet.nme", "code": "validation-failed"}
+				},
+				{
+					"message": "Validation error of type FieldUndefined",
+					"extensions": {"classification": "ValidationError"}
+				}
+			]
+		}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := errs[0].Code(), "validation-failed"; got != want {
+		t.Errorf("got code: %q, want: %q", got, want)
+	}
+	if got, want := errs[0].Extensions["path"], "$.selectionSet.user.selectionSet.nme"; got != want {
+		t.Errorf("got path extension: %v, want: %v", got, want)
+	}
+	if got, want := errs[1].Code(), ""; got != want {
+		t.Errorf("got code: %q, want: %q", got, want)
+	}
+	if got, want := errs[1].Classification(), "ValidationError"; got != want {
+		t.Errorf("got classification: %q, want: %q", got, want)
+	}
+	if !errs.HasCode("validation-failed") || errs.HasCode("access-denied") {
+		t.Error("got wrong HasCode result")
+	}
+}
+
+func TestPath_UnmarshalJSON(t *testing.T) {
+	var e graphql.Error
+	err := json.Unmarshal([]byte(`{"message": "boom", "path": ["hero", "friends", 1, "name"]}`), &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := e.Path, (graphql.Path{"hero", "friends", 1, "name"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got path: %#v, want: %#v", got, want)
+	}
+	if got, want := e.Path.String(), "hero.friends.1.name"; got != want {
+		t.Errorf("got path string: %q, want: %q", got, want)
+	}
+	if got, want := (graphql.Errors{e}).String(), "boom (path: hero.friends.1.name)"; got != want {
+		t.Errorf("got String(): %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Query_errorTypes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql/down", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Retry-After", "120")
+		http.Error(w, "important message", http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/graphql/huge", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		mustWrite(w, strings.Repeat("x", 1<<20))
+	})
+	mux.HandleFunc("/graphql/mismatch", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+	mux.HandleFunc("/graphql/invalid", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": `)
+	})
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+
+	client := graphql.NewClient("/graphql/down", &http.Client{Transport: localRoundTripper{handler: mux}})
+	err := client.Query(context.Background(), &q, nil)
+	var netErr *graphql.NetworkError
+	if !errors.As(err, &netErr) {
+		t.Fatalf("got error: %v, want: *graphql.NetworkError", err)
+	}
+	if got, want := netErr.StatusCode(), http.StatusServiceUnavailable; got != want {
+		t.Errorf("got status code: %v, want: %v", got, want)
+	}
+	if got, want := netErr.Body(), "important message\n"; got != want {
+		t.Errorf("got body: %q, want: %q", got, want)
+	}
+	if got, want := netErr.Header().Get("Retry-After"), "120"; got != want {
+		t.Errorf("got Retry-After: %q, want: %q", got, want)
+	}
+
+	client = graphql.NewClient("/graphql/huge", &http.Client{Transport: localRoundTripper{handler: mux}})
+	err = client.Query(context.Background(), &q, nil)
+	if !errors.As(err, &netErr) {
+		t.Fatalf("got error: %v, want: *graphql.NetworkError", err)
+	}
+	if got, want := len(netErr.Body()), 64<<10; got != want {
+		t.Errorf("got body of %d bytes, want: %d", got, want)
+	}
+
+	errTransport := errors.New("connection refused")
+	client = graphql.NewClient("/graphql", &http.Client{Transport: errorRoundTripper{err: errTransport}})
+	err = client.Query(context.Background(), &q, nil)
+	if !errors.As(err, &netErr) {
+		t.Fatalf("got error: %v, want: *graphql.NetworkError", err)
+	}
+	if !errors.Is(err, errTransport) || netErr.StatusCode() != 0 {
+		t.Errorf("got error: %v, want: wrapped transport error", err)
+	}
+
+	for _, path := range []string{"/graphql/mismatch", "/graphql/invalid"} {
+		client = graphql.NewClient(path, &http.Client{Transport: localRoundTripper{handler: mux}})
+		err = client.Query(context.Background(), &q, nil)
+		var decodeErr *graphql.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("%s: got error: %v, want: *graphql.DecodeError", path, err)
+		}
+	}
+}
+
+func TestClient_WithMaxResponseSize(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "`+strings.Repeat("a", 100)+`"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithMaxResponseSize(64)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var tooLarge *graphql.ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("got error: %v, want a *graphql.ResponseTooLargeError", err)
+	}
+	if got, want := tooLarge.Limit, int64(64); got != want {
+		t.Errorf("got limit: %d, want: %d", got, want)
+	}
+
+	client.WithMaxResponseSize(1 << 10)
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// errorRoundTripper is an http.RoundTripper that fails every request with err.
+type errorRoundTripper struct {
+	err error
+}
+
+func (e errorRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, e.err
+}
+
+func TestClient_Query_errorDecoder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"error": {"reason": "quota exceeded", "code": "QUOTA"}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	client.WithErrorDecoders(graphql.ErrorDecoderFunc(func(body []byte) (graphql.Errors, error) {
+		var out struct {
+			Error *struct {
+				Reason string
+				Code   string
+			}
+		}
+		if err := json.Unmarshal(body, &out); err != nil {
+			return nil, err
+		}
+		if out.Error == nil {
+			return nil, errors.New("no error object")
+		}
+		return graphql.Errors{{
+			Message:    out.Error.Reason,
+			Extensions: map[string]interface{}{"code": out.Error.Code},
+		}}, nil
+	}))
+
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := err.Error(), "quota exceeded"; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+	if !errs.HasCode("QUOTA") {
+		t.Errorf("got codes of %v, want: QUOTA", errs)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+	statusCodeTests := []struct {
+		statusCode int
+		want       bool
+	}{
+		{http.StatusTooManyRequests, true},
+		{http.StatusRequestTimeout, true},
+		{http.StatusInternalServerError, true},
+		{http.StatusBadGateway, true},
+		{http.StatusServiceUnavailable, true},
+		{http.StatusNotImplemented, false},
+		{http.StatusUnauthorized, false},
+		{http.StatusForbidden, false},
+		{http.StatusNotFound, false},
+	}
+	for _, tc := range statusCodeTests {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			http.Error(w, "failure", tc.statusCode)
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+		err := client.Query(context.Background(), &q, nil)
+		if got := graphql.IsRetryable(err); got != tc.want {
+			t.Errorf("status %d: got retryable %v, want: %v", tc.statusCode, got, tc.want)
+		}
+	}
+
+	transportTests := []struct {
+		err  error
+		want bool
+	}{
+		{syscall.ECONNRESET, true},
+		{syscall.ECONNREFUSED, true},
+		{io.ErrUnexpectedEOF, true},
+		{context.DeadlineExceeded, true},
+		{context.Canceled, false},
+		{errors.New("unsupported protocol scheme"), false},
+	}
+	for _, tc := range transportTests {
+		client := graphql.NewClient("/graphql", &http.Client{Transport: errorRoundTripper{err: tc.err}})
+		err := client.Query(context.Background(), &q, nil)
+		if got := graphql.IsRetryable(err); got != tc.want {
+			t.Errorf("transport error %v: got retryable %v, want: %v", tc.err, got, tc.want)
+		}
+	}
+
+	if graphql.IsRetryable(graphql.Errors{{Message: "access denied"}}) {
+		t.Error("got retryable GraphQL errors, want permanent")
+	}
+	if graphql.IsRetryable(errors.New("other")) || graphql.IsRetryable(nil) {
+		t.Error("got retryable unknown error, want permanent")
+	}
+}
+
+func TestClient_WithQueryInErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"login": "gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithQueryInErrors()
+
+	var q struct {
+		User struct {
+			Name graphql.String
+		} `graphql:"user(id: $id)"`
+	}
+	err := client.Query(context.Background(), &q, map[string]interface{}{
+		"id": graphql.ID("secret-id"),
+	})
+	var opErr *graphql.OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("got error: %v, want: *graphql.OperationError", err)
+	}
+	if got, want := opErr.Query, "query ($id:ID!){user(id: $id){name}}"; got != want {
+		t.Errorf("got query: %q, want: %q", got, want)
+	}
+	if got, want := opErr.Variables, []string{"id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got variables: %v, want: %v", got, want)
+	}
+	if strings.Contains(err.Error(), "secret-id") {
+		t.Errorf("got error %q containing variable value, want redacted", err)
+	}
+	var decodeErr *graphql.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Errorf("got error: %v, want: wrapped *graphql.DecodeError", err)
+	}
+}
+
+func TestClient_Query_malformedResponse(t *testing.T) {
+	tests := []struct {
+		body      string
+		wantError string
+	}{
+		{
+			body:      `{"message": "upstream unavailable"}`,
+			wantError: `malformed GraphQL response: no data and no errors, body: "{\"message\": \"upstream unavailable\"}"`,
+		},
+		{
+			body:      `<html><body>502 Bad Gateway</body></html>`,
+			wantError: `malformed GraphQL response: unexpected Content-Type "text/html; charset=utf-8", body: "<html><body>502 Bad Gateway</body></html>"`,
+		},
+	}
+	for _, tc := range tests {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			mustWrite(w, tc.body)
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+		var q struct {
+			User struct {
+				Name graphql.String
+			}
+		}
+		err := client.Query(context.Background(), &q, nil)
+		var malformedErr *graphql.MalformedResponseError
+		if !errors.As(err, &malformedErr) {
+			t.Fatalf("got error: %v, want: *graphql.MalformedResponseError", err)
+		}
+		if got := err.Error(); got != tc.wantError {
+			t.Errorf("got error: %v, want: %v", got, tc.wantError)
+		}
+		if got := malformedErr.Snippet(); got != tc.body {
+			t.Errorf("got snippet: %q, want: %q", got, tc.body)
+		}
+		var decodeErr *graphql.DecodeError
+		if !errors.As(err, &decodeErr) {
+			t.Errorf("got error: %v, want: *graphql.DecodeError", err)
+		}
+	}
+}
+
+func TestClient_Query_contentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		wantError   string
+	}{
+		{"application/json; charset=utf-8", `{"data": {"user": {"name": "Gopher"}}}`, ""},
+		{"application/graphql-response+json", `{"data": {"user": {"name": "Gopher"}}}`, ""},
+		{"text/html", `<html><body>Sign in</body></html>`, `malformed GraphQL response: unexpected Content-Type "text/html", body: "<html><body>Sign in</body></html>"`},
+		{"application/xml", `{"data": {"user": {"name": "Gopher"}}}`, `malformed GraphQL response: unexpected Content-Type "application/xml", body: "{\"data\": {\"user\": {\"name\": \"Gopher\"}}}"`},
+	}
+	for _, tc := range tests {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", tc.contentType)
+			mustWrite(w, tc.body)
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+		var q struct {
+			User struct {
+				Name graphql.String
+			}
+		}
+		err := client.Query(context.Background(), &q, nil)
+		if tc.wantError == "" {
+			if err != nil {
+				t.Errorf("%s: got error: %v", tc.contentType, err)
+			}
+			continue
+		}
+		var malformedErr *graphql.MalformedResponseError
+		if !errors.As(err, &malformedErr) {
+			t.Fatalf("%s: got error: %v, want: *graphql.MalformedResponseError", tc.contentType, err)
+		}
+		if got := err.Error(); got != tc.wantError {
+			t.Errorf("got error: %v, want: %v", got, tc.wantError)
+		}
+		if got := malformedErr.ContentType(); got != tc.contentType {
+			t.Errorf("got Content-Type: %q, want: %q", got, tc.contentType)
+		}
+	}
+}
+
+func TestClient_Query_messageListErrors(t *testing.T) {
+	// Large enough not to fit into a single read of the response body.
+	padding := strings.Repeat("x", 64<<10)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{
+			"data": {"user": {"name": "`+padding+`"}},
+			"errors": [
+				{
+					"message": ["first failure", "second failure"],
+					"locations": [{"line": 1, "column": 2}]
+				}
+			]
+		}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Name graphql.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := err.Error(), "first failure,second failure"; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+	if got, want := errs[1].Locations, []graphql.Location{{Line: 1, Column: 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got locations: %v, want: %v", got, want)
+	}
+	if got, want := string(q.User.Name), padding; got != want {
+		t.Errorf("got q.User.Name of %d bytes, want: %d", len(got), len(want))
+	}
+}
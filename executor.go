@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultExecutorConcurrency is the number of operations a BatchExecutor
+// executes at once unless configured otherwise.
+const defaultExecutorConcurrency = 8
+
+// BatchExecutor executes many independent operations in separate requests,
+// with at most a bounded number in flight, e.g. to fan out per-entity queries:
+//
+//	e := client.NewBatchExecutor(4)
+//	users := make([]userQuery, len(ids))
+//	for i, id := range ids {
+//		e.Query(&users[i], map[string]interface{}{"id": graphql.ID(id)})
+//	}
+//	err := e.Exec(ctx)
+//
+// Every operation goes through the middleware of the client, so they wait
+// for its RateLimiter, if any, like any other request.
+type BatchExecutor struct {
+	c           *Client
+	concurrency int
+	ops         []*BatchOperation
+}
+
+// NewBatchExecutor returns an empty BatchExecutor executing at most concurrency
+// operations of c at once, or 8 if concurrency isn't positive.
+func (c *Client) NewBatchExecutor(concurrency int) *BatchExecutor {
+	if concurrency <= 0 {
+		concurrency = defaultExecutorConcurrency
+	}
+	return &BatchExecutor{c: c, concurrency: concurrency}
+}
+
+// Query adds a query derived from q to the executor, like Client.Query.
+// The response is populated into q by Exec.
+func (e *BatchExecutor) Query(q interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return e.add(QueryOperation, q, variables, "", options)
+}
+
+// NamedQuery adds a query with operation name to the executor, like Client.NamedQuery.
+func (e *BatchExecutor) NamedQuery(name string, q interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return e.add(QueryOperation, q, variables, name, options)
+}
+
+// Mutate adds a mutation derived from m to the executor, like Client.Mutate.
+// The response is populated into m by Exec.
+func (e *BatchExecutor) Mutate(m interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return e.add(MutationOperation, m, variables, "", options)
+}
+
+// NamedMutate adds a mutation with operation name to the executor, like Client.NamedMutate.
+func (e *BatchExecutor) NamedMutate(name string, m interface{}, variables map[string]interface{}, options ...Option) *BatchOperation {
+	return e.add(MutationOperation, m, variables, name, options)
+}
+
+func (e *BatchExecutor) add(typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) *BatchOperation {
+	op := &BatchOperation{typ: typ, v: v, variables: variables, name: name, options: options}
+	e.ops = append(e.ops, op)
+	return op
+}
+
+// Exec executes the operations of the executor, starting them in order of
+// addition, and returns the error of the first failed operation, in order of
+// addition. The errors of all operations are reported by BatchOperation.Err;
+// those not started before ctx is done fail with its error.
+func (e *BatchExecutor) Exec(ctx context.Context) error {
+	pending := make(chan *BatchOperation)
+	workers := e.concurrency
+	if workers > len(e.ops) {
+		workers = len(e.ops)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range pending {
+				op.err = e.c.do(ctx, op.typ, op.v, op.variables, op.name, op.options)
+			}
+		}()
+	}
+	for _, op := range e.ops {
+		if ctx.Err() != nil {
+			op.err = ctx.Err()
+			continue
+		}
+		select {
+		case pending <- op:
+		case <-ctx.Done():
+			op.err = ctx.Err()
+		}
+	}
+	close(pending)
+	wg.Wait()
+	for _, op := range e.ops {
+		if op.err != nil {
+			return op.err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// lockedLimiter counts the waits for it, from any goroutine.
+type lockedLimiter struct {
+	mu    sync.Mutex
+	waits int
+}
+
+func (l *lockedLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.waits++
+	return nil
+}
+
+// userHandler serves users named after their ID, failing for the ID "fail",
+// and records the maximum number of requests served at once.
+func userHandler(t *testing.T, maxInFlight *int32) http.Handler {
+	var inFlight int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			max := atomic.LoadInt32(maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+
+		var in struct {
+			Variables struct{ ID string }
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		if in.Variables.ID == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "`+in.Variables.ID+`"}}}`)
+	})
+	return mux
+}
+
+type userByIDQuery struct {
+	User struct {
+		Name string
+	} `graphql:"user(id: $id)"`
+}
+
+func TestBatchExecutor(t *testing.T) {
+	var maxInFlight int32
+	limiter := &lockedLimiter{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: userHandler(t, &maxInFlight)}}).WithRateLimiter(limiter)
+
+	e := client.NewBatchExecutor(3)
+	users := make([]userByIDQuery, 20)
+	for i := range users {
+		e.Query(&users[i], map[string]interface{}{"id": graphql.ID(fmt.Sprint(i))})
+	}
+	if err := e.Exec(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, user := range users {
+		if got, want := user.User.Name, fmt.Sprint(i); got != want {
+			t.Errorf("got users[%d].User.Name: %q, want: %q", i, got, want)
+		}
+	}
+	if maxInFlight > 3 {
+		t.Errorf("got %d operations in flight, want at most 3", maxInFlight)
+	}
+	if got, want := limiter.waits, len(users); got != want {
+		t.Errorf("got %d waits for the rate limiter, want: %d", got, want)
+	}
+}
+
+func TestBatchExecutor_errors(t *testing.T) {
+	var maxInFlight int32
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: userHandler(t, &maxInFlight)}})
+
+	e := client.NewBatchExecutor(0)
+	var ok, failed userByIDQuery
+	okOp := e.Query(&ok, map[string]interface{}{"id": graphql.ID("1")})
+	failedOp := e.Query(&failed, map[string]interface{}{"id": graphql.ID("fail")})
+	err := e.Exec(context.Background())
+	var netErr *graphql.NetworkError
+	if !errors.As(err, &netErr) || err != failedOp.Err() {
+		t.Errorf("got error: %v, want the *graphql.NetworkError of the failed operation", err)
+	}
+	if err := okOp.Err(); err != nil {
+		t.Error(err)
+	}
+	if got, want := ok.User.Name, "1"; got != want {
+		t.Errorf("got ok.User.Name: %q, want: %q", got, want)
+	}
+}
+
+func TestBatchExecutor_canceled(t *testing.T) {
+	var maxInFlight int32
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: userHandler(t, &maxInFlight)}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e := client.NewBatchExecutor(2)
+	ops := make([]*graphql.BatchOperation, 5)
+	for i := range ops {
+		ops[i] = e.Query(&userByIDQuery{}, map[string]interface{}{"id": graphql.ID(fmt.Sprint(i))})
+	}
+	if err := e.Exec(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error: %v, want: %v", err, context.Canceled)
+	}
+	for i, op := range ops {
+		if !errors.Is(op.Err(), context.Canceled) {
+			t.Errorf("got error of operation %d: %v, want: %v", i, op.Err(), context.Canceled)
+		}
+	}
+	if maxInFlight != 0 {
+		t.Error("got requests, want none")
+	}
+}
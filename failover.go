@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// FailoverPolicy is the order in which the endpoints of a client are tried.
+type FailoverPolicy uint8
+
+const (
+	// FailoverPriority tries the endpoints in order, the URL of the client first.
+	FailoverPriority FailoverPolicy = iota
+	// FailoverRoundRobin starts with the next endpoint in turn, spreading the load,
+	// and tries the others in order.
+	FailoverRoundRobin
+)
+
+// WithFailover adds endpoints serving the same schema as the URL of the client,
+// tried according to policy. When sending an operation fails with a retryable
+// error (see IsRetryable), it's sent to the next endpoint.
+//
+// Mutations without idempotency key are only sent to the next endpoint if the
+// server couldn't be reached, as it may have executed them otherwise, and
+// operations with uploads aren't, as their readers were consumed. Operations
+// whose URL was changed by a middleware are only sent to that URL.
+func (c *Client) WithFailover(policy FailoverPolicy, urls ...string) *Client {
+	c.endpoints().policy = policy
+	c.failover.urls = append([]string{c.url}, urls...)
+	return c
+}
+
+// WithHedging makes the client send queries to another endpoint if no response
+// arrived within delay, and use the first successful response, canceling the other
+// requests. Each further delay, another endpoint is tried, up to one request per
+// endpoint added by WithFailover, or two requests if there are none. Mutations are
+// never hedged.
+func (c *Client) WithHedging(delay time.Duration) *Client {
+	c.endpoints().hedgeDelay = delay
+	return c
+}
+
+func (c *Client) endpoints() *failover {
+	if c.failover == nil {
+		c.failover = &failover{urls: []string{c.url}}
+	}
+	return c.failover
+}
+
+// failover sends operations to several endpoints.
+type failover struct {
+	urls       []string // The URL of the client first.
+	policy     FailoverPolicy
+	hedgeDelay time.Duration
+	next       uint32 // Next endpoint in turn, for FailoverRoundRobin.
+}
+
+// order returns the endpoints in the order they're to be tried.
+func (f *failover) order() []string {
+	if f.policy != FailoverRoundRobin || len(f.urls) == 1 {
+		return f.urls
+	}
+	start := int(atomic.AddUint32(&f.next, 1)-1) % len(f.urls)
+	urls := make([]string, 0, len(f.urls))
+	urls = append(urls, f.urls[start:]...)
+	return append(urls, f.urls[:start]...)
+}
+
+// handler returns the handler sending operations with next to the endpoints in turn.
+func (f *failover) handler(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		if op.URL != f.urls[0] {
+			return next(ctx, op)
+		}
+		urls := f.order()
+		uploads := len(extractUploads(op.Variables)) > 0
+		if f.hedgeDelay > 0 && op.Type == QueryOperation && !op.streamed() && !uploads {
+			return f.hedge(ctx, next, op, urls)
+		}
+		var resp *Response
+		var err error
+		for _, url := range urls {
+			resp, err = next(ctx, withURL(op, url))
+			if errors.Is(err, ErrCircuitOpen) && ctx.Err() == nil {
+				// Not sent, see Client.WithCircuitBreaker.
+				continue
+			}
+			if err == nil || !IsRetryable(err) || ctx.Err() != nil || uploads {
+				break
+			}
+			if op.Type == MutationOperation && op.IdempotencyKey == "" && !isConnectionError(err) {
+				// The server may have executed the mutation.
+				break
+			}
+		}
+		return resp, err
+	}
+}
+
+// hedge sends op to the first endpoint of urls, and to the next ones
+// each f.hedgeDelay, or right away when a request fails.
+func (f *failover) hedge(ctx context.Context, next OperationHandler, op *Operation, urls []string) (*Response, error) {
+	attempts := len(urls)
+	if attempts == 1 {
+		attempts = 2
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Cancels the requests still in flight.
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	results := make(chan result, attempts)
+	sent := 0
+	send := func() {
+		url := urls[sent%len(urls)]
+		sent++
+		go func() {
+			resp, err := next(ctx, withURL(op, url))
+			results <- result{resp, err}
+		}()
+	}
+
+	send()
+	timer := time.NewTimer(f.hedgeDelay)
+	defer timer.Stop()
+	var last result
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			if sent < attempts {
+				send()
+				pending++
+				timer.Reset(f.hedgeDelay)
+			}
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				return r.resp, nil
+			}
+			last = r
+			if sent < attempts && (IsRetryable(r.err) || errors.Is(r.err, ErrCircuitOpen)) && ctx.Err() == nil {
+				send()
+				pending++
+			}
+		}
+	}
+	return last.resp, last.err
+}
+
+// withURL returns a copy of op sent to url.
+func withURL(op *Operation, url string) *Operation {
+	if op.URL == url {
+		return op
+	}
+	o := *op
+	o.URL = url
+	return &o
+}
@@ -0,0 +1,155 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// endpointsHandler serves the endpoints /a, /b and /c, recording the requested paths.
+// The endpoints in down respond with 503 Service Unavailable, and those in slow
+// wait for their request to be canceled.
+type endpointsHandler struct {
+	down, slow map[string]bool
+
+	mu    sync.Mutex
+	paths []string
+}
+
+func (h *endpointsHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mu.Lock()
+	h.paths = append(h.paths, req.URL.Path)
+	h.mu.Unlock()
+	switch {
+	case h.down[req.URL.Path]:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case h.slow[req.URL.Path]:
+		<-req.Context().Done()
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "`+req.URL.Path+`"}}}`)
+	}
+}
+
+func (h *endpointsHandler) requested() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	paths := h.paths
+	h.paths = nil
+	return paths
+}
+
+func queryUserName(t *testing.T, client *graphql.Client) string {
+	t.Helper()
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	return q.User.Name
+}
+
+func TestClient_WithFailover(t *testing.T) {
+	h := &endpointsHandler{down: map[string]bool{"/a": true}}
+	client := graphql.NewClient("/a", &http.Client{Transport: localRoundTripper{handler: h}}).
+		WithFailover(graphql.FailoverPriority, "/b", "/c")
+
+	if got, want := queryUserName(t, client), "/b"; got != want {
+		t.Errorf("got response of: %q, want: %q", got, want)
+	}
+	if got, want := h.requested(), []string{"/a", "/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+
+	client.WithFailover(graphql.FailoverRoundRobin, "/b", "/c")
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, queryUserName(t, client))
+	}
+	if want := []string{"/b", "/b", "/c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got responses of: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_WithHedging(t *testing.T) {
+	h := &endpointsHandler{slow: map[string]bool{"/a": true}}
+	client := graphql.NewClient("/a", &http.Client{Transport: localRoundTripper{handler: h}}).
+		WithFailover(graphql.FailoverPriority, "/b").
+		WithHedging(10 * time.Millisecond)
+
+	if got, want := queryUserName(t, client), "/b"; got != want {
+		t.Errorf("got response of: %q, want: %q", got, want)
+	}
+}
+
+// refusingTransport fails the requests to the paths in refused as if the
+// server refused the connection, and sends the others with next.
+type refusingTransport struct {
+	refused map[string]bool
+	next    http.RoundTripper
+}
+
+func (t refusingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.refused[req.URL.Path] {
+		return nil, syscall.ECONNREFUSED
+	}
+	return t.next.RoundTrip(req)
+}
+
+func TestClient_WithFailover_mutations(t *testing.T) {
+	h := &endpointsHandler{down: map[string]bool{"/a": true}}
+	transport := refusingTransport{refused: map[string]bool{}, next: localRoundTripper{handler: h}}
+	client := graphql.NewClient("/a", &http.Client{Transport: transport}).
+		WithFailover(graphql.FailoverPriority, "/b")
+
+	var m struct {
+		User struct {
+			Name string
+		} `graphql:"user(name: \"Gopher\")"`
+	}
+	// The server may have executed the mutation failed with 503.
+	if err := client.Mutate(context.Background(), &m, nil); err == nil {
+		t.Error("got no error, want: 503")
+	}
+	if got, want := h.requested(), []string{"/a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+	if err := client.Mutate(context.Background(), &m, nil, graphql.WithIdempotencyKey("key")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.requested(), []string{"/a", "/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+	transport.refused["/a"] = true
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := h.requested(), []string{"/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+
+	// The file was read by the first request.
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(avatar: $avatar)"`
+	}
+	variables := map[string]interface{}{"avatar": graphql.Upload{File: strings.NewReader("avatar"), Name: "avatar.png"}}
+	delete(transport.refused, "/a")
+	if err := client.Query(context.Background(), &q, variables); err == nil {
+		t.Error("got no error, want: 503")
+	}
+	if got, want := h.requested(), []string{"/a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests to: %q, want: %q", got, want)
+	}
+}
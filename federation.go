@@ -0,0 +1,149 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/runtimeracer/go-graphql-client/internal/jsonutil"
+	"github.com/runtimeracer/go-graphql-client/internal/typeinfo"
+)
+
+// EntityRegistry maps the __typename of the entities of an Apollo Federation
+// subgraph to the Go types they're decoded to by Client.Entities, whose fields
+// are selected as those of query structs:
+//
+//	registry := graphql.NewEntityRegistry().
+//		Register("User", User{}).
+//		Register("Product", Product{})
+//
+// Types must be registered before the registry is used.
+type EntityRegistry struct {
+	types map[string]reflect.Type
+}
+
+// NewEntityRegistry returns an empty EntityRegistry.
+func NewEntityRegistry() *EntityRegistry {
+	return &EntityRegistry{types: make(map[string]reflect.Type)}
+}
+
+// Register registers the struct type of v, or of the struct v points to,
+// for the entities of type typename, replacing any previous one.
+func (r *EntityRegistry) Register(typename string, v interface{}) *EntityRegistry {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	r.types[typename] = t
+	return r
+}
+
+// document returns the _entities query selecting the fields of every registered type,
+// by their __typename, as tagKey selects them.
+func (r *EntityRegistry) document(tagKey string) (string, error) {
+	typenames := make([]string, 0, len(r.types))
+	for typename := range r.types {
+		typenames = append(typenames, typename)
+	}
+	sort.Strings(typenames)
+
+	var b strings.Builder
+	b.WriteString("query Entities($representations:[_Any!]!){_entities(representations: $representations){__typename")
+	for _, typename := range typenames {
+		b.WriteString(",... on ")
+		b.WriteString(typename)
+		if err := writeQuery(&b, r.types[typename], false, tagKey); err != nil {
+			return "", err
+		}
+	}
+	b.WriteString("}}")
+	return b.String(), nil
+}
+
+// Entities executes the _entities query of an Apollo Federation subgraph
+// for representations, which are marshaled to the JSON objects with the
+// __typename and key fields of the entities to resolve, e.g.
+// map[string]interface{}{"__typename": "User", "id": "1"}.
+//
+// It returns an entity for every representation, a pointer to a new value of
+// the type registered for its __typename, or nil if the subgraph resolved none:
+//
+//	entities, err := client.Entities(ctx, registry, representations)
+//	// ...
+//	user := entities[0].(*User)
+//
+// An entity of an unregistered type is a *DecodeError.
+func (c *Client) Entities(ctx context.Context, registry *EntityRegistry, representations []interface{}, options ...Option) ([]interface{}, error) {
+	if len(registry.types) == 0 {
+		return nil, errors.New("graphql: no entity types are registered")
+	}
+	if representations == nil {
+		representations = []interface{}{}
+	}
+	document, err := registry.document(c.decodeOpts.TagKey)
+	if err != nil {
+		return nil, err
+	}
+	variables := map[string]interface{}{"representations": representations}
+	op := c.newDocumentOperation(ctx, QueryOperation, variables, "Entities", options)
+	op.Query = document
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return nil, c.annotateError(err, op)
+	}
+	var entities []interface{}
+	if resp.Data != nil {
+		if entities, err = c.decodeEntities(registry, *resp.Data); err != nil {
+			return nil, c.annotateError(&DecodeError{err: err}, op)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return entities, c.annotateError(resp.Errors, op)
+	}
+	return entities, nil
+}
+
+// decodeEntities decodes the _entities of data into the types registered for their __typename.
+func (c *Client) decodeEntities(registry *EntityRegistry, data []byte) ([]interface{}, error) {
+	var result struct {
+		Entities []json.RawMessage `json:"_entities"`
+	}
+	if err := c.codec.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	tagKey := c.decodeOpts.TagKey
+	if tagKey == "" {
+		tagKey = typeinfo.DefaultTagKey
+	}
+	entities := make([]interface{}, len(result.Entities))
+	for i, raw := range result.Entities {
+		if string(raw) == "null" {
+			continue
+		}
+		var entity struct {
+			Typename string `json:"__typename"`
+		}
+		if err := c.codec.Unmarshal(raw, &entity); err != nil {
+			return nil, fmt.Errorf("entity %d: %v", i, err)
+		}
+		t, ok := registry.types[entity.Typename]
+		if !ok {
+			return nil, fmt.Errorf("entity %d is of unregistered type %q", i, entity.Typename)
+		}
+		// Decode the entity as the fragment on its type which selected it.
+		v := reflect.New(reflect.StructOf([]reflect.StructField{
+			{Name: "Typename", Type: reflect.TypeOf(""), Tag: reflect.StructTag(fmt.Sprintf("%s:%q", tagKey, "__typename"))},
+			{Name: "Entity", Type: reflect.PtrTo(t), Tag: reflect.StructTag(fmt.Sprintf("%s:%q", tagKey, "... on "+entity.Typename))},
+		}))
+		v.Elem().Field(1).Set(reflect.New(t))
+		if err := jsonutil.UnmarshalGraphQLWithOptions(raw, v.Interface(), c.decodeOpts); err != nil {
+			return nil, fmt.Errorf("entity %d: %v", i, err)
+		}
+		entities[i] = v.Elem().Field(1).Interface()
+	}
+	return entities, nil
+}
@@ -0,0 +1,93 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type federatedUser struct {
+	ID   graphql.ID
+	Name graphql.String
+}
+
+type federatedProduct struct {
+	Upc   graphql.String
+	Price graphql.Int
+}
+
+func TestClient_Entities(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query         string
+			OperationName string
+			Variables     map[string]interface{}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		if got, want := in.Query, "query Entities($representations:[_Any!]!){_entities(representations: $representations){__typename,... on Product{upc,price},... on User{id,name}}}"; got != want {
+			t.Errorf("got query: %q, want: %q", got, want)
+		}
+		want := map[string]interface{}{"representations": []interface{}{
+			map[string]interface{}{"__typename": "User", "id": "1"},
+			map[string]interface{}{"__typename": "Product", "upc": "2"},
+			map[string]interface{}{"__typename": "User", "id": "3"},
+		}}
+		if !reflect.DeepEqual(in.Variables, want) {
+			t.Errorf("got variables: %v, want: %v", in.Variables, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"_entities": [
+			{"__typename": "User", "id": "1", "name": "Gopher"},
+			{"__typename": "Product", "upc": "2", "price": 10},
+			null
+		]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	registry := graphql.NewEntityRegistry().
+		Register("User", federatedUser{}).
+		Register("Product", &federatedProduct{})
+	entities, err := client.Entities(context.Background(), registry, []interface{}{
+		map[string]interface{}{"__typename": "User", "id": "1"},
+		map[string]interface{}{"__typename": "Product", "upc": "2"},
+		map[string]interface{}{"__typename": "User", "id": "3"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{
+		&federatedUser{ID: "1", Name: "Gopher"},
+		&federatedProduct{Upc: "2", Price: 10},
+		nil,
+	}
+	if !reflect.DeepEqual(entities, want) {
+		t.Errorf("got entities: %#v, want: %#v", entities, want)
+	}
+}
+
+func TestClient_Entities_unregisteredType(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"_entities": [{"__typename": "Review"}]}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	registry := graphql.NewEntityRegistry().Register("User", federatedUser{})
+	_, err := client.Entities(context.Background(), registry, []interface{}{map[string]interface{}{"__typename": "Review", "id": "1"}})
+	var decodeErr *graphql.DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("got error: %v, want a *graphql.DecodeError", err)
+	}
+	if got, want := err.Error(), `entity 0 is of unregistered type "Review"`; got != want {
+		t.Errorf("got error: %q, want: %q", got, want)
+	}
+}
@@ -1,11 +1,17 @@
 module github.com/runtimeracer/go-graphql-client
 
-go 1.14
+go 1.23.0
 
 require (
 	github.com/google/uuid v1.1.2
 	github.com/graph-gophers/graphql-go v0.0.0-20201112095111-7a585a01e04c
-	github.com/opentracing/opentracing-go v1.2.0 // indirect
-	golang.org/x/net v0.0.0-20201110031124-69a78807bb2b
+	golang.org/x/tools v0.30.0
 	nhooyr.io/websocket v1.8.6
 )
+
+require (
+	github.com/klauspost/compress v1.10.3 // indirect
+	github.com/opentracing/opentracing-go v1.2.0 // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
@@ -2,15 +2,19 @@ package graphql
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"io/ioutil"
+	"log/slog"
+	"mime"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
-
-	"golang.org/x/net/context/ctxhttp"
+	"time"
 
 	"github.com/runtimeracer/go-graphql-client/internal/jsonutil"
 )
@@ -18,281 +22,904 @@ import (
 // Client is a GraphQL client.
 type Client struct {
 	url        string // GraphQL server URL.
-	httpClient *http.Client
+	httpClient Doer
+	// ownTransport is the transport of httpClient dedicated to the client, if any.
+	ownTransport *http.Transport
+	userAgent    string
+	decodeOpts   jsonutil.Options
+	codec        Codec
+
+	queryInErrors bool
+	deriveNames   bool
+	getQueries    bool
+	graphQLBodies bool
+	gzipRequests  bool
+	readOnly      bool
+	decompression *decompression
+	timeout       time.Duration
+	batcher       *windowBatcher
+	dedup         *deduplicator
+	cache         *responseCache
+	revalidateFor time.Duration
+	staleFor      time.Duration
+	failover      *failover
+	breaker       *CircuitBreaker
+	chaos         Middleware
+	limiter       RateLimiter
+	retry         *RetrySettings
+	websocket     *SubscriptionClient
+
+	maxResponseSize  int64
+	maxDocumentDepth int
+	maxDocumentSize  int
+	manifest         *PersistedManifest
+	allowList        *PersistedManifest
+
+	errorDecoders        []ErrorDecoder
+	variableTransformers []VariableTransformer
+	defaultVariables     map[string]interface{} // Not modified once set, as clones share it.
+
+	// defaultOptions are applied to every request, before the options of the call.
+	defaultOptions []Option
+
+	middlewares      []Middleware
+	requestModifiers []RequestModifier
+	signer           Signer
+	tokenProvider    TokenProvider
+	responseHooks    []ResponseHook
+
+	logger               *slog.Logger
+	redactor             Redactor
+	requestIDHeader      string
+	idempotencyKeyHeader string
+	debug                DebugFunc
+	debugEnabled         uint32 // Accessed atomically, 1 if debug dumps are on.
+	closed               uint32 // Accessed atomically, 1 once Close was called.
+}
+
+// Doer sends HTTP requests, as *http.Client does. The context of
+// the requests must be respected.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
 }
 
 // NewClient creates a GraphQL client targeting the specified GraphQL server URL.
-// If httpClient is nil, then http.DefaultClient is used.
-func NewClient(url string, httpClient *http.Client) *Client {
-	if httpClient == nil {
+// If httpClient is nil, then http.DefaultClient is used. Besides *http.Client,
+// any Doer is accepted, e.g. an instrumented or retrying client, or a test double.
+// The options are applied to every request, e.g. to send an API key:
+//
+//	client := graphql.NewClient(url, nil, graphql.WithRequestHeader("x-hasura-admin-secret", secret))
+func NewClient(url string, httpClient Doer, options ...Option) *Client {
+	if hc, ok := httpClient.(*http.Client); httpClient == nil || ok && hc == nil {
 		httpClient = http.DefaultClient
 	}
 	return &Client{
 		url:        url,
 		httpClient: httpClient,
+		userAgent:  defaultUserAgent,
+		decodeOpts: jsonutil.Options{TagKey: DefaultTagKey},
+		codec:      jsonCodec{},
+
+		errorDecoders:  []ErrorDecoder{messageListErrorDecoder{}},
+		defaultOptions: options,
+	}
+}
+
+// Clone returns a copy of c, to be configured further without affecting c,
+// e.g. with another URL, headers or role. The copy shares the HTTP client and
+// transport of c, its cache, deduplication and circuit breaker middleware though,
+// so transport options set on either client apply to both. The copy of a closed
+// client is closed, but closing either client doesn't close the other (see Close).
+func (c *Client) Clone() *Client {
+	clone := *c
+	clone.errorDecoders = slices.Clip(c.errorDecoders)
+	clone.variableTransformers = slices.Clip(c.variableTransformers)
+	clone.defaultOptions = slices.Clip(c.defaultOptions)
+	clone.middlewares = slices.Clip(c.middlewares)
+	clone.requestModifiers = slices.Clip(c.requestModifiers)
+	clone.responseHooks = slices.Clip(c.responseHooks)
+	if c.decompression != nil {
+		d := &decompression{decompressors: make(map[string]Decompressor)}
+		for _, enc := range c.decompression.encodings {
+			d.add(enc, c.decompression.decompressors[enc])
+		}
+		clone.decompression = d
+	}
+	if c.batcher != nil {
+		clone.batcher = &windowBatcher{c: &clone, window: c.batcher.window, maxSize: c.batcher.maxSize}
+	}
+	if c.cache != nil {
+		cache := *c.cache
+		clone.cache = &cache
+	}
+	if c.failover != nil {
+		f := *c.failover
+		f.urls = slices.Clone(c.failover.urls)
+		clone.failover = &f
+	}
+	return &clone
+}
+
+// With returns a copy of c, as Clone does, applying options to every request
+// after the ones of c, e.g. to derive a client per Hasura role:
+//
+//	editor := client.With(graphql.WithRequestHeader("x-hasura-role", "editor"))
+func (c *Client) With(options ...Option) *Client {
+	clone := c.Clone()
+	clone.defaultOptions = append(clone.defaultOptions, options...)
+	return clone
+}
+
+// WithURL makes the client send operations to url, along with the endpoints
+// added by WithFailover, e.g. on a copy returned by Clone.
+func (c *Client) WithURL(url string) *Client {
+	c.url = url
+	if c.failover != nil {
+		c.failover.urls[0] = url
+	}
+	return c
+}
+
+// WithQueryInErrors makes returned errors include the constructed query
+// and the names of the variables, as *OperationError, which helps tracing
+// decode errors back to the document. Variable values are left out,
+// as they may contain sensitive data.
+func (c *Client) WithQueryInErrors() *Client {
+	c.queryInErrors = true
+	return c
+}
+
+// WithDerivedOperationNames makes the client name the operations executed without
+// a name after the type of their struct, without its "Query", "Mutation" or
+// "Subscription" suffix, e.g. "GetUser" for a GetUserQuery, so that they aren't
+// anonymous in the logs and dashboards of the server. Operations of anonymous
+// struct types stay anonymous.
+func (c *Client) WithDerivedOperationNames() *Client {
+	c.deriveNames = true
+	return c
+}
+
+// WithGETQueries makes the client send queries as GET requests, with the query,
+// variables and operation name as URL parameters, which lets CDNs and proxies cache
+// them and suits servers accepting only GET for persisted documents.
+// Mutations are always sent as POST requests.
+func (c *Client) WithGETQueries() *Client {
+	c.getQueries = true
+	return c
+}
+
+// WithGraphQLBodies makes the client send operations as POST requests with
+// the document as body of type application/graphql, and the variables and
+// operation name as URL parameters, for servers requiring it. Queries are
+// still sent as GET requests if enabled by WithGETQueries, and operations
+// with uploads as multipart requests.
+func (c *Client) WithGraphQLBodies() *Client {
+	c.graphQLBodies = true
+	return c
+}
+
+// WithGzipRequests makes the client compress the JSON bodies of POST requests
+// with gzip, which shrinks large generated queries and bulk variables manyfold.
+// The server must accept requests with "Content-Encoding: gzip".
+func (c *Client) WithGzipRequests() *Client {
+	c.gzipRequests = true
+	return c
+}
+
+// ErrReadOnly is returned when a client made read-only by WithReadOnly executes a mutation.
+var ErrReadOnly = errors.New("graphql: client is read-only")
+
+// WithReadOnly makes the client fail mutations with ErrReadOnly before they're
+// constructed or sent, e.g. to guarantee that dashboards and services reading
+// replicas can't write through a shared client. Copies made by Clone and With
+// stay read-only.
+func (c *Client) WithReadOnly() *Client {
+	c.readOnly = true
+	return c
+}
+
+// WithTimeout bounds the time of every attempt to send an operation, including
+// reading the response, to timeout, while still respecting the cancellation of
+// the context of the call. Each retry gets a fresh timeout.
+// WithRequestTimeout overrides it for a single call.
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// WithMaxResponseSize limits the number of bytes read from a response body,
+// after decompression, to n. Larger responses fail with *ResponseTooLargeError,
+// protecting the application from misconfigured or hostile servers.
+func (c *Client) WithMaxResponseSize(n int64) *Client {
+	c.maxResponseSize = n
+	return c
+}
+
+// WithDocumentLimits limits the documents of operations to maxDepth nested
+// selection sets and maxSize bytes, failing larger ones with *DocumentTooLargeError
+// before they're sent, e.g. for those generated by accidentally deep query structs.
+// Non-positive limits don't apply. Query structs selecting themselves always
+// fail, as their documents would be infinite.
+func (c *Client) WithDocumentLimits(maxDepth, maxSize int) *Client {
+	c.maxDocumentDepth, c.maxDocumentSize = maxDepth, maxSize
+	return c
+}
+
+// WithErrorDecoders registers decoders for errors in non-standard formats.
+// They're tried in order, after the built-in decoder for errors having
+// a list of messages, when a response doesn't fit the standard format.
+func (c *Client) WithErrorDecoders(decoders ...ErrorDecoder) *Client {
+	c.errorDecoders = append(c.errorDecoders, decoders...)
+	return c
+}
+
+// WithTagKey sets the struct tag key holding GraphQL field selections,
+// which is DefaultTagKey by default. It's useful when the query structs
+// already use "graphql" tags for other purposes.
+func (c *Client) WithTagKey(key string) *Client {
+	c.decodeOpts.TagKey = key
+	return c
+}
+
+// WithUserAgent appends an application-provided product token to the
+// User-Agent header, which otherwise identifies this library and its version.
+// E.g., WithUserAgent("my-app/1.2.3") sends "go-graphql-client/v0.2.0 my-app/1.2.3".
+func (c *Client) WithUserAgent(suffix string) *Client {
+	c.userAgent = defaultUserAgent
+	if suffix != "" {
+		c.userAgent += " " + suffix
 	}
+	return c
+}
+
+// WithNumericStringCoercion enables decoding of numeric values sent as JSON strings
+// (e.g., Hasura's bigint and numeric scalars) into integer and float struct fields.
+// By default, such values produce a decoding error.
+func (c *Client) WithNumericStringCoercion() *Client {
+	c.decodeOpts.CoerceNumericStrings = true
+	return c
 }
 
 // Query executes a single GraphQL query request,
 // with a query derived from q, populating the response into it.
 // q should be a pointer to struct that corresponds to the GraphQL schema.
-func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
-	return c.do(ctx, queryOperation, q, variables, "")
+func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, QueryOperation, q, variables, "", options)
 }
 
 // NamedQuery executes a single GraphQL query request, with operation name
-func (c *Client) NamedQuery(ctx context.Context, name string, q interface{}, variables map[string]interface{}) error {
-	return c.do(ctx, queryOperation, q, variables, name)
+func (c *Client) NamedQuery(ctx context.Context, name string, q interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, QueryOperation, q, variables, name, options)
 }
 
 // Mutate executes a single GraphQL mutation request,
 // with a mutation derived from m, populating the response into it.
 // m should be a pointer to struct that corresponds to the GraphQL schema.
-func (c *Client) Mutate(ctx context.Context, m interface{}, variables map[string]interface{}) error {
-	return c.do(ctx, mutationOperation, m, variables, "")
+func (c *Client) Mutate(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, MutationOperation, m, variables, "", options)
 }
 
 // NamedMutate executes a single GraphQL mutation request, with operation name
-func (c *Client) NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}) error {
-	return c.do(ctx, mutationOperation, m, variables, name)
+func (c *Client) NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return c.do(ctx, MutationOperation, m, variables, name, options)
 }
 
 // Query executes a single GraphQL query request,
 // with a query derived from q, populating the response into it.
 // q should be a pointer to struct that corresponds to the GraphQL schema.
 // return raw bytes message.
-func (c *Client) QueryRaw(ctx context.Context, q interface{}, variables map[string]interface{}) (*json.RawMessage, error) {
-	return c.doRaw(ctx, queryOperation, q, variables, "")
+func (c *Client) QueryRaw(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) (*json.RawMessage, error) {
+	return c.doRaw(ctx, QueryOperation, q, variables, "", options)
 }
 
 // NamedQueryRaw executes a single GraphQL query request, with operation name
 // return raw bytes message.
-func (c *Client) NamedQueryRaw(ctx context.Context, name string, q interface{}, variables map[string]interface{}) (*json.RawMessage, error) {
-	return c.doRaw(ctx, queryOperation, q, variables, name)
+func (c *Client) NamedQueryRaw(ctx context.Context, name string, q interface{}, variables map[string]interface{}, options ...Option) (*json.RawMessage, error) {
+	return c.doRaw(ctx, QueryOperation, q, variables, name, options)
 }
 
 // MutateRaw executes a single GraphQL mutation request,
 // with a mutation derived from m, populating the response into it.
 // m should be a pointer to struct that corresponds to the GraphQL schema.
 // return raw bytes message.
-func (c *Client) MutateRaw(ctx context.Context, m interface{}, variables map[string]interface{}) (*json.RawMessage, error) {
-	return c.doRaw(ctx, mutationOperation, m, variables, "")
+func (c *Client) MutateRaw(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) (*json.RawMessage, error) {
+	return c.doRaw(ctx, MutationOperation, m, variables, "", options)
 }
 
 // NamedMutateRaw executes a single GraphQL mutation request, with operation name
 // return raw bytes message.
-func (c *Client) NamedMutateRaw(ctx context.Context, name string, m interface{}, variables map[string]interface{}) (*json.RawMessage, error) {
-	return c.doRaw(ctx, mutationOperation, m, variables, name)
+func (c *Client) NamedMutateRaw(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) (*json.RawMessage, error) {
+	return c.doRaw(ctx, MutationOperation, m, variables, name, options)
 }
 
-// do executes a single GraphQL operation.
+// doRaw executes a single GraphQL operation.
 // return raw message and error
-func (c *Client) doRaw(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string) (*json.RawMessage, error) {
-	var query string
-	switch op {
-	case queryOperation:
-		query = constructQuery(v, variables, name)
-	case mutationOperation:
-		query = constructMutation(v, variables, name)
-	}
-	in := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
-	}{
-		Query:     query,
-		Variables: variables,
-	}
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(in)
+func (c *Client) doRaw(ctx context.Context, typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) (*json.RawMessage, error) {
+	op, err := c.newOperation(ctx, typ, v, variables, name, options)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := ctxhttp.Post(ctx, c.httpClient, c.url, "application/json", &buf)
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return nil, c.annotateError(err, op)
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Data, c.annotateError(resp.Errors, op)
+	}
+	return resp.Data, nil
+}
+
+// QueryWithResponse executes a query like Query, and also returns the response,
+// with its HTTP status code, headers and duration, extensions and errors.
+// The response is returned along with the error if one was received,
+// e.g. when it has GraphQL errors.
+func (c *Client) QueryWithResponse(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) (*Response, error) {
+	return c.doWithResponse(ctx, QueryOperation, q, variables, "", options)
+}
+
+// NamedQueryWithResponse executes a query with operation name like QueryWithResponse.
+func (c *Client) NamedQueryWithResponse(ctx context.Context, name string, q interface{}, variables map[string]interface{}, options ...Option) (*Response, error) {
+	return c.doWithResponse(ctx, QueryOperation, q, variables, name, options)
+}
+
+// MutateWithResponse executes a mutation like Mutate, and also returns the response,
+// as QueryWithResponse does.
+func (c *Client) MutateWithResponse(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) (*Response, error) {
+	return c.doWithResponse(ctx, MutationOperation, m, variables, "", options)
+}
+
+// NamedMutateWithResponse executes a mutation with operation name like MutateWithResponse.
+func (c *Client) NamedMutateWithResponse(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) (*Response, error) {
+	return c.doWithResponse(ctx, MutationOperation, m, variables, name, options)
+}
+
+// do executes a single GraphQL operation and unmarshal json.
+func (c *Client) do(ctx context.Context, typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) error {
+	_, err := c.doWithResponse(ctx, typ, v, variables, name, options)
+	return err
+}
+
+// doWithResponse executes a single GraphQL operation, unmarshals json,
+// and returns the response, if any.
+func (c *Client) doWithResponse(ctx context.Context, typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) (*Response, error) {
+	op, err := c.newOperation(ctx, typ, v, variables, name, options)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return nil, fmt.Errorf("non-200 OK status code: %v body: %q", resp.Status, body)
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return nil, c.annotateError(err, op)
+	}
+	if resp.Data != nil {
+		decodeOpts := c.decodeOpts
+		decodeOpts.Elements = op.options.elements
+		err := jsonutil.UnmarshalGraphQLWithOptions(*resp.Data, v, decodeOpts)
+		if err != nil {
+			// TODO: Consider including response body in returned error, if deemed helpful.
+			return resp, c.annotateError(&DecodeError{err: err}, op)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp, c.annotateError(resp.Errors, op)
 	}
-	var out struct {
-		Data   *json.RawMessage
-		Errors errors
-		//Extensions interface{} // Unused.
+	return resp, nil
+}
+
+// newOperation constructs the GraphQL operation derived from v,
+// configured by the overrides of ctx.
+func (c *Client) newOperation(ctx context.Context, typ OperationType, v interface{}, variables map[string]interface{}, name string, options []Option) (*Operation, error) {
+	if c.readOnly && typ == MutationOperation {
+		return nil, ErrReadOnly
 	}
-	err = json.NewDecoder(resp.Body).Decode(&out)
+	variables, err := c.prepareVariables(ctx, typ, v, variables)
 	if err != nil {
-		// TODO: Consider including response body in returned error, if deemed helpful.
 		return nil, err
 	}
+	op := c.newDocumentOperation(ctx, typ, variables, name, options)
+	if op.Name == "" && c.deriveNames {
+		op.Name = derivedOperationName(typ, v)
+	}
+	op.Query, err = constructOperation(typ, v, variables, op.Name, c.decodeOpts.TagKey)
+	return op, err
+}
 
-	if len(out.Errors) > 0 {
-		return out.Data, out.Errors
+// newDocumentOperation returns the GraphQL operation configured by the overrides
+// of ctx, without its document.
+func (c *Client) newDocumentOperation(ctx context.Context, typ OperationType, variables map[string]interface{}, name string, options []Option) *Operation {
+	overrides, _ := ctx.Value(contextOverridesKey{}).(*contextOverrides)
+	if overrides == nil {
+		overrides = &contextOverrides{}
 	}
+	opts := c.newRequestOptions(overrides.header, options)
+	if name == "" {
+		name = overrides.name
+	}
+	url := c.url
+	if overrides.url != "" {
+		url = overrides.url
+	}
+	if opts.endpoint != "" {
+		url = opts.endpoint
+	}
+	op := &Operation{
+		Type:      typ,
+		Name:      name,
+		URL:       url,
+		Variables: variables,
+		Header:    make(http.Header),
+		options:   opts,
+	}
+	for k, v := range opts.header {
+		op.Header[k] = v
+	}
+	if len(opts.extensions) > 0 {
+		op.Extensions = make(map[string]interface{}, len(opts.extensions))
+		for k, v := range opts.extensions {
+			op.Extensions[k] = v
+		}
+	}
+	return op
+}
 
-	return out.Data, nil
+// annotateError wraps err into an OperationError if enabled by WithQueryInErrors,
+// and into a RequestIDError if op has a request ID.
+func (c *Client) annotateError(err error, op *Operation) error {
+	if c.queryInErrors {
+		err = newOperationError(err, op.Query, op.Variables, c.redactor)
+	}
+	if op.RequestID != "" {
+		err = &RequestIDError{RequestID: op.RequestID, Err: err}
+	}
+	return err
 }
 
-// do executes a single GraphQL operation and unmarshal json.
-func (c *Client) do(ctx context.Context, op operationType, v interface{}, variables map[string]interface{}, name string) error {
-
-	var query string
-	switch op {
-	case queryOperation:
-		query = constructQuery(v, variables, name)
-	case mutationOperation:
-		query = constructMutation(v, variables, name)
-	}
-	in := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
-	}{
-		Query:     query,
-		Variables: variables,
+// execute executes op through the middleware chain.
+func (c *Client) execute(ctx context.Context, op *Operation) (*Response, error) {
+	if c.isClosed() {
+		return nil, ErrClientClosed
 	}
-	var buf bytes.Buffer
-	err := json.NewEncoder(&buf).Encode(in)
-	if err != nil {
-		return err
+	if err := c.checkDocument(op.Query); err != nil {
+		return nil, err
 	}
-	resp, err := ctxhttp.Post(ctx, c.httpClient, c.url, "application/json", &buf)
-	if err != nil {
-		return err
+	c.assignRequestID(ctx, op)
+	c.assignIdempotencyKey(op)
+	h := c.send
+	if c.chaos != nil {
+		h = c.chaos(h)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		body, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("non-200 OK status code: %v body: %q", resp.Status, body)
+	if c.limiter != nil {
+		h = c.rateLimit(h)
+	}
+	if c.logger != nil {
+		h = c.logAttempt(h)
+	}
+	if c.breaker != nil {
+		h = c.breaker.Middleware()(h)
+	}
+	if c.failover != nil {
+		h = c.failover.handler(h)
+	}
+	if c.retry != nil {
+		h = c.retryHandler(h)
+	}
+	// Deduplication and the cache are inside authenticate, so that responses
+	// are only shared by the queries authenticated with the same token.
+	if c.dedup != nil {
+		h = c.dedup.handler(h)
+	}
+	if c.cache != nil {
+		h = c.cache.handler(h)
+	}
+	if c.tokenProvider != nil {
+		h = c.authenticate(h)
 	}
-	var out graphQLStdOut
-	out, err = c.unmarshalGraphQLResult(resp.Body)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		h = c.middlewares[i](h)
+	}
+	if c.logger != nil {
+		h = c.logOperation(h)
+	}
+	return h(ctx, op)
+}
+
+// send sends a single GraphQL operation to the server,
+// and returns the decoded response envelope.
+// It's the innermost OperationHandler of the middleware chain.
+func (c *Client) send(ctx context.Context, op *Operation) (*Response, error) {
+	if c.allowList != nil {
+		if _, ok := c.allowList.ID(op.Query); !ok {
+			return nil, ErrNotPersisted
+		}
+	}
+	if op.streamed() {
+		return c.sendStream(ctx, op)
+	}
+	if c.websocket != nil && len(extractUploads(op.Variables)) == 0 {
+		ctx, cancel := c.withTimeout(ctx, op.options)
+		defer cancel()
+		return c.websocket.execute(ctx, op)
+	}
+	if slot, ok := ctx.Value(batchSlotKey{}).(*batchSlot); ok && slot.collector.c == c {
+		if c.batchable(op) {
+			return slot.collector.send(ctx, slot, op)
+		}
+		// Don't hold back the rest of the batch.
+		slot.collector.release(slot)
+	} else if c.batcher != nil && c.batchable(op) {
+		return c.batcher.send(ctx, op)
+	}
+	return c.sendSingle(ctx, op)
+}
+
+// sendSingle sends op to the server in a request of its own.
+func (c *Client) sendSingle(ctx context.Context, op *Operation) (*Response, error) {
+	ctx, cancel := c.withTimeout(ctx, op.options)
+	defer cancel()
+	req, err := c.newRequest(ctx, op)
 	if err != nil {
-		// TODO: Consider including response body in returned error, if deemed helpful.
-		return err
+		return nil, err
 	}
-	if out.Data != nil {
-		err := jsonutil.UnmarshalGraphQL(*out.Data, v)
+	var out *Response
+	err = c.roundTrip(ctx, []*Operation{op}, req, func(resp *http.Response, body []byte, duration time.Duration) error {
+		if resp.StatusCode != http.StatusOK {
+			// Many servers report failed operations with a 4xx or 5xx status code
+			// along with a regular GraphQL response. Surface its errors if there are any.
+			if result, err := c.unmarshalGraphQLResult(body); err == nil && len(result.Errors) > 0 {
+				out = result.withHTTP(resp, duration)
+				return nil
+			}
+			return newStatusError(resp, body)
+		}
+		if err := checkContentType(resp, body); err != nil {
+			return &DecodeError{err: err}
+		}
+		result, err := c.unmarshalGraphQLResult(body)
 		if err != nil {
-			// TODO: Consider including response body in returned error, if deemed helpful.
-			return err
+			return &DecodeError{err: err}
 		}
+		out = result.withHTTP(resp, duration)
+		return nil
+	})
+	return out, err
+}
+
+// withTimeout bounds ctx by the timeout of opts, or of c if unset.
+// opts may be nil.
+func (c *Client) withTimeout(ctx context.Context, opts *requestOptions) (context.Context, context.CancelFunc) {
+	timeout := c.timeout
+	if opts != nil && opts.timeout > 0 {
+		timeout = opts.timeout
 	}
-	if len(out.Errors) > 0 {
-		return out.Errors
+	if timeout <= 0 {
+		return ctx, func() {}
 	}
-	return nil
+	return context.WithTimeout(ctx, timeout)
 }
 
-func (c *Client) unmarshalGraphQLResult(responseBody io.Reader) (graphQLStdOut, error) {
-	// Try unmarshal into default format
-	var output graphQLStdOut
-	buf := &bytes.Buffer{}
-	tee := io.TeeReader(responseBody, buf)
-	err := json.NewDecoder(tee).Decode(&output)
+// roundTrip sends req, carrying ops, and reads the response, calling the response
+// hooks and then handle with the response, its body, which is valid until handle
+// returns, and the time elapsed.
+func (c *Client) roundTrip(ctx context.Context, ops []*Operation, req *http.Request, handle func(resp *http.Response, body []byte, duration time.Duration) error) error {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		// TODO: Consider including response body in returned error, if deemed helpful.
-		// TODO: Add Warning message somehow that default is not working
-		var extFormat graphQLExtOut
-		err := json.NewDecoder(buf).Decode(&extFormat)
+		c.dumpDebug(ctx, ops, nil, nil, time.Since(start), err)
+		return &NetworkError{err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody := resp.Body
+	if c.decompression != nil {
+		respBody, err = c.decompression.body(resp)
 		if err != nil {
-			// Output too weird or query error
-			return output, err
+			return &NetworkError{err: err}
 		}
+		defer respBody.Close()
+	}
 
-		// Convert Ext to default to meet criteria
-		output = graphQLStdOut{
-			Data:       extFormat.Data,
-			Errors:     extFormat.Errors.ConvertToStandard(),
-			Extensions: extFormat.Extensions,
+	// Read the body once, so that it can be decoded in several formats.
+	respBuf := getBuffer()
+	defer putBuffer(respBuf)
+	if c.maxResponseSize > 0 {
+		if resp.ContentLength > c.maxResponseSize {
+			return &ResponseTooLargeError{Limit: c.maxResponseSize}
 		}
+		respBody = ioutil.NopCloser(io.LimitReader(respBody, c.maxResponseSize+1))
 	}
-	return output, nil
-}
-
-type graphQLStdOut struct {
-	Data       *json.RawMessage
-	Errors     errors
-	Extensions interface{}
+	if _, err := respBuf.ReadFrom(respBody); err != nil {
+		return &NetworkError{err: err}
+	}
+	if c.maxResponseSize > 0 && int64(respBuf.Len()) > c.maxResponseSize {
+		return &ResponseTooLargeError{Limit: c.maxResponseSize}
+	}
+	body := respBuf.Bytes()
+	duration := time.Since(start)
+	c.dumpDebug(ctx, ops, resp, body, duration, nil)
+
+	if len(c.responseHooks) > 0 {
+		var op *Operation
+		if len(ops) == 1 {
+			op = ops[0]
+		}
+		raw := &RawResponse{
+			Operation:  op,
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Duration:   duration,
+			Body:       body,
+		}
+		for _, hook := range c.responseHooks {
+			if err := hook(ctx, raw); err != nil {
+				return err
+			}
+		}
+	}
+	return handle(resp, body, duration)
 }
 
-type graphQLExtOut struct {
-	Data       *json.RawMessage
-	Errors     errorsExt
-	Extensions interface{}
+// payload returns the payload sending op, with the persisted ID of its document
+// instead if enabled by WithPersistedManifest.
+func (c *Client) payload(op *Operation) (requestPayload, error) {
+	in := requestPayload{
+		Query:      op.Query,
+		Variables:  op.Variables,
+		Extensions: op.Extensions,
+	}
+	if c.manifest != nil {
+		id, ok := c.manifest.ID(op.Query)
+		if !ok {
+			return requestPayload{}, ErrNotPersisted
+		}
+		in.Query, in.ID = "", id
+	}
+	return in, nil
 }
 
-// errors represents the "errors" array in a response from a GraphQL server.
-// If returned via error interface, the slice is expected to contain at least 1 element.
-//
-// Specification: https://facebook.github.io/graphql/#sec-Errors.
-type errors []errorStruct
-type errorStruct struct {
-	Message   string
-	Locations []struct {
-		Line   int
-		Column int
+// newRequest builds the HTTP request sending op to the GraphQL server.
+// Queries are sent as GET requests if enabled by WithGETQueries,
+// operations with uploads as multipart POST requests, and everything else
+// as application/graphql POST requests if enabled by WithGraphQLBodies,
+// or otherwise as JSON-encoded POST requests.
+func (c *Client) newRequest(ctx context.Context, op *Operation) (*http.Request, error) {
+	in, err := c.payload(op)
+	if err != nil {
+		return nil, err
+	}
+	uploads := extractUploads(in.Variables)
+	var req *http.Request
+	var body []byte // Sent body, for the signer.
+	switch {
+	case c.getQueries && op.Type == QueryOperation && len(uploads) == 0:
+		req, err = c.newGETRequest(ctx, op, in)
+		body = []byte{}
+	case len(uploads) > 0:
+		req, err = c.newMultipartRequest(ctx, op, in, uploads)
+	case c.graphQLBodies:
+		req, body, err = c.newGraphQLRequest(ctx, op, in)
+	default:
+		req, body, err = c.newJSONRequest(ctx, op.URL, in)
+	}
+	if err != nil {
+		return nil, err
 	}
+	if err := c.prepareRequest(req, op.Header, op.queryParams(), op.RequestID, body); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
-// Error implements error interface.
-func (e errors) Error() string {
-	if len(e) == 0 {
-		return ""
+// prepareRequest sets the headers of req, including header, adds params to
+// its URL, and applies the request modifiers and the signer to it.
+func (c *Client) prepareRequest(req *http.Request, header http.Header, params url.Values, requestID string, body []byte) error {
+	if len(params) > 0 {
+		query := req.URL.Query()
+		for k, v := range params {
+			query[k] = v
+		}
+		req.URL.RawQuery = query.Encode()
 	}
-	var stringOutput = make([]string, len(e))
-	for i := range e {
-		stringOutput[i] = fmt.Sprintf("%v", e[i].Message)
+	req.Header.Set("User-Agent", c.userAgent)
+	if requestID != "" {
+		req.Header.Set(c.requestIDHeader, requestID)
 	}
-	return strings.Join(stringOutput, ",")
+	if c.decompression != nil {
+		req.Header.Set("Accept-Encoding", c.decompression.acceptEncoding())
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	for _, modify := range c.requestModifiers {
+		if err := modify(req); err != nil {
+			return err
+		}
+	}
+	if c.signer != nil {
+		if err := c.signer.Sign(req, body); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// errorsExt represents the "errors" array in a response from a GraphQL server.
-// If returned via error interface, the slice is expected to contain at least 1 element.
-// The "Ext" variant of this struct is able to handle non-standard implementations of the error message
-type errorsExt []errorsExtStruct
-type errorsExtStruct struct {
-	Message   []interface{}
-	Locations []struct {
-		Line   int
-		Column int
+// newGETRequest returns a GET request with the payload in as URL parameters.
+func (c *Client) newGETRequest(ctx context.Context, op *Operation, in requestPayload) (*http.Request, error) {
+	u, err := c.urlWithParams(op, in, true)
+	if err != nil {
+		return nil, err
 	}
+	return http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 }
 
-// Error implements error interface.
-func (e errorsExt) Error() string {
-	if len(e) == 0 {
-		return ""
+// newGraphQLRequest returns a POST request with the document of in as
+// application/graphql body, and the rest of in as URL parameters, along with the body.
+func (c *Client) newGraphQLRequest(ctx context.Context, op *Operation, in requestPayload) (*http.Request, []byte, error) {
+	u, err := c.urlWithParams(op, in, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := []byte(in.Query)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
 	}
+	req.Header.Set("Content-Type", "application/graphql")
+	return req, body, nil
+}
 
-	var stringOutput = make([]string, len(e[0].Message))
-	for i := range e[0].Message {
-		stringOutput[i] = fmt.Sprintf("%v", e[0].Message[i])
+// urlWithParams returns the endpoint of op with the payload in as URL parameters,
+// including the document if withQuery is true.
+func (c *Client) urlWithParams(op *Operation, in requestPayload, withQuery bool) (string, error) {
+	u, err := url.Parse(op.URL)
+	if err != nil {
+		return "", err
 	}
-	return strings.Join(stringOutput, ",")
+	params := u.Query()
+	if in.ID != "" {
+		params.Set("id", in.ID)
+	} else if withQuery {
+		params.Set("query", in.Query)
+	}
+	if len(in.Variables) > 0 {
+		variables, err := c.codec.Marshal(in.Variables)
+		if err != nil {
+			return "", err
+		}
+		params.Set("variables", string(variables))
+	}
+	if len(in.Extensions) > 0 {
+		extensions, err := c.codec.Marshal(in.Extensions)
+		if err != nil {
+			return "", err
+		}
+		params.Set("extensions", string(extensions))
+	}
+	if op.Name != "" {
+		params.Set("operationName", op.Name)
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), nil
 }
 
-// ConvertToStandard translates extended error structs into structs matching the GraphQL Standard
-func (e errorsExt) ConvertToStandard() errors {
-	if len(e) == 0 {
-		return nil
+// newMultipartRequest returns a POST request with the payload in and its uploads as a multipart body.
+func (c *Client) newMultipartRequest(ctx context.Context, op *Operation, in requestPayload, uploads []fileUpload) (*http.Request, error) {
+	// The body is created last, as its writer only stops once it's read or closed.
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, op.URL, nil)
+	if err != nil {
+		return nil, err
 	}
+	body, contentType := newMultipartBody(c.codec, in, uploads)
+	req.Body = body
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
 
-	standardError := make(errors, len(e[0].Message))
-	for i := range e[0].Message {
-		standardError[i] = errorStruct{
-			Message:   fmt.Sprintf("%v", e[0].Message[i]),
-			Locations: e[0].Locations,
+// newJSONRequest returns a POST request to endpoint with in as JSON body,
+// compressed if enabled by WithGzipRequests, along with the body.
+func (c *Client) newJSONRequest(ctx context.Context, endpoint string, in interface{}) (*http.Request, []byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if c.gzipRequests {
+		zw := gzipWriterPool.Get().(*gzip.Writer)
+		defer gzipWriterPool.Put(zw)
+		zw.Reset(buf)
+		if err := encodeJSON(zw, c.codec, in); err != nil {
+			return nil, nil, err
 		}
+		if err := zw.Close(); err != nil {
+			return nil, nil, err
+		}
+	} else if err := encodeJSON(buf, c.codec, in); err != nil {
+		return nil, nil, err
+	}
+	// The transport may still read the body after the response arrived,
+	// so it's copied out of the pooled buffer, at its exact size.
+	body := append([]byte(nil), buf.Bytes()...)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.gzipRequests {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, body, nil
+}
 
-	return standardError
+// withHTTP sets the HTTP metadata of r from resp, and returns r.
+func (r *Response) withHTTP(resp *http.Response, duration time.Duration) *Response {
+	r.StatusCode = resp.StatusCode
+	r.Header = resp.Header
+	r.Duration = duration
+	return r
 }
 
-type operationType uint8
+// requestPayload is the JSON body of a POST request.
+// It has either the document of the operation, or its persisted ID.
+type requestPayload struct {
+	Query      string                 `json:"query,omitempty"`
+	ID         string                 `json:"id,omitempty"`
+	Variables  map[string]interface{} `json:"variables,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
 
-const (
-	queryOperation operationType = iota
-	mutationOperation
-	//subscriptionOperation // Unused.
-)
+// checkContentType checks that resp, with body, has a JSON Content-Type, such as
+// application/json or application/graphql-response+json, if any. text/plain
+// is accepted too, as sent by servers which don't set a Content-Type, such as
+// those of net/http.
+func checkContentType(resp *http.Response, body []byte) error {
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || mediaType == "text/plain") {
+		return nil
+	}
+	malformed := newMalformedResponseError(body, nil)
+	malformed.contentType = contentType
+	return malformed
+}
+
+// unmarshalGraphQLResult decodes the GraphQL response body.
+// If the response doesn't fit the format of the specification, the errors
+// are decoded by the first of the registered error decoders able to handle them.
+// The result doesn't reference body, which may be reused afterwards.
+func (c *Client) unmarshalGraphQLResult(body []byte) (*Response, error) {
+	// Try unmarshal into default format
+	var output Response
+	err := c.codec.Unmarshal(body, &output)
+	if err == nil && (output.Data != nil || len(output.Errors) > 0) {
+		return &output, nil
+	}
+
+	// TODO: Add Warning message somehow that default is not working
+	// Decode the rest of the response ignoring the errors,
+	// which are left to the error decoders.
+	var rest struct {
+		Data       *json.RawMessage
+		Extensions interface{}
+	}
+	if restErr := c.codec.Unmarshal(body, &rest); restErr != nil {
+		// Output too weird or query error
+		if err == nil {
+			err = restErr
+		}
+		return nil, newMalformedResponseError(body, err)
+	}
+	for _, d := range c.errorDecoders {
+		errs, decodeErr := d.DecodeErrors(body)
+		if decodeErr != nil {
+			continue
+		}
+		return &Response{
+			Data:       rest.Data,
+			Errors:     errs,
+			Extensions: rest.Extensions,
+		}, nil
+	}
+	if err != nil {
+		return nil, newMalformedResponseError(body, err)
+	}
+	// Neither data nor errors, e.g., a JSON error page of a proxy.
+	return nil, newMalformedResponseError(body, nil)
+}
@@ -1,12 +1,21 @@
 package graphql_test
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/runtimeracer/go-graphql-client"
 )
@@ -62,6 +71,13 @@ func TestClient_Query_partialDataWithErrorResponse(t *testing.T) {
 	if got, want := err.Error(), "Could not resolve to a node with the global id of 'NotExist'"; got != want {
 		t.Errorf("got error: %v, want: %v", got, want)
 	}
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := errs[0].Path, (graphql.Path{"node2"}); !reflect.DeepEqual(got, want) {
+		t.Errorf("got path: %v, want: %v", got, want)
+	}
 
 	if q.Node1 == nil || q.Node1.ID != "MDEyOklzc3VlQ29tbWVudDE2OTQwNzk0Ng==" {
 		t.Errorf("got wrong q.Node1: %v", q.Node1)
@@ -137,6 +153,41 @@ func TestClient_Query_errorStatusCode(t *testing.T) {
 	}
 }
 
+func TestClient_Query_errorStatusCodeWithErrorResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		mustWrite(w, `{
+			"errors": [
+				{
+					"message": "Cannot query field \"nme\" on type \"User\".",
+					"locations": [{"line": 1, "column": 8}],
+					"extensions": {"code": "GRAPHQL_VALIDATION_FAILED"}
+				}
+			]
+		}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Nme graphql.String
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var errs graphql.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got error: %v, want: graphql.Errors", err)
+	}
+	if got, want := errs[0].Code(), "GRAPHQL_VALIDATION_FAILED"; got != want {
+		t.Errorf("got code: %v, want: %v", got, want)
+	}
+	if got, want := err.Error(), `Cannot query field "nme" on type "User".`; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
 // Test that an empty (but non-nil) variables map is
 // handled no differently than a nil variables map.
 func TestClient_Query_emptyVariables(t *testing.T) {
@@ -165,6 +216,758 @@ func TestClient_Query_emptyVariables(t *testing.T) {
 	}
 }
 
+func TestClient_userAgent(t *testing.T) {
+	var got string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header.Get("User-Agent")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "go-graphql-client/" + graphql.Version; got != want {
+		t.Errorf("got User-Agent: %q, want: %q", got, want)
+	}
+
+	client.WithUserAgent("my-app/1.2.3")
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := "go-graphql-client/" + graphql.Version + " my-app/1.2.3"; got != want {
+		t.Errorf("got User-Agent: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Query_tagKey(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body := mustRead(req.Body)
+		if got, want := body, `{"query":"{u: user(id: 1){name}}"}`+"\n"; got != want {
+			t.Errorf("got body: %v, want %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"u": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithTagKey("gqlclient")
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"resolver" gqlclient:"u: user(id: 1)"`
+	}
+	err := client.Query(context.Background(), &q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Query_requestHeader(t *testing.T) {
+	var got http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	err := client.Query(context.Background(), &q, nil,
+		graphql.WithRequestHeader("Authorization", "Bearer token"),
+		graphql.WithRequestHeader("x-hasura-role", "editor"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := got.Get("Authorization"), "Bearer token"; got != want {
+		t.Errorf("got Authorization: %q, want: %q", got, want)
+	}
+	if got, want := got.Get("X-Hasura-Role"), "editor"; got != want {
+		t.Errorf("got X-Hasura-Role: %q, want: %q", got, want)
+	}
+
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := got.Get("Authorization"); got != "" {
+		t.Errorf("got Authorization: %q on next request, want none", got)
+	}
+}
+
+func TestClient_Query_defaultHeaders(t *testing.T) {
+	var got http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}},
+		graphql.WithRequestHeader("x-hasura-admin-secret", "secret"),
+		graphql.WithRequestHeader("x-hasura-role", "admin"),
+	)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	err := client.Query(context.Background(), &q, nil, graphql.WithRequestHeader("x-hasura-role", "editor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := got.Get("X-Hasura-Admin-Secret"), "secret"; got != want {
+		t.Errorf("got X-Hasura-Admin-Secret: %q, want: %q", got, want)
+	}
+	if got, want := got.Get("X-Hasura-Role"), "editor"; got != want {
+		t.Errorf("got X-Hasura-Role: %q, want: %q", got, want)
+	}
+	if got, want := got.Get("User-Agent"), "go-graphql-client/"+graphql.Version; got != want {
+		t.Errorf("got User-Agent: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Query_requestExtensions(t *testing.T) {
+	var bodies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet {
+			bodies = append(bodies, req.URL.Query().Get("extensions"))
+		} else {
+			bodies = append(bodies, mustRead(req.Body))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}},
+		graphql.WithRequestExtension("tenant", "acme"),
+	)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil, graphql.WithRequestExtension("trace", true)); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Query(context.Background(), &q, nil, graphql.WithRequestExtension("tenant", "globex")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Clone().WithGETQueries().Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"query":"{user{name}}","extensions":{"tenant":"acme","trace":true}}` + "\n",
+		`{"query":"{user{name}}","extensions":{"tenant":"globex"}}` + "\n",
+		`{"tenant":"acme"}`,
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("got bodies: %q, want: %q", bodies, want)
+	}
+}
+
+func TestClient_Query_queryParams(t *testing.T) {
+	var queries []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		queries = append(queries, req.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql?api-version=1", &http.Client{Transport: localRoundTripper{handler: mux}},
+		graphql.WithQueryParam("api-key", "secret"),
+	)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil, graphql.WithQueryParam("api-version", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Clone().WithGETQueries().Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []url.Values{
+		{"api-key": {"secret"}, "api-version": {"2"}},
+		{"api-key": {"secret"}, "api-version": {"1"}, "query": {"{user{name}}"}},
+	}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("got queries: %v, want: %v", queries, want)
+	}
+}
+
+func TestClient_Query_endpoint(t *testing.T) {
+	var paths []string
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		if req.URL.Path == "/down" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}
+	mux.HandleFunc("/graphql", handler)
+	mux.HandleFunc("/shard-1", handler)
+	mux.HandleFunc("/shard-2", handler)
+	mux.HandleFunc("/down", handler)
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithFailover(graphql.FailoverPriority, "/shard-2")
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil, graphql.WithEndpoint("/shard-1")); err != nil {
+		t.Fatal(err)
+	}
+	// The call takes precedence over the context.
+	ctx := graphql.WithURL(context.Background(), "/shard-2")
+	if err := client.Query(ctx, &q, nil, graphql.WithEndpoint("/shard-1")); err != nil {
+		t.Fatal(err)
+	}
+	// The endpoints of the client aren't tried.
+	if err := client.Query(context.Background(), &q, nil, graphql.WithEndpoint("/down")); err == nil {
+		t.Error("got no error from the endpoint down")
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/shard-1", "/shard-1", "/down", "/graphql"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths: %q, want: %q", paths, want)
+	}
+}
+
+func TestClient_Query_getQueries(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch req.Method {
+		case http.MethodGet:
+			params := req.URL.Query()
+			if got, want := params.Get("query"), `query GetUser($id:Int!){user(id: $id){name}}`; got != want {
+				t.Errorf("got query: %q, want: %q", got, want)
+			}
+			if got, want := params.Get("variables"), `{"id":1}`; got != want {
+				t.Errorf("got variables: %q, want: %q", got, want)
+			}
+			if got, want := params.Get("operationName"), "GetUser"; got != want {
+				t.Errorf("got operationName: %q, want: %q", got, want)
+			}
+			if got, want := params.Get("api"), "v1"; got != want {
+				t.Errorf("got api: %q, want: %q", got, want)
+			}
+			mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+		case http.MethodPost:
+			mustWrite(w, `{"data": {"updateUser": {"name": "Gopher"}}}`)
+		}
+	})
+	client := graphql.NewClient("/graphql?api=v1", &http.Client{Transport: localRoundTripper{handler: mux}}).WithGETQueries()
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	err := client.NamedQuery(context.Background(), "GetUser", &q, map[string]interface{}{"id": graphql.Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+
+	var m struct {
+		UpdateUser struct {
+			Name string
+		}
+	}
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.UpdateUser.Name, "Gopher"; got != want {
+		t.Errorf("got m.UpdateUser.Name: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Query_gzipRequests(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("Content-Encoding"), "gzip"; got != want {
+			t.Errorf("got Content-Encoding: %q, want: %q", got, want)
+		}
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := mustRead(zr), `{"query":"{user{name}}"}`+"\n"; got != want {
+			t.Errorf("got body: %v, want %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithGzipRequests()
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+}
+
+// TestClient_Query_pooledBuffers checks that concurrent requests, whose bodies
+// are encoded in pooled buffers and gzip writers, don't share their bodies.
+func TestClient_Query_pooledBuffers(t *testing.T) {
+	for _, gzipped := range []bool{false, true} {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+			var body io.Reader = req.Body
+			if gzipped {
+				zr, err := gzip.NewReader(req.Body)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				body = zr
+			}
+			var in struct {
+				Variables struct{ ID string }
+			}
+			if err := json.NewDecoder(body).Decode(&in); err != nil {
+				t.Error(err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			mustWrite(w, `{"data": {"user": {"name": "`+in.Variables.ID+`"}}}`)
+		})
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+		if gzipped {
+			client.WithGzipRequests()
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				var q struct {
+					User struct {
+						Name string
+					} `graphql:"user(id: $id)"`
+				}
+				// IDs of varying length leave pooled buffers of varying size.
+				if err := client.Query(context.Background(), &q, map[string]interface{}{"id": graphql.ID(id)}); err != nil {
+					t.Error(err)
+					return
+				}
+				if q.User.Name != id {
+					t.Errorf("got q.User.Name: %q, want: %q", q.User.Name, id)
+				}
+			}(strings.Repeat("x", i*i))
+		}
+		wg.Wait()
+	}
+}
+
+func TestClient_Query_withElements(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"export": {"rows": [{"id": "1"}, {"id": "2"}, {"id": "3"}]}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	type row struct {
+		ID graphql.ID
+	}
+	var q struct {
+		Export struct {
+			Rows []row
+		}
+	}
+	var ids []graphql.ID
+	err := client.Query(context.Background(), &q, nil, graphql.WithElements("export.rows", func(r row) error {
+		ids = append(ids, r.ID)
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []graphql.ID{"1", "2", "3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("got IDs: %v, want: %v", ids, want)
+	}
+	if len(q.Export.Rows) != 0 {
+		t.Errorf("got %d rows kept, want none", len(q.Export.Rows))
+	}
+
+	errStop := errors.New("stop")
+	err = client.Query(context.Background(), &q, nil, graphql.WithElements("export.rows", func(r row) error {
+		return errStop
+	}))
+	if !errors.Is(err, errStop) {
+		t.Errorf("got error: %v, want: %v", err, errStop)
+	}
+
+	err = client.Query(context.Background(), &q, nil, graphql.WithElements("export.rows", func(id graphql.ID) error {
+		return nil
+	}))
+	if got, want := fmt.Sprint(err), `elements of "export.rows" are of type graphql_test.row, not graphql.ID`; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_WithDocumentLimits(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"friends": [{"name": "Gopher"}]}}}`)
+	})
+	var q struct {
+		User struct {
+			Friends []struct {
+				Name string
+			}
+		}
+	}
+	for _, tc := range []struct {
+		maxDepth, maxSize int
+		want              string
+	}{
+		{3, 0, ""},
+		{2, 0, "graphql: document is nested 3 levels deep, exceeding the limit of 2"},
+		{0, 20, "graphql: document is 21 bytes long, exceeding the limit of 20 bytes"},
+		{0, 21, ""},
+	} {
+		calls = 0
+		client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithDocumentLimits(tc.maxDepth, tc.maxSize)
+		err := client.Query(context.Background(), &q, nil)
+		if tc.want == "" {
+			if err != nil || calls != 1 {
+				t.Errorf("limits %d, %d: got error: %v after %d requests, want none after 1", tc.maxDepth, tc.maxSize, err, calls)
+			}
+			continue
+		}
+		var tooLarge *graphql.DocumentTooLargeError
+		if !errors.As(err, &tooLarge) || err.Error() != tc.want {
+			t.Errorf("limits %d, %d: got error: %v, want: %s", tc.maxDepth, tc.maxSize, err, tc.want)
+		}
+		if calls != 0 {
+			t.Errorf("limits %d, %d: got %d requests, want none", tc.maxDepth, tc.maxSize, calls)
+		}
+	}
+}
+
+func TestClient_Query_timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+	client := graphql.NewClient(server.URL, nil).WithTimeout(10 * time.Millisecond)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v, want: %v", err, context.DeadlineExceeded)
+	}
+	if !graphql.IsRetryable(err) {
+		t.Errorf("got IsRetryable false for %v, want true", err)
+	}
+
+	client.WithTimeout(time.Hour)
+	err = client.Query(context.Background(), &q, nil, graphql.WithRequestTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error: %v, want: %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClient_Query_doer(t *testing.T) {
+	doer := doerFunc(func(req *http.Request) (*http.Response, error) {
+		if got, want := mustRead(req.Body), `{"query":"{user{name}}"}`+"\n"; got != want {
+			t.Errorf("got body: %v, want %v", got, want)
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       ioutil.NopCloser(strings.NewReader(`{"data": {"user": {"name": "Gopher"}}}`)),
+		}, nil
+	})
+	client := graphql.NewClient("/graphql", doer)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+}
+
+// doerFunc is a graphql.Doer calling the function.
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestClient_Mutate_readOnly(t *testing.T) {
+	var sent []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		sent = append(sent, mustRead(req.Body))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithReadOnly()
+
+	var m struct {
+		DeleteUser struct {
+			ID string
+		} `graphql:"deleteUser(id: 1)"`
+	}
+	if err := client.Mutate(context.Background(), &m, nil); !errors.Is(err, graphql.ErrReadOnly) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrReadOnly)
+	}
+	if _, err := client.With(graphql.WithRequestHeader("X-Role", "admin")).NamedMutateRaw(context.Background(), "DeleteUser", &m, nil); !errors.Is(err, graphql.ErrReadOnly) {
+		t.Errorf("got error of the copy: %v, want: %v", err, graphql.ErrReadOnly)
+	}
+	b := client.NewBatch()
+	mutation := b.Mutate(&m, nil)
+	if err := b.Exec(context.Background()); !errors.Is(err, graphql.ErrReadOnly) || !errors.Is(mutation.Err(), graphql.ErrReadOnly) {
+		t.Errorf("got error of the batch: %v, want: %v", err, graphql.ErrReadOnly)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("got requests: %q, want none", sent)
+	}
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(sent), 1; got != want {
+		t.Errorf("got %d requests, want: %d", got, want)
+	}
+}
+
+func TestClient_Mutate_graphQLBodies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Method, http.MethodPost; got != want {
+			t.Errorf("got method: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("Content-Type"), "application/graphql"; got != want {
+			t.Errorf("got Content-Type: %q, want: %q", got, want)
+		}
+		if got, want := mustRead(req.Body), `mutation UpdateUser($id:Int!){updateUser(id: $id){name}}`; got != want {
+			t.Errorf("got body: %q, want: %q", got, want)
+		}
+		if got, want := req.URL.Query().Get("variables"), `{"id":1}`; got != want {
+			t.Errorf("got variables: %q, want: %q", got, want)
+		}
+		if got, want := req.URL.Query().Get("operationName"), "UpdateUser"; got != want {
+			t.Errorf("got operationName: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"updateUser": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithGraphQLBodies()
+
+	var m struct {
+		UpdateUser struct {
+			Name string
+		} `graphql:"updateUser(id: $id)"`
+	}
+	err := client.NamedMutate(context.Background(), "UpdateUser", &m, map[string]interface{}{"id": graphql.Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.UpdateUser.Name, "Gopher"; got != want {
+		t.Errorf("got m.UpdateUser.Name: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_QueryWithResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}, "extensions": {"cost": 3}}`)
+	})
+	mux.HandleFunc("/errors", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		mustWrite(w, `{"errors": [{"message": "bad request"}]}`)
+	})
+	httpClient := &http.Client{Transport: localRoundTripper{handler: mux}}
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	resp, err := graphql.NewClient("/graphql", httpClient).QueryWithResponse(context.Background(), &q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	if got, want := resp.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got status code: %d, want: %d", got, want)
+	}
+	if got, want := resp.Header.Get("X-RateLimit-Remaining"), "42"; got != want {
+		t.Errorf("got header: %q, want: %q", got, want)
+	}
+	if got, want := resp.Extensions, map[string]interface{}{"cost": 3.0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got extensions: %v, want: %v", got, want)
+	}
+
+	resp, err = graphql.NewClient("/errors", httpClient).QueryWithResponse(context.Background(), &q, nil)
+	if err == nil || err.Error() != "bad request" {
+		t.Errorf("got error: %v, want: bad request", err)
+	}
+	if resp == nil {
+		t.Fatal("got no response")
+	}
+	if got, want := resp.StatusCode, http.StatusBadRequest; got != want {
+		t.Errorf("got status code: %d, want: %d", got, want)
+	}
+	if got, want := len(resp.Errors), 1; got != want {
+		t.Errorf("got %d errors, want: %d", got, want)
+	}
+}
+
+func TestClient_Clone(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	handler := func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.URL.Path+" "+req.Header.Get("X-Hasura-Role")+" "+req.Header.Get("X-Base"))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}
+	mux.HandleFunc("/graphql", handler)
+	mux.HandleFunc("/tenant", handler)
+	base := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}},
+		graphql.WithRequestHeader("X-Base", "1"))
+
+	editor := base.With(graphql.WithRequestHeader("X-Hasura-Role", "editor"))
+	tenant := base.Clone().WithURL("/tenant").Use(func(next graphql.OperationHandler) graphql.OperationHandler {
+		return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+			op.Header.Set("X-Hasura-Role", "tenant")
+			return next(ctx, op)
+		}
+	})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	for _, client := range []*graphql.Client{base, editor, tenant, base} {
+		if err := client.Query(context.Background(), &q, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{"/graphql  1", "/graphql editor 1", "/tenant tenant 1", "/graphql  1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got requests: %q, want: %q", got, want)
+	}
+}
+
+type GetUserQuery struct {
+	User struct {
+		Name string
+	}
+}
+
+type RenameUser struct {
+	RenameUser struct {
+		Name string
+	} `graphql:"renameUser(name: \"Gopher\")"`
+}
+
+func TestClient_WithDerivedOperationNames(t *testing.T) {
+	var bodies []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		bodies = append(bodies, mustRead(req.Body))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithDerivedOperationNames()
+
+	var named GetUserQuery
+	if err := client.Query(context.Background(), &named, nil); err != nil {
+		t.Fatal(err)
+	}
+	var m RenameUser
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.NamedQuery(context.Background(), "Explicit", &named, nil); err != nil {
+		t.Fatal(err)
+	}
+	var anonymous struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &anonymous, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"query":"query GetUser{user{name}}"}` + "\n",
+		`{"query":"mutation RenameUser{renameUser(name: \"Gopher\"){name}}"}` + "\n",
+		`{"query":"query Explicit{user{name}}"}` + "\n",
+		`{"query":"{user{name}}"}` + "\n",
+	}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("got bodies: %q, want: %q", bodies, want)
+	}
+}
+
 // localRoundTripper is an http.RoundTripper that executes HTTP transactions
 // by using handler directly, instead of going over an HTTP connection.
 type localRoundTripper struct {
@@ -191,3 +994,31 @@ func mustWrite(w io.Writer, s string) {
 		panic(err)
 	}
 }
+
+func BenchmarkClient_Query(b *testing.B) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	for _, gzipped := range []bool{false, true} {
+		b.Run(map[bool]string{false: "json", true: "gzip"}[gzipped], func(b *testing.B) {
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+			if gzipped {
+				client.WithGzipRequests()
+			}
+			variables := map[string]interface{}{"id": graphql.ID(strings.Repeat("x", 4096))}
+			var q struct {
+				User struct {
+					Name string
+				} `graphql:"user(id: $id)"`
+			}
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := client.Query(context.Background(), &q, variables); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
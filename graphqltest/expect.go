@@ -0,0 +1,160 @@
+package graphqltest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Expectation describes an operation a test expects a client to send, and
+// reports how the operations actually sent differ from it:
+//
+//	graphqltest.ExpectOperation("GetUser").
+//		WithVariable("id", 42).
+//		WithHeader("Authorization", "bearer "+token).
+//		AssertSent(t, server.Requests())
+type Expectation struct {
+	operationName string
+	variables     []expectedVariable
+	header        http.Header
+}
+
+type expectedVariable struct {
+	name  string
+	value interface{} // As decoded from JSON.
+}
+
+// ExpectOperation returns an Expectation of an operation named operationName,
+// or of any operation if it's empty.
+func ExpectOperation(operationName string) *Expectation {
+	return &Expectation{operationName: operationName, header: make(http.Header)}
+}
+
+// WithVariable expects the operation to have the variable name with value,
+// compared as JSON, e.g. 42 matches a graphql.Int(42) but not a graphql.ID("42").
+// It panics if value can't be encoded as JSON.
+func (e *Expectation) WithVariable(name string, value interface{}) *Expectation {
+	var v interface{}
+	if err := roundTrip(value, &v); err != nil {
+		panic(fmt.Sprintf("graphqltest: variable %q: %v", name, err))
+	}
+	e.variables = append(e.variables, expectedVariable{name: name, value: v})
+	return e
+}
+
+// WithHeader expects the request carrying the operation to have the header key with value.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	e.header.Add(key, value)
+	return e
+}
+
+// Assert fails t with the differences between req and e, if any, and reports whether req matches e.
+func (e *Expectation) Assert(t testing.TB, req Request) bool {
+	t.Helper()
+	diffs := e.diff(req)
+	if len(diffs) > 0 {
+		t.Errorf("graphqltest: operation %s isn't %s:\n\t%s", describe(req), e, strings.Join(diffs, "\n\t"))
+	}
+	return len(diffs) == 0
+}
+
+// AssertSent fails t unless one of requests matches e, with the differences
+// between e and the requests of the expected name. It reports whether one matched.
+func (e *Expectation) AssertSent(t testing.TB, requests []Request) bool {
+	t.Helper()
+	var b strings.Builder
+	sameName := false
+	for _, req := range requests {
+		diffs := e.diff(req)
+		if len(diffs) == 0 {
+			return true
+		}
+		if e.operationName != "" && req.OperationName != e.operationName {
+			continue
+		}
+		sameName = true
+		fmt.Fprintf(&b, "\n%s:\n\t%s", describe(req), strings.Join(diffs, "\n\t"))
+	}
+	if len(requests) == 0 {
+		t.Errorf("graphqltest: %s wasn't sent, nor any other operation", e)
+	} else if !sameName {
+		names := make([]string, len(requests))
+		for i, req := range requests {
+			names[i] = describe(req)
+		}
+		t.Errorf("graphqltest: %s wasn't sent, the operations sent are: %s", e, strings.Join(names, ", "))
+	} else {
+		t.Errorf("graphqltest: %s wasn't sent, the operations of its name differ from it:%s", e, b.String())
+	}
+	return false
+}
+
+// String describes e, e.g. `operation "GetUser" with id 42`.
+func (e *Expectation) String() string {
+	var b strings.Builder
+	if e.operationName != "" {
+		fmt.Fprintf(&b, "operation %q", e.operationName)
+	} else {
+		b.WriteString("an operation")
+	}
+	for i, v := range e.variables {
+		if i == 0 {
+			b.WriteString(" with ")
+		} else {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s %s", v.name, jsonString(v.value))
+	}
+	return b.String()
+}
+
+// diff returns the differences between req and e.
+func (e *Expectation) diff(req Request) []string {
+	var diffs []string
+	if e.operationName != "" && req.OperationName != e.operationName {
+		diffs = append(diffs, fmt.Sprintf("operation name: got %q, want %q", req.OperationName, e.operationName))
+	}
+	for _, v := range e.variables {
+		got, ok := req.Variables[v.name]
+		switch {
+		case !ok:
+			diffs = append(diffs, fmt.Sprintf("variable %s: missing, want %s", v.name, jsonString(v.value)))
+		case !reflect.DeepEqual(got, v.value):
+			diffs = append(diffs, fmt.Sprintf("variable %s: got %s, want %s", v.name, jsonString(got), jsonString(v.value)))
+		}
+	}
+	keys := make([]string, 0, len(e.header))
+	for key := range e.header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		got := req.Header.Values(key)
+		for _, want := range e.header[key] {
+			if !slices.Contains(got, want) {
+				diffs = append(diffs, fmt.Sprintf("header %s: got %q, want %q", key, got, want))
+			}
+		}
+	}
+	return diffs
+}
+
+// describe returns the name of the operation of req, quoted, or "anonymous".
+func describe(req Request) string {
+	if req.OperationName == "" {
+		return "anonymous"
+	}
+	return fmt.Sprintf("%q", req.OperationName)
+}
+
+func jsonString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,81 @@
+package graphqltest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/graphqltest"
+)
+
+func TestExpectation(t *testing.T) {
+	server := graphqltest.NewServer(t).Respond("", `{"user": {"name": "gopher"}}`)
+	client := server.Client(graphql.WithRequestHeader("Authorization", "bearer token"))
+	var q userQuery
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, map[string]interface{}{"id": graphql.Int(42)}); err != nil {
+		t.Fatal(err)
+	}
+	requests := server.Requests()
+
+	graphqltest.ExpectOperation("GetUser").
+		WithVariable("id", 42).
+		WithHeader("Authorization", "bearer token").
+		AssertSent(t, requests)
+	if !graphqltest.ExpectOperation("").WithVariable("id", graphql.Int(42)).Assert(t, requests[0]) {
+		t.Error("got a mismatch, want a match")
+	}
+
+	tests := []struct {
+		name        string
+		expectation *graphqltest.Expectation
+		want        []string
+	}{
+		{
+			name:        "variable",
+			expectation: graphqltest.ExpectOperation("GetUser").WithVariable("id", "42").WithVariable("first", 10),
+			want: []string{
+				`operation "GetUser" with id "42", first 10 wasn't sent, the operations of its name differ from it:`,
+				`variable id: got 42, want "42"`,
+				`variable first: missing, want 10`,
+			},
+		},
+		{
+			name:        "header",
+			expectation: graphqltest.ExpectOperation("GetUser").WithHeader("authorization", "bearer other"),
+			want:        []string{`header Authorization: got ["bearer token"], want "bearer other"`},
+		},
+		{
+			name:        "name",
+			expectation: graphqltest.ExpectOperation("GetViewer"),
+			want:        []string{`operation "GetViewer" wasn't sent, the operations sent are: "GetUser"`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &errorfTB{TB: t}
+			if tt.expectation.AssertSent(tb, requests) {
+				t.Error("got a match, want a mismatch")
+			}
+			if len(tb.errors) != 1 {
+				t.Fatalf("got errors: %q, want one", tb.errors)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(tb.errors[0], want) {
+					t.Errorf("got error:\n%s\nwant it to contain: %s", tb.errors[0], want)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Expect(t *testing.T) {
+	tb := &errorfTB{TB: t}
+	t.Run("unmet", func(t *testing.T) {
+		tb.TB = t
+		graphqltest.NewServer(tb).Expect(graphqltest.ExpectOperation("GetUser"))
+	})
+	if len(tb.errors) != 1 || !strings.Contains(tb.errors[0], `operation "GetUser" wasn't sent`) {
+		t.Errorf("got errors: %q, want GetUser not sent", tb.errors)
+	}
+}
@@ -0,0 +1,182 @@
+package graphqltest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+var update = flag.Bool("graphqltest.update", false, "update the golden files of graphqltest.Golden* with the current documents")
+
+// GoldenQuery compares the document of the query derived from q, as c constructs
+// it, to the golden file path, catching accidental changes of query structs:
+//
+//	graphqltest.GoldenQuery(t, client, "testdata/get_user.graphql", &GetUserQuery{}, map[string]interface{}{
+//		"id": graphql.ID(""),
+//	})
+//
+// Only the types of the variables matter, not their values. A nil c constructs
+// documents as graphql.NewClient does. See GoldenDocument for the comparison.
+func GoldenQuery(t testing.TB, c *graphql.Client, path string, q interface{}, variables map[string]interface{}) {
+	t.Helper()
+	if c == nil {
+		c = graphql.NewClient("", nil)
+	}
+	document, _, err := c.Plan(q, variables)
+	if err != nil {
+		t.Fatalf("graphqltest: %v", err)
+	}
+	GoldenDocument(t, path, document)
+}
+
+// GoldenMutation compares the document of the mutation derived from m to the golden file path, as GoldenQuery does.
+func GoldenMutation(t testing.TB, c *graphql.Client, path string, m interface{}, variables map[string]interface{}) {
+	t.Helper()
+	if c == nil {
+		c = graphql.NewClient("", nil)
+	}
+	document, _, err := c.PlanMutation(m, variables)
+	if err != nil {
+		t.Fatalf("graphqltest: %v", err)
+	}
+	GoldenDocument(t, path, document)
+}
+
+// GoldenDocument compares document, indented with a field per line, to the golden
+// file path, and fails t with their line diff if they differ. Running the tests
+// with the -graphqltest.update flag writes the documents to their golden files instead:
+//
+//	go test ./... -graphqltest.update
+func GoldenDocument(t testing.TB, path, document string) {
+	t.Helper()
+	got := indentDocument(document)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("graphqltest: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("graphqltest: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("graphqltest: golden file %s doesn't exist; run the test with -graphqltest.update to create it", path)
+	} else if err != nil {
+		t.Fatalf("graphqltest: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("graphqltest: document differs from golden file %s (-want +got):\n%s", path, lineDiff(string(want), got))
+	}
+}
+
+// indentDocument returns document with a line for each field, indented by two
+// spaces per selection set, and a final newline. Arguments, variable definitions
+// and strings are kept as they are.
+func indentDocument(document string) string {
+	var b strings.Builder
+	depth, parens := 0, 0
+	newline := func() {
+		b.WriteByte('\n')
+		b.WriteString(strings.Repeat("  ", depth))
+	}
+	for i := 0; i < len(document); i++ {
+		ch := document[i]
+		switch {
+		case ch == '"':
+			// Copy strings, including block strings and escaped quotes, as they are.
+			end := i + 1
+			if strings.HasPrefix(document[i:], `"""`) {
+				if j := strings.Index(document[i+3:], `"""`); j >= 0 {
+					end = i + 3 + j + 3
+				} else {
+					end = len(document)
+				}
+			} else {
+				for end < len(document) && document[end] != '"' {
+					if document[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end++
+			}
+			if end > len(document) {
+				end = len(document)
+			}
+			b.WriteString(document[i:end])
+			i = end - 1
+		case parens > 0:
+			if ch == '(' {
+				parens++
+			} else if ch == ')' {
+				parens--
+			}
+			b.WriteByte(ch)
+		case ch == '(':
+			parens++
+			b.WriteByte(ch)
+		case ch == '{':
+			if b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteByte('{')
+			depth++
+			newline()
+		case ch == ',':
+			newline()
+		case ch == '}':
+			depth--
+			newline()
+			b.WriteByte('}')
+		default:
+			b.WriteByte(ch)
+		}
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// lineDiff returns the lines of want missing from got prefixed by "-", and
+// those added by "+", between the lines they have in common, indented by two spaces.
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			fmt.Fprintf(&out, "  %s\n", a[i])
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&out, "- %s\n", a[i])
+			i++
+		default:
+			fmt.Fprintf(&out, "+ %s\n", b[j])
+			j++
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,80 @@
+package graphqltest_test
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/graphqltest"
+)
+
+type GetRepositoryQuery struct {
+	Repository struct {
+		Name   graphql.String
+		Issues struct {
+			Nodes []struct {
+				Title  graphql.String
+				Author struct {
+					Login graphql.String
+					User  struct {
+						Bio graphql.String
+					} `graphql:"... on User"`
+				}
+			}
+		} `graphql:"issues(first: $first, labels: [\"bug}\"]) @include(if: $withIssues)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+var repositoryVariables = map[string]interface{}{
+	"owner":      graphql.String(""),
+	"name":       graphql.String(""),
+	"first":      graphql.Int(0),
+	"withIssues": graphql.Boolean(false),
+}
+
+func TestGoldenQuery(t *testing.T) {
+	client := graphql.NewClient("", nil).WithDerivedOperationNames()
+	graphqltest.GoldenQuery(t, client, "testdata/get_repository.graphql", &GetRepositoryQuery{}, repositoryVariables)
+}
+
+// errorfTB records the errors reported by the golden file helpers.
+type errorfTB struct {
+	testing.TB
+	errors []string
+}
+
+func (tb *errorfTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func TestGoldenDocument(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user.graphql")
+	if err := flag.Set("graphqltest.update", "true"); err != nil {
+		t.Fatal(err)
+	}
+	graphqltest.GoldenDocument(t, path, "query ($id:ID!){user(id: $id){name,email}}")
+	if err := flag.Set("graphqltest.update", "false"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "query ($id:ID!) {\n  user(id: $id) {\n    name\n    email\n  }\n}\n"; string(got) != want {
+		t.Errorf("got golden file:\n%s\nwant:\n%s", got, want)
+	}
+
+	tb := &errorfTB{TB: t}
+	graphqltest.GoldenDocument(tb, path, "query ($id:ID!){user(id: $id){name,login}}")
+	if len(tb.errors) != 1 {
+		t.Fatalf("got errors: %q, want one", tb.errors)
+	}
+	wantDiff := "    user(id: $id) {\n      name\n-     email\n+     login\n    }\n"
+	if !strings.Contains(tb.errors[0], wantDiff) {
+		t.Errorf("got error:\n%s\nwant diff:\n%s", tb.errors[0], wantDiff)
+	}
+}
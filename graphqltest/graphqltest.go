@@ -0,0 +1,378 @@
+// Package graphqltest provides a GraphQL server for testing code built on
+// package graphql. It answers the operations it receives with canned
+// responses, matched by operation name and variables, and records them:
+//
+//	server := graphqltest.NewServer(t).
+//		Respond("GetUser", `{"user": {"name": "gopher"}}`)
+//	client := server.Client()
+//	// ... exercise code using client ...
+//	if got := server.Requests()[0].Variables["id"]; got != "1" {
+//		t.Errorf("got id: %v, want: 1", got)
+//	}
+//
+// It understands the requests of every transport of graphql.Client over HTTP:
+// JSON bodies, compressed or batched, GET queries, application/graphql bodies
+// and multipart uploads.
+package graphqltest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// Server is a GraphQL server answering the operations matching its handlers
+// with their responses. The handlers are tried in the order they were added,
+// and the first one matching an operation answers it. Operations matched by
+// none fail the test, and are answered with a GraphQL error.
+type Server struct {
+	*httptest.Server
+
+	t        testing.TB
+	mu       sync.Mutex
+	handlers []handler
+	requests []Request
+}
+
+// Match selects the operations a response answers. Its zero value matches any operation.
+type Match struct {
+	// OperationName is the name of the operation, if not empty.
+	OperationName string
+
+	// Variables are the values of the variables of the operation, if not nil.
+	// They're compared as JSON, and other variables are ignored.
+	Variables map[string]interface{}
+}
+
+// Response is the canned response to an operation.
+type Response struct {
+	// Data is the JSON value of the "data" field of the response, or null if empty.
+	Data string
+
+	// Errors are the entries of the "errors" field of the response.
+	Errors []graphql.Error
+
+	// Extensions is the "extensions" field of the response.
+	Extensions map[string]interface{}
+
+	// StatusCode is the HTTP status code of the response, or 200 if 0.
+	StatusCode int
+
+	// Header holds HTTP headers added to the response.
+	Header http.Header
+}
+
+// Request is an operation received by a Server.
+type Request struct {
+	// Query is the document of the operation, empty if it was sent by persisted ID.
+	Query string
+
+	// ID is the persisted ID of the operation, if any.
+	ID string
+
+	// OperationName is the name of the operation, as sent by the client or,
+	// if it wasn't, as declared by its document.
+	OperationName string
+
+	// Variables are the variables of the operation, as decoded from JSON.
+	Variables map[string]interface{}
+
+	// Header holds the HTTP headers of the request carrying the operation.
+	Header http.Header
+}
+
+type handler struct {
+	operationName string
+	variables     map[string]interface{}
+	respond       func(Request) Response
+}
+
+// NewServer starts a Server, which is closed when t and its subtests complete.
+func NewServer(t testing.TB) *Server {
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a graphql.Client sending its operations to s.
+func (s *Server) Client(options ...graphql.Option) *graphql.Client {
+	return graphql.NewClient(s.URL, s.Server.Client(), options...)
+}
+
+// Respond answers the operations named operationName, or any operation if
+// it's empty, with data, the JSON value of the "data" field of the response.
+func (s *Server) Respond(operationName, data string) *Server {
+	return s.Handle(Match{OperationName: operationName}, Response{Data: data})
+}
+
+// Handle answers the operations matching m with resp.
+func (s *Server) Handle(m Match, resp Response) *Server {
+	return s.HandleFunc(m, func(Request) Response { return resp })
+}
+
+// HandleFunc answers the operations matching m with the responses returned by fn.
+// It panics if the variables of m can't be encoded as JSON.
+func (s *Server) HandleFunc(m Match, fn func(Request) Response) *Server {
+	h := handler{operationName: m.OperationName, respond: fn}
+	if m.Variables != nil {
+		if err := roundTrip(m.Variables, &h.variables); err != nil {
+			panic(fmt.Sprintf("graphqltest: variables of %q: %v", m.OperationName, err))
+		}
+	}
+	s.mu.Lock()
+	s.handlers = append(s.handlers, h)
+	s.mu.Unlock()
+	return s
+}
+
+// Expect fails the test of s, once it completes, unless s received an
+// operation matching e. See Expectation.AssertSent.
+func (s *Server) Expect(e *Expectation) *Server {
+	s.t.Cleanup(func() {
+		e.AssertSent(s.t, s.Requests())
+	})
+	return s
+}
+
+// Requests returns the operations received by s, in the order they were received.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Documents returns the documents of the operations received by s, in the
+// order they were received.
+func (s *Server) Documents() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	documents := make([]string, len(s.requests))
+	for i, req := range s.requests {
+		documents[i] = req.Query
+	}
+	return documents
+}
+
+// payload is an operation as sent in the body of a request.
+type payload struct {
+	Query         string                 `json:"query,omitempty"`
+	ID            string                 `json:"id,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	payloads, batched, err := readPayloads(r)
+	if err != nil {
+		s.t.Errorf("graphqltest: %s %s: %v", r.Method, r.URL, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	responses := make([]Response, len(payloads))
+	for i, p := range payloads {
+		req := Request{
+			Query:         p.Query,
+			ID:            p.ID,
+			OperationName: p.OperationName,
+			Variables:     p.Variables,
+			Header:        r.Header.Clone(),
+		}
+		if req.OperationName == "" {
+			req.OperationName = declaredName(req.Query)
+		}
+		responses[i] = s.respond(req)
+	}
+
+	var body interface{}
+	status := http.StatusOK
+	if batched {
+		// The status code and headers of batched responses are ignored.
+		results := make([]wireResponse, len(responses))
+		for i, resp := range responses {
+			results[i] = newWireResponse(resp)
+		}
+		body = results
+	} else {
+		resp := responses[0]
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		if resp.StatusCode != 0 {
+			status = resp.StatusCode
+		}
+		body = newWireResponse(resp)
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.t.Errorf("graphqltest: %v", err)
+	}
+}
+
+// respond records req, and returns the response of the first handler matching it.
+func (s *Server) respond(req Request) Response {
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	var respond func(Request) Response
+	for _, h := range s.handlers {
+		if h.matches(req) {
+			respond = h.respond
+			break
+		}
+	}
+	s.mu.Unlock()
+	if respond == nil {
+		msg := fmt.Sprintf("graphqltest: no response for operation %q with variables %v", req.OperationName, req.Variables)
+		s.t.Error(msg)
+		return Response{Errors: []graphql.Error{{Message: msg}}}
+	}
+	return respond(req)
+}
+
+func (h handler) matches(req Request) bool {
+	if h.operationName != "" && h.operationName != req.OperationName {
+		return false
+	}
+	for k, v := range h.variables {
+		got, ok := req.Variables[k]
+		if !ok || !reflect.DeepEqual(got, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// readPayloads reads the operations sent by r, and whether they were batched.
+func readPayloads(r *http.Request) ([]payload, bool, error) {
+	if r.Method == http.MethodGet {
+		params := r.URL.Query()
+		p := payload{
+			Query:         params.Get("query"),
+			ID:            params.Get("id"),
+			OperationName: params.Get("operationName"),
+		}
+		if v := params.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &p.Variables); err != nil {
+				return nil, false, fmt.Errorf("variables: %v", err)
+			}
+		}
+		return []payload{p}, false, nil
+	}
+
+	body := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, false, err
+		}
+		defer zr.Close()
+		body = zr
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/graphql":
+		document, err := io.ReadAll(body)
+		if err != nil {
+			return nil, false, err
+		}
+		return []payload{{Query: string(document)}}, false, nil
+	case "multipart/form-data":
+		r.Body = body
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, false, err
+		}
+		var p payload
+		if err := json.Unmarshal([]byte(r.FormValue("operations")), &p); err != nil {
+			return nil, false, fmt.Errorf("operations: %v", err)
+		}
+		return []payload{p}, false, nil
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, false, err
+	}
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		var payloads []payload
+		if err := json.Unmarshal(data, &payloads); err != nil {
+			return nil, false, err
+		}
+		if len(payloads) == 0 {
+			return nil, false, errors.New("empty batch")
+		}
+		return payloads, true, nil
+	}
+	var p payload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, false, err
+	}
+	return []payload{p}, false, nil
+}
+
+var operationNameRE = regexp.MustCompile(`^\s*(?:query|mutation|subscription)\s+([_A-Za-z][_0-9A-Za-z]*)`)
+
+// declaredName returns the name of the operation of document, if any.
+func declaredName(document string) string {
+	if m := operationNameRE.FindStringSubmatch(document); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// wireResponse is the JSON body of a Response.
+type wireResponse struct {
+	Data       json.RawMessage        `json:"data"`
+	Errors     []wireError            `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type wireError struct {
+	Message    string                 `json:"message"`
+	Locations  []wireLocation         `json:"locations,omitempty"`
+	Path       graphql.Path           `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+type wireLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func newWireResponse(resp Response) wireResponse {
+	w := wireResponse{Data: json.RawMessage("null"), Extensions: resp.Extensions}
+	if resp.Data != "" {
+		w.Data = json.RawMessage(resp.Data)
+	}
+	for _, e := range resp.Errors {
+		we := wireError{Message: e.Message, Path: e.Path, Extensions: e.Extensions}
+		for _, l := range e.Locations {
+			we.Locations = append(we.Locations, wireLocation{Line: l.Line, Column: l.Column})
+		}
+		w.Errors = append(w.Errors, we)
+	}
+	return w
+}
+
+// roundTrip decodes the JSON encoding of v into out.
+func roundTrip(v interface{}, out interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
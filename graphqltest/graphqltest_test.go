@@ -0,0 +1,136 @@
+package graphqltest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/graphqltest"
+)
+
+type userQuery struct {
+	User struct{ Name graphql.String } `graphql:"user(id: $id)"`
+}
+
+func TestServer(t *testing.T) {
+	server := graphqltest.NewServer(t).
+		Handle(graphqltest.Match{OperationName: "GetUser", Variables: map[string]interface{}{"id": graphql.ID("2")}}, graphqltest.Response{Data: `{"user": {"name": "gordon"}}`}).
+		Respond("GetUser", `{"user": {"name": "gopher"}}`)
+	client := server.Client()
+
+	for _, tt := range []struct{ id, want string }{{"1", "gopher"}, {"2", "gordon"}} {
+		var q userQuery
+		if err := client.NamedQuery(context.Background(), "GetUser", &q, map[string]interface{}{"id": graphql.ID(tt.id)}); err != nil {
+			t.Fatal(err)
+		}
+		if got := string(q.User.Name); got != tt.want {
+			t.Errorf("got name of user %s: %q, want: %q", tt.id, got, tt.want)
+		}
+	}
+
+	requests := server.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want: 2", len(requests))
+	}
+	if got, want := requests[0].OperationName, "GetUser"; got != want {
+		t.Errorf("got operation name: %q, want: %q", got, want)
+	}
+	if got, want := requests[1].Variables["id"], "2"; got != want {
+		t.Errorf("got id: %v, want: %v", got, want)
+	}
+	if got, want := server.Documents()[0], "query GetUser($id:ID!){user(id: $id){name}}"; got != want {
+		t.Errorf("got document: %q, want: %q", got, want)
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	server := graphqltest.NewServer(t).Handle(graphqltest.Match{}, graphqltest.Response{
+		Errors:     []graphql.Error{{Message: "user not found", Path: graphql.Path{"user"}, Extensions: map[string]interface{}{"code": "NOT_FOUND"}}},
+		StatusCode: http.StatusOK,
+	})
+
+	var q userQuery
+	err := server.Client().Query(context.Background(), &q, map[string]interface{}{"id": graphql.ID("1")})
+	var errs graphql.Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("got error: %v, want a single GraphQL error", err)
+	}
+	if got, want := errs[0].Code(), "NOT_FOUND"; got != want {
+		t.Errorf("got code: %q, want: %q", got, want)
+	}
+	if got, want := errs[0].Path.String(), "user"; got != want {
+		t.Errorf("got path: %q, want: %q", got, want)
+	}
+}
+
+func TestServer_transports(t *testing.T) {
+	server := graphqltest.NewServer(t).HandleFunc(graphqltest.Match{}, func(req graphqltest.Request) graphqltest.Response {
+		return graphqltest.Response{Data: fmt.Sprintf(`{"user": {"name": %q}}`, req.Variables["id"])}
+	})
+	clients := []struct {
+		name   string
+		client *graphql.Client
+	}{
+		{"GET", server.Client().WithGETQueries()},
+		{"gzip", server.Client().WithGzipRequests()},
+	}
+	for _, tt := range clients {
+		t.Run(tt.name, func(t *testing.T) {
+			var q userQuery
+			if err := tt.client.Query(context.Background(), &q, map[string]interface{}{"id": graphql.ID(tt.name)}); err != nil {
+				t.Fatal(err)
+			}
+			if got := string(q.User.Name); got != tt.name {
+				t.Errorf("got name: %q, want: %q", got, tt.name)
+			}
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		batch := server.Client().NewBatch()
+		var q1, q2 userQuery
+		op1 := batch.Query(&q1, map[string]interface{}{"id": graphql.ID("1")})
+		op2 := batch.Query(&q2, map[string]interface{}{"id": graphql.ID("2")})
+		if err := batch.Exec(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		if op1.Err() != nil || op2.Err() != nil {
+			t.Fatalf("got errors: %v, %v", op1.Err(), op2.Err())
+		}
+		if q1.User.Name != "1" || q2.User.Name != "2" {
+			t.Errorf("got names: %q, %q, want: 1, 2", q1.User.Name, q2.User.Name)
+		}
+	})
+}
+
+// recordingTB records the errors reported by a Server.
+type recordingTB struct {
+	testing.TB
+	mu     sync.Mutex
+	errors []string
+}
+
+func (tb *recordingTB) Error(args ...interface{}) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.errors = append(tb.errors, fmt.Sprint(args...))
+}
+
+func TestServer_unmatched(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	server := graphqltest.NewServer(tb).Respond("GetUser", `{"user": {"name": "gopher"}}`)
+
+	var q userQuery
+	err := server.Client().NamedQuery(context.Background(), "GetViewer", &q, map[string]interface{}{"id": graphql.ID("1")})
+	if err == nil || !strings.Contains(err.Error(), `no response for operation "GetViewer"`) {
+		t.Errorf("got error: %v, want no response", err)
+	}
+	if len(tb.errors) != 1 {
+		t.Errorf("got test errors: %q, want one", tb.errors)
+	}
+}
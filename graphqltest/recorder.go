@@ -0,0 +1,247 @@
+package graphqltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// RecorderSettings configure a Recorder.
+type RecorderSettings struct {
+	// Transport sends the requests while recording, or http.DefaultTransport if nil.
+	Transport http.RoundTripper
+
+	// Record makes the recorder record, replacing the fixture, even if it exists.
+	// E.g. set it from an environment variable to re-record the fixtures of a test suite.
+	Record bool
+}
+
+// Recorder is an http.RoundTripper recording the operations sent through it,
+// along with their responses, to a fixture file, and replaying them from it
+// in later runs, so that tests against real APIs are deterministic:
+//
+//	rec := graphqltest.NewRecorder(t, "testdata/github.json", graphqltest.RecorderSettings{})
+//	client := rec.Client("https://api.github.com/graphql")
+//
+// It records if the fixture doesn't exist and saves it when the test completes,
+// unless it failed. Otherwise it answers every operation with the response of
+// the first recorded operation not replayed yet with the same document, name and
+// variables, and fails the test on operations without any.
+//
+// The headers of requests aren't recorded, so that credentials stay out of fixtures.
+type Recorder struct {
+	t         testing.TB
+	path      string
+	transport http.RoundTripper
+	recording bool
+
+	mu           sync.Mutex
+	interactions []interaction
+	replayed     []bool
+}
+
+// interaction is a request recorded by a Recorder, with its response.
+type interaction struct {
+	Operations []payload `json:"operations"`
+	Response   recording `json:"response"`
+}
+
+// recording is a recorded HTTP response. Its body is kept as JSON if it's
+// valid JSON, to keep fixtures readable, and as a string otherwise.
+type recording struct {
+	StatusCode int             `json:"status"`
+	Header     http.Header     `json:"header,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+	Text       string          `json:"text,omitempty"`
+}
+
+// fixture is the content of the fixture file of a Recorder.
+type fixture struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// NewRecorder returns a Recorder with the fixture file path, recording or
+// replaying as settings configure it. It fails t if the fixture can't be read.
+func NewRecorder(t testing.TB, path string, settings RecorderSettings) *Recorder {
+	r := &Recorder{t: t, path: path, transport: settings.Transport}
+	if r.transport == nil {
+		r.transport = http.DefaultTransport
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case settings.Record || errors.Is(err, os.ErrNotExist):
+		r.recording = true
+		t.Cleanup(r.save)
+	case err != nil:
+		t.Fatalf("graphqltest: %v", err)
+	default:
+		var f fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatalf("graphqltest: fixture %s: %v", path, err)
+		}
+		r.interactions = f.Interactions
+		r.replayed = make([]bool, len(f.Interactions))
+	}
+	return r
+}
+
+// Client returns a graphql.Client sending its operations to url through r.
+func (r *Recorder) Client(url string, options ...graphql.Option) *graphql.Client {
+	return graphql.NewClient(url, &http.Client{Transport: r}, options...)
+}
+
+// Recording reports whether r records, rather than replays, operations.
+func (r *Recorder) Recording() bool {
+	return r.recording
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	parsed := req.Clone(req.Context())
+	parsed.Body = io.NopCloser(bytes.NewReader(body))
+	operations, _, err := readPayloads(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("graphqltest: %v", err)
+	}
+	if r.recording {
+		return r.record(req, body, operations)
+	}
+	return r.replay(req, operations)
+}
+
+// record sends req, whose body was read as body, and records its response.
+func (r *Recorder) record(req *http.Request, body []byte, operations []payload) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	// Let the transport negotiate the compression of the response, and decompress it.
+	req.Header.Del("Accept-Encoding")
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+	rec := recording{StatusCode: resp.StatusCode, Header: header}
+	if json.Valid(respBody) {
+		rec.Body = json.RawMessage(respBody)
+	} else {
+		rec.Text = string(respBody)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, interaction{Operations: operations, Response: rec})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	return resp, nil
+}
+
+// replay returns the recorded response to operations, sent by req.
+func (r *Recorder) replay(req *http.Request, operations []payload) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, in := range r.interactions {
+		if r.replayed[i] || !sameOperations(in.Operations, operations) {
+			continue
+		}
+		r.replayed[i] = true
+		body := []byte(in.Response.Body)
+		if in.Response.Body == nil {
+			body = []byte(in.Response.Text)
+		}
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+	names := make([]string, len(operations))
+	for i, op := range operations {
+		names[i] = op.OperationName
+		if names[i] == "" {
+			names[i] = declaredName(op.Query)
+		}
+	}
+	err := fmt.Errorf("graphqltest: no recorded response for operations %q in %s; delete it to record them again", names, r.path)
+	r.t.Error(err)
+	return nil, err
+}
+
+// save writes the recorded interactions to the fixture file, unless the test failed.
+func (r *Recorder) save() {
+	if r.t.Failed() {
+		r.t.Logf("graphqltest: not saving fixture %s of a failed test", r.path)
+		return
+	}
+	r.mu.Lock()
+	f := fixture{Interactions: r.interactions}
+	r.mu.Unlock()
+	if f.Interactions == nil {
+		f.Interactions = []interaction{}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		r.t.Errorf("graphqltest: %v", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		r.t.Errorf("graphqltest: %v", err)
+		return
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		r.t.Errorf("graphqltest: %v", err)
+	}
+}
+
+// sameOperations reports whether the operations a and b have the same
+// documents, persisted IDs, names and variables.
+func sameOperations(a, b []payload) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Query != b[i].Query || a[i].ID != b[i].ID || a[i].OperationName != b[i].OperationName {
+			return false
+		}
+		// Variables are compared as JSON, as they were recorded.
+		if len(a[i].Variables) != 0 || len(b[i].Variables) != 0 {
+			if !reflect.DeepEqual(a[i].Variables, b[i].Variables) {
+				return false
+			}
+		}
+	}
+	return true
+}
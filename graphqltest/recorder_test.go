@@ -0,0 +1,81 @@
+package graphqltest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/graphqltest"
+)
+
+// offlineTransport fails every request, as the network is unavailable when replaying.
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("offline")
+}
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "users.json")
+	server := graphqltest.NewServer(t).
+		Handle(graphqltest.Match{Variables: map[string]interface{}{"id": "1"}}, graphqltest.Response{Data: `{"user": {"name": "gopher"}}`}).
+		Handle(graphqltest.Match{Variables: map[string]interface{}{"id": "2"}}, graphqltest.Response{Data: `{"user": {"name": "gordon"}}`})
+
+	query := func(t *testing.T, client *graphql.Client, id string) (string, error) {
+		var q userQuery
+		err := client.Query(context.Background(), &q, map[string]interface{}{"id": graphql.ID(id)})
+		return string(q.User.Name), err
+	}
+
+	t.Run("record", func(t *testing.T) {
+		rec := graphqltest.NewRecorder(t, path, graphqltest.RecorderSettings{})
+		if !rec.Recording() {
+			t.Fatal("got replaying, want recording")
+		}
+		client := rec.Client(server.URL).WithGzipRequests()
+		for _, id := range []string{"1", "2", "1"} {
+			if _, err := query(t, client, id); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("fixture wasn't saved: %v", err)
+	}
+
+	t.Run("replay", func(t *testing.T) {
+		rec := graphqltest.NewRecorder(t, path, graphqltest.RecorderSettings{Transport: offlineTransport{}})
+		if rec.Recording() {
+			t.Fatal("got recording, want replaying")
+		}
+		client := rec.Client(server.URL)
+		for _, tt := range []struct{ id, want string }{{"2", "gordon"}, {"1", "gopher"}, {"1", "gopher"}} {
+			got, err := query(t, client, tt.id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got name of user %s: %q, want: %q", tt.id, got, tt.want)
+			}
+		}
+	})
+	if got, want := len(server.Requests()), 3; got != want {
+		t.Errorf("got %d requests to the server, want: %d", got, want)
+	}
+
+	t.Run("unrecorded", func(t *testing.T) {
+		tb := &recordingTB{TB: t}
+		client := graphqltest.NewRecorder(tb, path, graphqltest.RecorderSettings{Transport: offlineTransport{}}).Client(server.URL)
+		if _, err := query(t, client, "3"); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+			t.Errorf("got error: %v, want no recorded response", err)
+		}
+		if len(tb.errors) != 1 {
+			t.Errorf("got test errors: %q, want one", tb.errors)
+		}
+	})
+}
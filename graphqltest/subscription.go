@@ -0,0 +1,312 @@
+package graphqltest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// SubscriptionServer is a websocket server speaking the graphql-ws protocol of
+// graphql.SubscriptionClient, into whose subscriptions tests push events:
+//
+//	server := graphqltest.NewSubscriptionServer(t)
+//	sc := server.Client()
+//	go sc.Run()
+//	// ... subscribe with sc ...
+//	sub, err := server.WaitSubscription(ctx)
+//	// ...
+//	err = sub.Push(`{"messageAdded": {"text": "hello"}}`)
+//
+// Faults are injected with Disconnect, which drops the connections without
+// closing them, and Subscription.PushFrame, which sends arbitrary frames.
+type SubscriptionServer struct {
+	*httptest.Server
+
+	t      testing.TB
+	mu     sync.Mutex
+	conns  map[*serverConn]bool
+	subs   []*Subscription
+	waited int
+	added  chan struct{} // Closed and replaced when a subscription is added.
+	params []map[string]interface{}
+}
+
+// Subscription is an operation started by a client of a SubscriptionServer.
+type Subscription struct {
+	// ID is the ID of the operation, chosen by the client.
+	ID string
+
+	// Query is the document of the operation.
+	Query string
+
+	// OperationName is the name of the operation, as declared by its document.
+	OperationName string
+
+	// Variables are the variables of the operation, as decoded from JSON.
+	Variables map[string]interface{}
+
+	conn    *serverConn
+	once    sync.Once
+	stopped chan struct{}
+}
+
+// serverConn is a websocket connection accepted by a SubscriptionServer.
+type serverConn struct {
+	ws  *websocket.Conn
+	raw net.Conn
+	mu  sync.Mutex // Serializes writes.
+}
+
+// NewSubscriptionServer starts a SubscriptionServer, which is closed when t and its subtests complete.
+func NewSubscriptionServer(t testing.TB) *SubscriptionServer {
+	s := &SubscriptionServer{t: t, conns: make(map[*serverConn]bool), added: make(chan struct{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveWebSocket))
+	t.Cleanup(func() {
+		s.Disconnect()
+		s.Close()
+	})
+	return s
+}
+
+// WebSocketURL returns the ws:// URL of s.
+func (s *SubscriptionServer) WebSocketURL() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+// Client returns a graphql.SubscriptionClient connecting to s, which must be run.
+func (s *SubscriptionServer) Client() *graphql.SubscriptionClient {
+	return graphql.NewSubscriptionClient(s.WebSocketURL())
+}
+
+// WaitSubscription returns the next subscription started by a client, in the
+// order they were started, waiting for it if needed, or the error of ctx if
+// it's done first. Subscriptions restarted after a reconnection are new ones.
+func (s *SubscriptionServer) WaitSubscription(ctx context.Context) (*Subscription, error) {
+	for {
+		s.mu.Lock()
+		if s.waited < len(s.subs) {
+			sub := s.subs[s.waited]
+			s.waited++
+			s.mu.Unlock()
+			return sub, nil
+		}
+		added := s.added
+		s.mu.Unlock()
+		select {
+		case <-added:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Subscriptions returns the subscriptions started by clients of s, in the order they were started.
+func (s *SubscriptionServer) Subscriptions() []*Subscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Subscription(nil), s.subs...)
+}
+
+// Publish pushes data to the running subscriptions named operationName, or
+// to all of them if it's empty, as Subscription.Push does. It returns the
+// number of subscriptions it was pushed to.
+func (s *SubscriptionServer) Publish(operationName, data string) (int, error) {
+	n := 0
+	for _, sub := range s.Subscriptions() {
+		if sub.Stopped() || (operationName != "" && sub.OperationName != operationName) {
+			continue
+		}
+		if err := sub.Push(data); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// ConnectionParams returns the payloads of the connection_init messages
+// received by s, such as the parameters of graphql.SubscriptionClient.WithConnectionParams.
+func (s *SubscriptionServer) ConnectionParams() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]interface{}(nil), s.params...)
+}
+
+// Disconnect drops the connections of s abruptly, without a close frame,
+// as a mid-stream network failure does. Clients may connect again.
+func (s *SubscriptionServer) Disconnect() {
+	s.mu.Lock()
+	conns := make([]*serverConn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.mu.Unlock()
+	for _, conn := range conns {
+		_ = conn.raw.Close()
+	}
+}
+
+func (s *SubscriptionServer) serveWebSocket(w http.ResponseWriter, req *http.Request) {
+	hw := &hijackRecorder{ResponseWriter: w}
+	ws, err := websocket.Accept(hw, req, &websocket.AcceptOptions{Subprotocols: []string{"graphql-ws"}})
+	if err != nil {
+		s.t.Errorf("graphqltest: %v", err)
+		return
+	}
+	conn := &serverConn{ws: ws, raw: hw.conn}
+	s.mu.Lock()
+	s.conns[conn] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		for _, sub := range s.subs {
+			if sub.conn == conn {
+				sub.stop()
+			}
+		}
+		s.mu.Unlock()
+		_ = ws.Close(websocket.StatusNormalClosure, "")
+	}()
+
+	// The request context isn't canceled once the connection is hijacked.
+	ctx := context.Background()
+	for {
+		var msg graphql.OperationMessage
+		if err := wsjson.Read(ctx, ws, &msg); err != nil {
+			return
+		}
+		switch msg.Type {
+		case graphql.GQL_CONNECTION_INIT:
+			var params map[string]interface{}
+			_ = json.Unmarshal(msg.Payload, &params)
+			s.mu.Lock()
+			s.params = append(s.params, params)
+			s.mu.Unlock()
+			if err := conn.write(graphql.OperationMessage{Type: graphql.GQL_CONNECTION_ACK}); err != nil {
+				return
+			}
+		case graphql.GQL_START:
+			var p payload
+			if err := json.Unmarshal(msg.Payload, &p); err != nil {
+				s.t.Errorf("graphqltest: start message %s: %v", msg.ID, err)
+				continue
+			}
+			sub := &Subscription{
+				ID:            msg.ID,
+				Query:         p.Query,
+				OperationName: p.OperationName,
+				Variables:     p.Variables,
+				conn:          conn,
+				stopped:       make(chan struct{}),
+			}
+			if sub.OperationName == "" {
+				sub.OperationName = declaredName(p.Query)
+			}
+			s.mu.Lock()
+			s.subs = append(s.subs, sub)
+			close(s.added)
+			s.added = make(chan struct{})
+			s.mu.Unlock()
+		case graphql.GQL_STOP:
+			s.mu.Lock()
+			for _, sub := range s.subs {
+				if sub.conn == conn && sub.ID == msg.ID {
+					sub.stop()
+				}
+			}
+			s.mu.Unlock()
+		case graphql.GQL_CONNECTION_TERMINATE:
+			return
+		}
+	}
+}
+
+// Push sends data, the JSON value of the "data" field of an execution result, to the client.
+func (sub *Subscription) Push(data string) error {
+	return sub.send(graphql.OperationMessage{ID: sub.ID, Type: graphql.GQL_DATA, Payload: json.RawMessage(`{"data":` + data + `}`)})
+}
+
+// PushErrors sends an execution result with errs, and no data, to the client.
+func (sub *Subscription) PushErrors(errs ...graphql.Error) error {
+	payload, err := json.Marshal(newWireResponse(Response{Errors: errs}))
+	if err != nil {
+		return err
+	}
+	return sub.send(graphql.OperationMessage{ID: sub.ID, Type: graphql.GQL_DATA, Payload: payload})
+}
+
+// Complete tells the client that the subscription is done.
+func (sub *Subscription) Complete() error {
+	err := sub.send(graphql.OperationMessage{ID: sub.ID, Type: graphql.GQL_COMPLETE})
+	sub.stop()
+	return err
+}
+
+// PushFrame sends frame as it is in a text message, e.g. malformed JSON,
+// or a message of an unknown type.
+func (sub *Subscription) PushFrame(frame string) error {
+	if sub.Stopped() {
+		return fmt.Errorf("graphqltest: subscription %s is stopped", sub.ID)
+	}
+	sub.conn.mu.Lock()
+	defer sub.conn.mu.Unlock()
+	return sub.conn.ws.Write(context.Background(), websocket.MessageText, []byte(frame))
+}
+
+// Done returns a channel closed once the client stops the subscription,
+// it's completed, or its connection is closed.
+func (sub *Subscription) Done() <-chan struct{} {
+	return sub.stopped
+}
+
+// Stopped reports whether the subscription is stopped, as Done is closed.
+func (sub *Subscription) Stopped() bool {
+	select {
+	case <-sub.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+func (sub *Subscription) send(msg graphql.OperationMessage) error {
+	if sub.Stopped() {
+		return fmt.Errorf("graphqltest: subscription %s is stopped", sub.ID)
+	}
+	return sub.conn.write(msg)
+}
+
+func (sub *Subscription) stop() {
+	sub.once.Do(func() { close(sub.stopped) })
+}
+
+func (c *serverConn) write(msg graphql.OperationMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return wsjson.Write(context.Background(), c.ws, msg)
+}
+
+// hijackRecorder records the connection hijacked from the ResponseWriter it wraps,
+// so that it can be closed without the close handshake of websocket.Conn.
+type hijackRecorder struct {
+	http.ResponseWriter
+	conn net.Conn
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, brw, err := w.ResponseWriter.(http.Hijacker).Hijack()
+	w.conn = conn
+	return conn, brw, err
+}
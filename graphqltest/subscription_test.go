@@ -0,0 +1,123 @@
+package graphqltest_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/graphqltest"
+)
+
+type messageAddedSubscription struct {
+	MessageAdded struct{ Text graphql.String } `graphql:"messageAdded(room: $room)"`
+}
+
+func TestSubscriptionServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	server := graphqltest.NewSubscriptionServer(t)
+	errs := make(chan error, 10)
+	sc := server.Client().
+		WithConnectionParams(map[string]interface{}{"token": "secret"}).
+		OnError(func(sc *graphql.SubscriptionClient, err error) error {
+			errs <- err
+			return nil
+		})
+	defer sc.Close()
+
+	texts := make(chan string, 10)
+	id, err := sc.NamedSubscribe("OnMessage", &messageAddedSubscription{}, map[string]interface{}{"room": graphql.String("go")}, func(message *json.RawMessage, err error) error {
+		if err != nil {
+			texts <- "error: " + err.Error()
+			return nil
+		}
+		var s messageAddedSubscription
+		if err := json.Unmarshal(*message, &s); err != nil {
+			return err
+		}
+		texts <- string(s.MessageAdded.Text)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go sc.Run()
+
+	sub, err := server.WaitSubscription(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.ID != id || sub.OperationName != "OnMessage" || sub.Variables["room"] != "go" {
+		t.Errorf("got subscription %s %q with variables %v, want %s \"OnMessage\" in room go", sub.ID, sub.OperationName, sub.Variables, id)
+	}
+	if got := server.ConnectionParams(); len(got) != 1 || got[0]["token"] != "secret" {
+		t.Errorf("got connection params: %v, want a token", got)
+	}
+
+	receive := func(want string) {
+		t.Helper()
+		select {
+		case got := <-texts:
+			if got != want {
+				t.Errorf("got message: %q, want: %q", got, want)
+			}
+		case <-ctx.Done():
+			t.Fatalf("got no message, want: %q", want)
+		}
+	}
+	if err := sub.Push(`{"messageAdded": {"text": "hello"}}`); err != nil {
+		t.Fatal(err)
+	}
+	receive("hello")
+	if n, err := server.Publish("OnMessage", `{"messageAdded": {"text": "world"}}`); err != nil || n != 1 {
+		t.Fatalf("got %d subscriptions published to, error: %v, want 1", n, err)
+	}
+	receive("world")
+	if err := sub.PushErrors(graphql.Error{Message: "room closed"}); err != nil {
+		t.Fatal(err)
+	}
+	receive("error: room closed")
+
+	// A malformed frame is reported to OnError.
+	if err := sub.PushFrame(`{"type": "data", "id": `); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-errs:
+	case <-ctx.Done():
+		t.Fatal("got no error for a malformed frame")
+	}
+
+	// After a disconnection, the client subscribes again.
+	server.Disconnect()
+	select {
+	case <-sub.Done():
+	case <-ctx.Done():
+		t.Fatal("subscription wasn't stopped by the disconnection")
+	}
+	if err := sub.Push(`{"messageAdded": {"text": "lost"}}`); err == nil {
+		t.Error("got no error pushing to a disconnected subscription")
+	}
+	resub, err := server.WaitSubscription(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resub.ID != id {
+		t.Errorf("got resubscription %s, want: %s", resub.ID, id)
+	}
+	if err := resub.Push(`{"messageAdded": {"text": "again"}}`); err != nil {
+		t.Fatal(err)
+	}
+	receive("again")
+
+	if err := sc.Unsubscribe(id); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-resub.Done():
+	case <-ctx.Done():
+		t.Fatal("subscription wasn't stopped by unsubscribing")
+	}
+}
@@ -0,0 +1,173 @@
+package graphql
+
+import (
+	"sort"
+	"strings"
+)
+
+// HashDocument returns the hex-encoded SHA-256 hash of the canonical form of
+// document (see CanonicalDocument), which identifies the operations of documents
+// differing only in formatting, e.g. as metrics labels. It's the hash of the
+// documents in logs and cache keys, and Operation.DocumentHash.
+//
+// Persisted manifests and servers hashing the documents they receive identify
+// documents by the hash of their exact text instead, see DocumentID.
+func HashDocument(document string) string {
+	return DocumentID(CanonicalDocument(document))
+}
+
+// CanonicalDocument returns document without comments, commas and the whitespace
+// not separating names and numbers, and with the variable definitions of its operations
+// sorted by name, e.g. "query ($b: Int, $a: Int) { user { id, name } }" becomes
+// "query($a:Int$b:Int){user{id name}}". Strings are kept as they are.
+func CanonicalDocument(document string) string {
+	tokens := documentTokens(document)
+	sortVariableDefinitions(tokens)
+	var b strings.Builder
+	b.Grow(len(document))
+	for i, tok := range tokens {
+		if i > 0 && isWordToken(tokens[i-1]) && isWordToken(tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+// documentTokens returns the lexical tokens of document, without the ignored ones.
+func documentTokens(document string) []string {
+	var tokens []string
+	for i := 0; i < len(document); {
+		ch := document[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == ',':
+			i++
+		case ch == '#':
+			for i < len(document) && document[i] != '\n' && document[i] != '\r' {
+				i++
+			}
+		case ch == '"':
+			end := i + 1
+			if strings.HasPrefix(document[i:], `"""`) {
+				end = i + 3
+				for end < len(document) && !strings.HasPrefix(document[end:], `"""`) {
+					if strings.HasPrefix(document[end:], `\"""`) {
+						end += 3
+					}
+					end++
+				}
+				end += 3
+			} else {
+				for end < len(document) && document[end] != '"' && document[end] != '\n' {
+					if document[end] == '\\' {
+						end++
+					}
+					end++
+				}
+				end++
+			}
+			end = min(end, len(document))
+			tokens = append(tokens, document[i:end])
+			i = end
+		case strings.HasPrefix(document[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case isWordByte(ch):
+			end := i + 1
+			for end < len(document) && (isWordByte(document[end]) || document[end] == '.' ||
+				(document[end] == '+' || document[end] == '-') && (document[end-1] == 'e' || document[end-1] == 'E')) {
+				end++
+			}
+			tokens = append(tokens, document[i:end])
+			i = end
+		default:
+			// Punctuators, and the bytes of invalid characters.
+			tokens = append(tokens, document[i:i+1])
+			i++
+		}
+	}
+	return tokens
+}
+
+// isWordByte reports whether ch is part of a name or number.
+func isWordByte(ch byte) bool {
+	return ch == '_' || ch == '-' || '0' <= ch && ch <= '9' || 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch >= 0x80
+}
+
+// isWordToken reports whether tok is a name or number, which are separated from each other by a space.
+func isWordToken(tok string) bool {
+	return tok != "" && tok != "..." && isWordByte(tok[0])
+}
+
+// sortVariableDefinitions sorts the variable definitions of the operations of tokens by name.
+func sortVariableDefinitions(tokens []string) {
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "{":
+			depth++
+			continue
+		case "}":
+			depth--
+			continue
+		case "query", "mutation", "subscription":
+			if depth != 0 {
+				continue
+			}
+		default:
+			continue
+		}
+		// The variable definitions, if any, follow the keyword and the name.
+		start := i + 1
+		if start < len(tokens) && isWordToken(tokens[start]) {
+			start++
+		}
+		if start >= len(tokens) || tokens[start] != "(" {
+			continue
+		}
+		end := start + 1
+		for nested := 0; end < len(tokens) && (nested > 0 || tokens[end] != ")"); end++ {
+			switch tokens[end] {
+			case "(", "[", "{":
+				nested++
+			case ")", "]", "}":
+				nested--
+			}
+		}
+		sortDefinitions(tokens[start+1 : end])
+		i = end
+	}
+}
+
+// sortDefinitions sorts the variable definitions of tokens, each starting with "$", by name.
+func sortDefinitions(tokens []string) {
+	var defs [][]string
+	nested := 0
+	for i, tok := range tokens {
+		if tok == "$" && nested == 0 {
+			defs = append(defs, nil)
+		}
+		switch tok {
+		case "(", "[", "{":
+			nested++
+		case ")", "]", "}":
+			nested--
+		}
+		if len(defs) == 0 {
+			return // Not a variable definition.
+		}
+		defs[len(defs)-1] = append(defs[len(defs)-1], tokens[i])
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		return len(defs[i]) > 1 && len(defs[j]) > 1 && defs[i][1] < defs[j][1]
+	})
+	i := 0
+	for _, def := range defs {
+		i += copy(tokens[i:], def)
+	}
+}
+
+// DocumentHash returns the hash of the document of op, as HashDocument does.
+func (op *Operation) DocumentHash() string {
+	return HashDocument(op.Query)
+}
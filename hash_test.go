@@ -0,0 +1,54 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestCanonicalDocument(t *testing.T) {
+	tests := []struct {
+		document string
+		want     string
+	}{
+		{
+			"query ($b: Int, $a: Int) { user { id, name } }",
+			"query($a:Int$b:Int){user{id name}}",
+		},
+		{
+			"query($a:Int$b:Int){user{id name}}",
+			"query($a:Int$b:Int){user{id name}}",
+		},
+		{
+			"# Users.\nquery GetUsers($first: Int = 10, $after: String) {\n  users(first: $first, after: $after) {\n    ...on User { name }\n  }\n}\n",
+			"query GetUsers($after:String$first:Int=10){users(first:$first after:$after){...on User{name}}}",
+		},
+		{
+			"mutation ($input: [Input!]! @deprecated, $id: ID!) { update(id: $id, input: $input, note: \"a,  b\", text: \"\"\" x, \"\"\", n: -1.5e+3) }",
+			"mutation($id:ID!$input:[Input!]!@deprecated){update(id:$id input:$input note:\"a,  b\"text:\"\"\" x, \"\"\"n:-1.5e+3)}",
+		},
+		{
+			"{ user(login: \"gopher\") { name } }",
+			"{user(login:\"gopher\"){name}}",
+		},
+	}
+	for _, tt := range tests {
+		if got := graphql.CanonicalDocument(tt.document); got != tt.want {
+			t.Errorf("CanonicalDocument(%q):\ngot:  %q\nwant: %q", tt.document, got, tt.want)
+		}
+	}
+}
+
+func TestHashDocument(t *testing.T) {
+	a := graphql.HashDocument("query ($b: Int, $a: Int) { user(b: $b, a: $a) { id } }")
+	b := graphql.HashDocument("query($a:Int$b:Int){user(b:$b a:$a){id}}")
+	if a != b {
+		t.Errorf("got hashes %s and %s of the same operation", a, b)
+	}
+	if got, want := b, graphql.DocumentID("query($a:Int$b:Int){user(b:$b a:$a){id}}"); got != want {
+		t.Errorf("got hash of a canonical document: %s, want its ID: %s", got, want)
+	}
+	if c := graphql.HashDocument("query($a:Int$b:Int){user(a:$a b:$b){id}}"); c == a {
+		t.Error("got the same hash for different arguments")
+	}
+}
@@ -0,0 +1,45 @@
+package graphql
+
+import (
+	"github.com/google/uuid"
+)
+
+// DefaultIdempotencyKeyHeader is the header carrying idempotency keys, unless set
+// otherwise by Client.WithIdempotencyKeys.
+const DefaultIdempotencyKeyHeader = "Idempotency-Key"
+
+// WithIdempotencyKeys makes the client give each mutation an idempotency key, sent
+// in header, or DefaultIdempotencyKeyHeader if header is "". The key is the one set
+// by WithIdempotencyKey, or a random UUID generated once per call, and it's sent
+// with every attempt, e.g. the retries of a middleware and those to the endpoints
+// of WithFailover, so that servers supporting idempotency apply the mutation once.
+//
+// Mutations with distinct keys aren't batched together.
+func (c *Client) WithIdempotencyKeys(header string) *Client {
+	if header == "" {
+		header = DefaultIdempotencyKeyHeader
+	}
+	c.idempotencyKeyHeader = header
+	return c
+}
+
+// assignIdempotencyKey sets the idempotency key of op, if it's a mutation
+// and has a key set by WithIdempotencyKey, or keys are enabled by WithIdempotencyKeys.
+func (c *Client) assignIdempotencyKey(op *Operation) {
+	if op.Type != MutationOperation || op.IdempotencyKey != "" || op.options == nil {
+		return
+	}
+	key := op.options.idempotencyKey
+	if key == "" {
+		if c.idempotencyKeyHeader == "" {
+			return
+		}
+		key = uuid.New().String()
+	}
+	header := c.idempotencyKeyHeader
+	if header == "" {
+		header = DefaultIdempotencyKeyHeader
+	}
+	op.IdempotencyKey = key
+	op.Header.Set(header, key)
+}
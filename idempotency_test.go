@@ -0,0 +1,106 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithIdempotencyKeys(t *testing.T) {
+	var keys []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get(graphql.DefaultIdempotencyKeyHeader))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, req *http.Request) {
+		keys = append(keys, req.Header.Get(graphql.DefaultIdempotencyKeyHeader))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"updateUser": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/a", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithFailover(graphql.FailoverPriority, "/b").
+		WithIdempotencyKeys("")
+
+	var m struct {
+		UpdateUser struct {
+			Name string
+		} `graphql:"updateUser(name: \"Gopher\")"`
+	}
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("got keys: %q, want 2", keys)
+	}
+	if _, err := uuid.Parse(keys[0]); err != nil {
+		t.Errorf("got key: %q, want a UUID", keys[0])
+	}
+	if keys[1] != keys[0] {
+		t.Errorf("got key of the retry: %q, want: %q", keys[1], keys[0])
+	}
+
+	keys = nil
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("got keys: %q, want a single key", keys)
+	}
+
+	keys = nil
+	if err := client.Mutate(context.Background(), &m, nil, graphql.WithIdempotencyKey("order-42")); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "order-42" || keys[1] != "order-42" {
+		t.Errorf("got keys: %q, want: %q", keys, "order-42")
+	}
+
+	keys = nil
+	if err := client.Query(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0] != "" || keys[1] != "" {
+		t.Errorf("got keys of the query: %q, want none", keys)
+	}
+}
+
+func TestWithIdempotencyKey(t *testing.T) {
+	var header http.Header
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"updateUser": {"name": "Gopher"}}}`)
+	})
+	var m struct {
+		UpdateUser struct {
+			Name string
+		} `graphql:"updateUser(name: \"Gopher\")"`
+	}
+
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	if err := client.Mutate(context.Background(), &m, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get(graphql.DefaultIdempotencyKeyHeader); got != "" {
+		t.Errorf("got key: %q, want none", got)
+	}
+	if err := client.Mutate(context.Background(), &m, nil, graphql.WithIdempotencyKey("order-42")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := header.Get(graphql.DefaultIdempotencyKeyHeader), "order-42"; got != want {
+		t.Errorf("got key: %q, want: %q", got, want)
+	}
+
+	client = client.Clone().WithIdempotencyKeys("X-Idempotency-Key")
+	if err := client.Mutate(context.Background(), &m, nil, graphql.WithIdempotencyKey("order-43")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := header.Get("X-Idempotency-Key"), "order-43"; got != want {
+		t.Errorf("got key: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Querier executes GraphQL queries and mutations, as *Client does.
+// Code depending on a Querier rather than a *Client can be tested
+// with a fake implementation, without any HTTP server:
+//
+//	type fakeQuerier struct{ graphql.Querier }
+//
+//	func (fakeQuerier) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...graphql.Option) error {
+//		q.(*userQuery).User.Name = "gopher"
+//		return nil
+//	}
+//
+// Embedding the interface, as above, only requires the methods a test calls.
+type Querier interface {
+	Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) error
+	NamedQuery(ctx context.Context, name string, q interface{}, variables map[string]interface{}, options ...Option) error
+	Mutate(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) error
+	NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) error
+}
+
+// Subscriber subscribes to GraphQL subscriptions, as *SubscriptionClient does.
+// It can be faked in tests like a Querier.
+type Subscriber interface {
+	Subscribe(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error)
+	NamedSubscribe(name string, v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error)
+	Unsubscribe(id string) error
+}
+
+var (
+	_ Querier    = (*Client)(nil)
+	_ Subscriber = (*SubscriptionClient)(nil)
+)
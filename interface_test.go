@@ -0,0 +1,95 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type viewerQuery struct {
+	Viewer struct{ Login graphql.String }
+}
+
+// viewerLogin is code under test, depending on a graphql.Querier.
+func viewerLogin(ctx context.Context, client graphql.Querier) (string, error) {
+	var q viewerQuery
+	if err := client.Query(ctx, &q, nil); err != nil {
+		return "", err
+	}
+	return string(q.Viewer.Login), nil
+}
+
+// fakeQuerier answers the queries of viewerLogin, and panics on the other methods.
+type fakeQuerier struct {
+	graphql.Querier
+	login string
+	err   error
+}
+
+func (f fakeQuerier) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...graphql.Option) error {
+	q.(*viewerQuery).Viewer.Login = graphql.String(f.login)
+	return f.err
+}
+
+func TestQuerier(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"viewer": {"login": "gopher"}}}`)
+	})
+	clients := []struct {
+		name   string
+		client graphql.Querier
+	}{
+		{"Client", graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})},
+		{"fake", fakeQuerier{login: "gopher"}},
+	}
+	for _, tt := range clients {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := viewerLogin(context.Background(), tt.client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "gopher"; got != want {
+				t.Errorf("got login: %q, want: %q", got, want)
+			}
+		})
+	}
+
+	errFake := errors.New("fake error")
+	if _, err := viewerLogin(context.Background(), fakeQuerier{err: errFake}); err != errFake {
+		t.Errorf("got error: %v, want: %v", err, errFake)
+	}
+}
+
+// fakeSubscriber delivers a single message to every subscription.
+type fakeSubscriber struct {
+	graphql.Subscriber
+	message string
+}
+
+func (f fakeSubscriber) Subscribe(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
+	message := json.RawMessage(f.message)
+	return "1", handler(&message, nil)
+}
+
+func TestSubscriber(t *testing.T) {
+	var sub graphql.Subscriber = fakeSubscriber{message: `{"viewer": {"login": "gopher"}}`}
+	var got viewerQuery
+	_, err := sub.Subscribe(&viewerQuery{}, nil, func(message *json.RawMessage, err error) error {
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(*message, &got)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "gopher"; string(got.Viewer.Login) != want {
+		t.Errorf("got login: %q, want: %q", got.Viewer.Login, want)
+	}
+}
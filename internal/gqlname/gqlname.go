@@ -0,0 +1,25 @@
+// Package gqlname scans GraphQL names, for the packages parsing graphql tags.
+package gqlname
+
+// IsByte reports whether c may appear in a GraphQL name, first if it's
+// its first byte, which can't be a digit.
+// See https://spec.graphql.org/June2018/#Name.
+func IsByte(c byte, first bool) bool {
+	switch {
+	case c == '_', 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z':
+		return true
+	case '0' <= c && c <= '9':
+		return !first
+	default:
+		return false
+	}
+}
+
+// Len returns the length of the GraphQL name at the start of s, or 0 if there's none.
+func Len(s string) int {
+	i := 0
+	for i < len(s) && IsByte(s[i], i == 0) {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,24 @@
+package gqlname_test
+
+import (
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/internal/gqlname"
+)
+
+func TestLen(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want int
+	}{
+		{"user(id: 1)", 4},
+		{"_typename2 @skip", 10},
+		{"2fa", 0},
+		{"...", 0},
+		{"", 0},
+	} {
+		if got := gqlname.Len(tc.in); got != tc.want {
+			t.Errorf("Len(%q) = %d, want: %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"io"
 	"reflect"
 	"strings"
+
+	"github.com/runtimeracer/go-graphql-client/internal/typeinfo"
 )
 
 // UnmarshalGraphQL parses the JSON-encoded GraphQL response data and stores
@@ -18,9 +20,38 @@ import (
 // The implementation is created on top of the JSON tokenizer available
 // in "encoding/json".Decoder.
 func UnmarshalGraphQL(data []byte, v interface{}) error {
+	return UnmarshalGraphQLWithOptions(data, v, Options{})
+}
+
+// Options configures optional decoding behavior.
+type Options struct {
+	// CoerceNumericStrings enables decoding of JSON strings holding
+	// a numeric value (e.g., "9007199254740993") into integer and float
+	// fields. Some servers encode bigint and numeric scalars that way.
+	CoerceNumericStrings bool
+
+	// TagKey is the struct tag key holding GraphQL field selections.
+	// If empty, "graphql" is used.
+	TagKey string
+
+	// Elements maps the paths of JSON arrays decoded into slice fields,
+	// made of the keys of the array and its enclosing objects joined by dots,
+	// e.g. "export.rows", to the function each of their elements is handed
+	// to once decoded, instead of being kept in the slice.
+	Elements map[string]ElementFunc
+}
+
+// ElementFunc is called with each element of a JSON array, decoded into
+// the slice element elem, which is reused for the next element afterwards.
+// Decoding stops with the error it returns, if any.
+type ElementFunc func(elem reflect.Value) error
+
+// UnmarshalGraphQLWithOptions is like UnmarshalGraphQL,
+// but allows configuring decoding behavior via opts.
+func UnmarshalGraphQLWithOptions(data []byte, v interface{}, opts Options) error {
 	dec := json.NewDecoder(bytes.NewReader(data))
 	dec.UseNumber()
-	err := (&decoder{tokenizer: dec}).Decode(v)
+	err := (&decoder{tokenizer: dec, opts: opts}).Decode(v)
 	if err != nil {
 		return err
 	}
@@ -44,9 +75,20 @@ type decoder struct {
 		Token() (json.Token, error)
 	}
 
+	opts Options
+
 	// Stack of what part of input JSON we're in the middle of - objects, arrays.
 	parseState []json.Delim
 
+	// Stack of the keys of the objects we're in the middle of, if Options.Elements is set.
+	keys []string
+
+	// Stack of the arrays whose elements are handed to their Options.Elements function.
+	streams []stream
+
+	// Stack of the fixed-size arrays decoded into, per JSON array we're in the middle of.
+	arrays [][]arrayFill
+
 	// Stacks of values where to unmarshal.
 	// The top of each stack is the reflect.Value where to unmarshal next JSON value.
 	//
@@ -56,6 +98,19 @@ type decoder struct {
 	vs [][]reflect.Value
 }
 
+// stream is an array whose elements are handed to fn once decoded.
+type stream struct {
+	depth  int             // Length of the parse state inside the array.
+	slices []reflect.Value // Slices the elements are decoded into.
+	fn     ElementFunc
+}
+
+// arrayFill is a fixed-size array, and the number of elements decoded into it.
+type arrayFill struct {
+	v reflect.Value
+	n int
+}
+
 // Decode decodes a single JSON value from d.tokenizer into v.
 func (d *decoder) Decode(v interface{}) error {
 	rv := reflect.ValueOf(v)
@@ -86,6 +141,9 @@ func (d *decoder) decode() error {
 			if !ok {
 				return errors.New("unexpected non-key in JSON input")
 			}
+			if d.opts.Elements != nil {
+				d.keys[len(d.keys)-1] = key
+			}
 			someFieldExist := false
 			for i := range d.vs {
 				v := d.vs[i][len(d.vs[i])-1]
@@ -94,7 +152,7 @@ func (d *decoder) decode() error {
 				}
 				var f reflect.Value
 				if v.Kind() == reflect.Struct {
-					f = fieldByGraphQLName(v, key)
+					f = fieldByGraphQLName(v, key, d.opts.TagKey)
 					if f.IsValid() {
 						someFieldExist = true
 					}
@@ -123,15 +181,24 @@ func (d *decoder) decode() error {
 					v = v.Elem()
 				}
 				var f reflect.Value
-				if v.Kind() == reflect.Slice {
+				switch v.Kind() {
+				case reflect.Slice:
 					v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem()))) // v = append(v, T).
 					f = v.Index(v.Len() - 1)
 					someSliceExist = true
+				case reflect.Array:
+					a := d.arrayFill(v)
+					if a.n == v.Len() {
+						return fmt.Errorf("JSON array has more than %d elements to unmarshal into %v", v.Len(), v.Type())
+					}
+					f = v.Index(a.n)
+					a.n++
+					someSliceExist = true
 				}
 				d.vs[i] = append(d.vs[i], f)
 			}
 			if !someSliceExist {
-				return fmt.Errorf("slice doesn't exist in any of %v places to unmarshal", len(d.vs))
+				return fmt.Errorf("slice or array doesn't exist in any of %v places to unmarshal", len(d.vs))
 			}
 		}
 
@@ -144,12 +211,15 @@ func (d *decoder) decode() error {
 				if !v.IsValid() {
 					continue
 				}
-				err := unmarshalValue(tok, v)
+				err := d.unmarshalValue(tok, v)
 				if err != nil {
 					return err
 				}
 			}
 			d.popAllVs()
+			if err := d.endValue(); err != nil {
+				return err
+			}
 
 		case json.Delim:
 			switch tok {
@@ -157,6 +227,9 @@ func (d *decoder) decode() error {
 				// Start of object.
 
 				d.pushState(tok)
+				if d.opts.Elements != nil {
+					d.keys = append(d.keys, "")
+				}
 
 				frontier := make([]reflect.Value, len(d.vs)) // Places to look for GraphQL fragments/embedded structs.
 				for i := range d.vs {
@@ -178,8 +251,9 @@ func (d *decoder) decode() error {
 					if v.Kind() != reflect.Struct {
 						continue
 					}
-					for i := 0; i < v.NumField(); i++ {
-						if isGraphQLFragment(v.Type().Field(i)) || v.Type().Field(i).Anonymous {
+					fields := typeinfo.Of(v.Type(), d.opts.TagKey).Fields
+					for i := range fields {
+						if fields[i].Fragment || fields[i].Anonymous {
 							// Add GraphQL fragment or embedded struct.
 							d.vs = append(d.vs, []reflect.Value{v.Field(i)})
 							frontier = append(frontier, v.Field(i))
@@ -189,28 +263,60 @@ func (d *decoder) decode() error {
 			case '[':
 				// Start of array.
 
+				// Arrays directly nested in arrays have no path of their own.
+				var s stream
+				if d.opts.Elements != nil && d.state() == '{' {
+					s.fn = d.opts.Elements[strings.Join(d.keys, ".")]
+				}
 				d.pushState(tok)
 
+				var arrays []arrayFill
 				for i := range d.vs {
 					v := d.vs[i][len(d.vs[i])-1]
-					// TODO: Confirm this is needed, write a test case.
-					//if v.Kind() == reflect.Ptr && v.IsNil() {
-					//	v.Set(reflect.New(v.Type().Elem())) // v = new(T).
-					//}
+					// Allocate pointers to slices and arrays, which stay nil for null.
+					if v.Kind() == reflect.Ptr && v.IsNil() {
+						if k := v.Type().Elem().Kind(); k == reflect.Slice || k == reflect.Array {
+							v.Set(reflect.New(v.Type().Elem())) // v = new(T).
+						}
+					}
 
-					// Reset slice to empty (in case it had non-zero initial value).
+					// Reset slice to empty, or array to zero (in case it had non-zero initial value).
 					if v.Kind() == reflect.Ptr {
 						v = v.Elem()
 					}
+					if v.Kind() == reflect.Array {
+						v.Set(reflect.Zero(v.Type()))
+						arrays = append(arrays, arrayFill{v: v})
+						continue
+					}
 					if v.Kind() != reflect.Slice {
 						continue
 					}
 					v.Set(reflect.MakeSlice(v.Type(), 0, 0)) // v = make(T, 0, 0).
+					if s.fn != nil {
+						s.slices = append(s.slices, v)
+					}
+				}
+				d.arrays = append(d.arrays, arrays)
+				if s.fn != nil {
+					s.depth = len(d.parseState)
+					d.streams = append(d.streams, s)
 				}
 			case '}', ']':
 				// End of object or array.
+				if tok == ']' {
+					if err := d.endArray(); err != nil {
+						return err
+					}
+				}
 				d.popAllVs()
+				if tok == '}' && d.opts.Elements != nil {
+					d.keys = d.keys[:len(d.keys)-1]
+				}
 				d.popState()
+				if err := d.endValue(); err != nil {
+					return err
+				}
 			default:
 				return errors.New("unexpected delimiter in JSON input")
 			}
@@ -221,6 +327,63 @@ func (d *decoder) decode() error {
 	return nil
 }
 
+// endValue is called once a JSON value has been decoded. If it's an element
+// of a streamed array, it's handed to the function of the array, and removed
+// from the slices it was decoded into.
+func (d *decoder) endValue() error {
+	if len(d.streams) == 0 {
+		return nil
+	}
+	s := d.streams[len(d.streams)-1]
+	if len(d.parseState) < s.depth {
+		// The value is the streamed array itself,
+		// which may be an element of an enclosing one.
+		d.streams = d.streams[:len(d.streams)-1]
+		return d.endValue()
+	}
+	if len(d.parseState) > s.depth {
+		return nil
+	}
+	for _, v := range s.slices {
+		if v.Len() == 0 {
+			continue
+		}
+		elem := v.Index(v.Len() - 1)
+		if err := s.fn(elem); err != nil {
+			return err
+		}
+		// Release the element. The next one is decoded in the same place.
+		elem.Set(reflect.Zero(elem.Type()))
+		v.SetLen(0)
+	}
+	return nil
+}
+
+// arrayFill returns the fill of the fixed-size array v decoded into
+// at the JSON array we're in the middle of.
+func (d *decoder) arrayFill(v reflect.Value) *arrayFill {
+	arrays := d.arrays[len(d.arrays)-1]
+	for i := range arrays {
+		if arrays[i].v.UnsafeAddr() == v.UnsafeAddr() && arrays[i].v.Type() == v.Type() {
+			return &arrays[i]
+		}
+	}
+	panic("jsonutil: array decoded into isn't tracked")
+}
+
+// endArray is called at the end of a JSON array, and fails if it had fewer
+// elements than the fixed-size arrays decoded into have.
+func (d *decoder) endArray() error {
+	arrays := d.arrays[len(d.arrays)-1]
+	d.arrays = d.arrays[:len(d.arrays)-1]
+	for _, a := range arrays {
+		if a.n != a.v.Len() {
+			return fmt.Errorf("JSON array has too few elements to unmarshal into %v, got %d", a.v.Type(), a.n)
+		}
+	}
+	return nil
+}
+
 // pushState pushes a new parse state s onto the stack.
 func (d *decoder) pushState(s json.Delim) {
 	d.parseState = append(d.parseState, s)
@@ -254,58 +417,57 @@ func (d *decoder) popAllVs() {
 
 // fieldByGraphQLName returns an exported struct field of struct v
 // that matches GraphQL name, or invalid reflect.Value if none found.
-func fieldByGraphQLName(v reflect.Value, name string) reflect.Value {
-	for i := 0; i < v.NumField(); i++ {
-		if v.Type().Field(i).PkgPath != "" {
+// Field selections are read from struct tags with key tagKey.
+func fieldByGraphQLName(v reflect.Value, name string, tagKey string) reflect.Value {
+	fields := typeinfo.Of(v.Type(), tagKey).Fields
+	for i := range fields {
+		if !fields[i].Exported {
 			// Skip unexported field.
 			continue
 		}
-		if hasGraphQLName(v.Type().Field(i), name) {
+		if fields[i].HasGraphQLName(name) {
 			return v.Field(i)
 		}
 	}
 	return reflect.Value{}
 }
 
-// hasGraphQLName reports whether struct field f has GraphQL name.
-func hasGraphQLName(f reflect.StructField, name string) bool {
-	value, ok := f.Tag.Lookup("graphql")
-	if !ok {
-		// TODO: caseconv package is relatively slow. Optimize it, then consider using it here.
-		//return caseconv.MixedCapsToLowerCamelCase(f.Name) == name
-		return strings.EqualFold(f.Name, name)
-	}
-	value = strings.TrimSpace(value) // TODO: Parse better.
-	if strings.HasPrefix(value, "...") {
-		// GraphQL fragment. It doesn't have a name.
-		return false
-	}
-	if i := strings.Index(value, "("); i != -1 {
-		value = value[:i]
-	}
-	if i := strings.Index(value, ":"); i != -1 {
-		value = value[:i]
-	}
-	return strings.TrimSpace(value) == name
-}
-
-// isGraphQLFragment reports whether struct field f is a GraphQL fragment.
-func isGraphQLFragment(f reflect.StructField) bool {
-	value, ok := f.Tag.Lookup("graphql")
-	if !ok {
-		return false
-	}
-	value = strings.TrimSpace(value) // TODO: Parse better.
-	return strings.HasPrefix(value, "...")
-}
-
 // unmarshalValue unmarshals JSON value into v.
 // v must be addressable and not obtained by the use of unexported
 // struct fields, otherwise unmarshalValue will panic.
-func unmarshalValue(value json.Token, v reflect.Value) error {
+func (d *decoder) unmarshalValue(value json.Token, v reflect.Value) error {
+	if s, ok := value.(string); ok && d.opts.CoerceNumericStrings && isNumeric(v.Type()) {
+		// Decode the quoted number as if it were sent unquoted.
+		value = json.Number(strings.TrimSpace(s))
+	}
 	b, err := json.Marshal(value) // TODO: Short-circuit (if profiling says it's worth it).
 	if err != nil {
 		return err
 	}
 	return json.Unmarshal(b, v.Addr().Interface())
 }
+
+// isNumeric reports whether values of type t are decoded from JSON numbers,
+// looking through pointers. Types implementing json.Unmarshaler decode
+// themselves and are never considered numeric.
+func isNumeric(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		if t.Implements(jsonUnmarshaler) {
+			return false
+		}
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
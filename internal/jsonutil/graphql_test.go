@@ -1,6 +1,7 @@
 package jsonutil_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -201,6 +202,77 @@ func TestUnmarshalGraphQL_objectPointerArray(t *testing.T) {
 	}
 }
 
+func TestUnmarshalGraphQL_pointerToSlice(t *testing.T) {
+	type query struct {
+		Null  *[]graphql.String
+		Empty *[]graphql.String
+		Tags  *[]graphql.String
+		Users *[]struct {
+			Name graphql.String
+		}
+	}
+	var got query
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"null": null,
+		"empty": [],
+		"tags": ["a", "b"],
+		"users": [{"name": "bar"}]
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Empty: &[]graphql.String{},
+		Tags:  &[]graphql.String{"a", "b"},
+		Users: &[]struct{ Name graphql.String }{{"bar"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+}
+
+func TestUnmarshalGraphQL_fixedSizeArray(t *testing.T) {
+	type query struct {
+		Point  [2]graphql.Float
+		Matrix [2][2]graphql.Int
+		Pair   *[2]struct {
+			Name graphql.String
+		}
+	}
+	got := query{Point: [2]graphql.Float{9, 9}}
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"point": [1.5, 2.5],
+		"matrix": [[1, 2], [3, 4]],
+		"pair": [{"name": "bar"}, {"name": "baz"}]
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Point:  [2]graphql.Float{1.5, 2.5},
+		Matrix: [2][2]graphql.Int{{1, 2}, {3, 4}},
+		Pair:   &[2]struct{ Name graphql.String }{{"bar"}, {"baz"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %+v, want: %+v", got, want)
+	}
+
+	for _, tc := range []struct {
+		data string
+		want string
+	}{
+		{`{"point": [1, 2, 3]}`, "JSON array has more than 2 elements to unmarshal into [2]graphql.Float"},
+		{`{"point": [1]}`, "JSON array has too few elements to unmarshal into [2]graphql.Float, got 1"},
+		{`{"matrix": [[1, 2], [3]]}`, "JSON array has too few elements to unmarshal into [2]graphql.Int, got 1"},
+	} {
+		var got query
+		err := jsonutil.UnmarshalGraphQL([]byte(tc.data), &got)
+		if err == nil || err.Error() != tc.want {
+			t.Errorf("%s: got error: %v, want: %s", tc.data, err, tc.want)
+		}
+	}
+}
+
 func TestUnmarshalGraphQL_pointerWithInlineFragment(t *testing.T) {
 	type actor struct {
 		User struct {
@@ -393,3 +465,194 @@ func TestUnmarshalGraphQL_arrayInsideInlineFragment(t *testing.T) {
 		t.Error("not equal")
 	}
 }
+
+func TestUnmarshalGraphQL_numericString(t *testing.T) {
+	type query struct {
+		Count  graphql.Int
+		Big    int64
+		Amount *graphql.Float
+		Name   graphql.String
+	}
+	err := jsonutil.UnmarshalGraphQL([]byte(`{"count": "42"}`), new(query))
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+
+	var got query
+	err = jsonutil.UnmarshalGraphQLWithOptions([]byte(`{
+		"count": "42",
+		"big": "9007199254740993",
+		"amount": "12.5",
+		"name": "123"
+	}`), &got, jsonutil.Options{CoerceNumericStrings: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		Count:  42,
+		Big:    9007199254740993,
+		Amount: graphql.NewFloat(12.5),
+		Name:   "123",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("not equal:\ngot:  %v\nwant: %v", got, want)
+	}
+
+	err = jsonutil.UnmarshalGraphQLWithOptions([]byte(`{"count": "forty-two"}`), new(query), jsonutil.Options{CoerceNumericStrings: true})
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+}
+
+func TestUnmarshalGraphQL_alias(t *testing.T) {
+	/*
+		query {
+			a: user(login: "alice") { name }
+			b: user(login: "bob") { name }
+			title @include(if: true)
+		}
+	*/
+	type user struct {
+		Name graphql.String
+	}
+	type query struct {
+		A     user           `graphql:"a: user(login: \"alice\")"`
+		B     *user          `graphql:"b : user(login: \"bob\")"`
+		Title graphql.String `graphql:"title @include(if: true)"`
+	}
+	var got query
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"a": {"name": "Alice"},
+		"b": {"name": "Bob"},
+		"title": "Admins"
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := query{
+		A:     user{Name: "Alice"},
+		B:     &user{Name: "Bob"},
+		Title: "Admins",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("not equal:\ngot:  %+v\nwant: %+v", got, want)
+	}
+
+	err = jsonutil.UnmarshalGraphQL([]byte(`{"user": {"name": "Alice"}}`), new(query))
+	if err == nil {
+		t.Fatal("got error: nil, want: non-nil")
+	}
+}
+
+func TestUnmarshalGraphQL_typename(t *testing.T) {
+	type query struct {
+		Viewer struct {
+			Typename string
+			Login    graphql.String
+		}
+		Node struct {
+			Kind graphql.String `graphql:"__typename"`
+		}
+	}
+	var got query
+	err := jsonutil.UnmarshalGraphQL([]byte(`{
+		"viewer": {"__typename": "User", "login": "gopher"},
+		"node": {"__typename": "Organization"}
+	}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want query
+	want.Viewer.Typename = "User"
+	want.Viewer.Login = "gopher"
+	want.Node.Kind = "Organization"
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("not equal:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+func TestUnmarshalGraphQL_elements(t *testing.T) {
+	type row struct {
+		ID   graphql.Int
+		Tags []graphql.String
+	}
+	type query struct {
+		Export struct {
+			Rows  []row
+			Total graphql.Int
+		}
+		Teams []struct {
+			Members []graphql.String
+			Matrix  [][]graphql.Int
+		}
+	}
+	var rows []row
+	var members []graphql.String
+	var matrix [][]graphql.Int
+	var rowsKept int
+	var got query
+	err := jsonutil.UnmarshalGraphQLWithOptions([]byte(`{
+		"export": {
+			"rows": [
+				{"id": 1, "tags": ["a", "b"]},
+				{"id": 2, "tags": []},
+				{"id": 3, "tags": ["c"]}
+			],
+			"total": 3
+		},
+		"teams": [
+			{"members": ["alice", "bob"], "matrix": [[1, 2], [3]]},
+			{"members": ["carol"], "matrix": []}
+		]
+	}`), &got, jsonutil.Options{Elements: map[string]jsonutil.ElementFunc{
+		"export.rows": func(elem reflect.Value) error {
+			rows = append(rows, elem.Interface().(row))
+			rowsKept = len(got.Export.Rows)
+			return nil
+		},
+		"teams.members": func(elem reflect.Value) error {
+			members = append(members, elem.Interface().(graphql.String))
+			return nil
+		},
+		// Nested arrays are elements, without a path of their own.
+		"teams.matrix": func(elem reflect.Value) error {
+			matrix = append(matrix, elem.Interface().([]graphql.Int))
+			return nil
+		},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRows := []row{
+		{ID: 1, Tags: []graphql.String{"a", "b"}},
+		{ID: 2, Tags: []graphql.String{}},
+		{ID: 3, Tags: []graphql.String{"c"}},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("got rows: %+v, want: %+v", rows, wantRows)
+	}
+	if rowsKept != 1 || len(got.Export.Rows) != 0 || got.Export.Total != 3 {
+		t.Errorf("got %d rows kept while decoding, %d afterwards, and total %d, want: 1, 0 and 3", rowsKept, len(got.Export.Rows), got.Export.Total)
+	}
+	if want := []graphql.String{"alice", "bob", "carol"}; !reflect.DeepEqual(members, want) {
+		t.Errorf("got members: %q, want: %q", members, want)
+	}
+	if want := [][]graphql.Int{{1, 2}, {3}}; !reflect.DeepEqual(matrix, want) {
+		t.Errorf("got matrix: %v, want: %v", matrix, want)
+	}
+	if len(got.Teams) != 2 || len(got.Teams[0].Members) != 0 || len(got.Teams[0].Matrix) != 0 {
+		t.Errorf("got teams: %+v, want 2 teams without members or matrix", got.Teams)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = jsonutil.UnmarshalGraphQLWithOptions([]byte(`{"export": {"rows": [{"id": 1}, {"id": 2}], "total": 2}}`), new(query), jsonutil.Options{Elements: map[string]jsonutil.ElementFunc{
+		"export.rows": func(elem reflect.Value) error {
+			calls++
+			return errStop
+		},
+	}})
+	if err != errStop || calls != 1 {
+		t.Errorf("got error: %v after %d calls, want: %v after 1", err, calls, errStop)
+	}
+}
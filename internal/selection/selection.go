@@ -0,0 +1,220 @@
+// Package selection parses the field selections and fragments of the graphql
+// tags of query structs, for the static analysis of query structs.
+package selection
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/runtimeracer/go-graphql-client/internal/gqlname"
+)
+
+// Selection is the field selection or fragment of a graphql tag, e.g.
+// `owner: user(login: $login) @include(if: $withOwner)` or `... on User`.
+type Selection struct {
+	// Fragment reports whether the selection is a fragment, rather than a field.
+	Fragment bool
+	// TypeCondition is the type of a fragment, or "" if it has none.
+	TypeCondition string
+	// Alias and Name are those of a field.
+	Alias, Name string
+	// Variables holds the names of the variables referenced by the selection.
+	Variables []string
+}
+
+// ResponseKey returns the key of a field in the response.
+func (s *Selection) ResponseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// Parse parses the selection in the graphql tag tag.
+func Parse(tag string) (*Selection, error) {
+	p := &parser{s: tag, sel: &Selection{}}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.sel, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+	sel *Selection
+}
+
+func (p *parser) parse() error {
+	if p.skip("...") {
+		p.sel.Fragment = true
+		if p.peekName() == "on" {
+			p.name()
+			if p.sel.TypeCondition = p.name(); p.sel.TypeCondition == "" {
+				return p.errorf("expected type condition")
+			}
+		}
+	} else {
+		if p.sel.Name = p.name(); p.sel.Name == "" {
+			return p.errorf("expected field name")
+		}
+		if p.skip(":") {
+			p.sel.Alias = p.sel.Name
+			if p.sel.Name = p.name(); p.sel.Name == "" {
+				return p.errorf("expected field name after alias")
+			}
+		}
+		if err := p.arguments(); err != nil {
+			return err
+		}
+	}
+	for p.skip("@") {
+		if p.name() == "" {
+			return p.errorf("expected directive name")
+		}
+		if err := p.arguments(); err != nil {
+			return err
+		}
+	}
+	if p.skipIgnored(); p.pos < len(p.s) {
+		return p.errorf("unexpected %q", p.s[p.pos:])
+	}
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+// skipIgnored skips white space and commas.
+func (p *parser) skipIgnored() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n,", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// skip consumes the punctuator s if it's next, and reports whether it was.
+func (p *parser) skip(s string) bool {
+	p.skipIgnored()
+	if !strings.HasPrefix(p.s[p.pos:], s) {
+		return false
+	}
+	p.pos += len(s)
+	return true
+}
+
+// peekName returns the name at the current position, or "" if there's none.
+func (p *parser) peekName() string {
+	p.skipIgnored()
+	return p.s[p.pos : p.pos+gqlname.Len(p.s[p.pos:])]
+}
+
+// name consumes the name at the current position, returning "" if there's none.
+func (p *parser) name() string {
+	name := p.peekName()
+	p.pos += len(name)
+	return name
+}
+
+func (p *parser) arguments() error {
+	if !p.skip("(") {
+		return nil
+	}
+	if p.skip(")") {
+		return p.errorf("expected argument")
+	}
+	for !p.skip(")") {
+		if p.name() == "" {
+			return p.errorf("expected argument name or \")\"")
+		}
+		if !p.skip(":") {
+			return p.errorf("expected \":\" after argument name")
+		}
+		if err := p.value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) value() error {
+	p.skipIgnored()
+	if p.pos >= len(p.s) {
+		return p.errorf("expected value")
+	}
+	switch c := p.s[p.pos]; {
+	case c == '$':
+		p.pos++
+		name := p.name()
+		if name == "" {
+			return p.errorf("expected variable name")
+		}
+		p.sel.Variables = append(p.sel.Variables, name)
+	case c == '"':
+		return p.string()
+	case c == '-' || '0' <= c && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.s) && strings.IndexByte("0123456789.eE+-", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		if _, err := strconv.ParseFloat(p.s[start:p.pos], 64); err != nil {
+			p.pos = start
+			return p.errorf("invalid number")
+		}
+	case c == '[':
+		p.pos++
+		for !p.skip("]") {
+			if err := p.value(); err != nil {
+				return err
+			}
+		}
+	case c == '{':
+		p.pos++
+		for !p.skip("}") {
+			if p.name() == "" {
+				return p.errorf("expected field name or \"}\"")
+			}
+			if !p.skip(":") {
+				return p.errorf("expected \":\" after field name")
+			}
+			if err := p.value(); err != nil {
+				return err
+			}
+		}
+	default:
+		if p.name() == "" {
+			return p.errorf("expected value")
+		}
+	}
+	return nil
+}
+
+// string consumes a string literal.
+func (p *parser) string() error {
+	if p.skip(`"""`) {
+		end := strings.Index(p.s[p.pos:], `"""`)
+		if end < 0 {
+			return p.errorf("unterminated block string")
+		}
+		p.pos += end + 3
+		return nil
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '"':
+			p.pos++
+			return nil
+		case '\\':
+			p.pos++
+		case '\n':
+			p.pos = len(p.s)
+		}
+		p.pos++
+	}
+	p.pos = start
+	return p.errorf("unterminated string")
+}
@@ -0,0 +1,37 @@
+package selection_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/internal/selection"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want selection.Selection
+	}{
+		{"name", selection.Selection{Name: "name"}},
+		{"owner: user(login: $login, first: 10) @include(if: $withOwner)", selection.Selection{Alias: "owner", Name: "user", Variables: []string{"login", "withOwner"}}},
+		{`search(filter: {text: "a $b", tags: [$tag]})`, selection.Selection{Name: "search", Variables: []string{"tag"}}},
+		{"... on User", selection.Selection{Fragment: true, TypeCondition: "User"}},
+		{"... @skip(if: $skip)", selection.Selection{Fragment: true, Variables: []string{"skip"}}},
+	}
+	for _, tc := range tests {
+		got, err := selection.Parse(tc.tag)
+		if err != nil {
+			t.Errorf("%s: %v", tc.tag, err)
+			continue
+		}
+		if !reflect.DeepEqual(*got, tc.want) {
+			t.Errorf("%s: got %+v, want %+v", tc.tag, *got, tc.want)
+		}
+	}
+
+	for _, tag := range []string{"", "user(", "user(id:)", "a: ", "... on", "a b", `a(b: "c)`, "a(b: 1.2.3)"} {
+		if _, err := selection.Parse(tag); err == nil {
+			t.Errorf("%q: got no error", tag)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package typeinfo provides cached metadata about the struct types used as
+// GraphQL query data structures. It's shared by query construction and
+// response decoding, so struct tags are parsed once per type rather than
+// on every request.
+package typeinfo
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/runtimeracer/go-graphql-client/ident"
+	"github.com/runtimeracer/go-graphql-client/internal/gqlname"
+)
+
+// Struct holds metadata about a struct type.
+type Struct struct {
+	// Scalar reports whether the struct implements json.Unmarshaler,
+	// in which case it's a scalar and its fields aren't part of the query.
+	Scalar bool
+
+	// Fields holds metadata about each field of the struct, in declaration order.
+	Fields []Field
+}
+
+// Field holds metadata about a single struct field.
+type Field struct {
+	Index     int
+	Name      string       // Go name of the field.
+	Type      reflect.Type // Type of the field.
+	Exported  bool
+	Anonymous bool
+
+	Tag    string // Value of the struct tag holding the GraphQL field selection.
+	HasTag bool   // Whether the field has such a struct tag.
+
+	// Inline reports whether the field is an embedded struct without a graphql tag,
+	// whose fields are inlined into the parent selection set.
+	Inline bool
+
+	// Fragment reports whether the field is a GraphQL fragment (its tag starts with "...").
+	Fragment bool
+
+	// Selection is what's written into the query for this field:
+	// the graphql tag if present, or the lowerCamelCase field name.
+	Selection string
+
+	// responseName is the key of the field in the response, derived from the graphql tag,
+	// or "__typename" for an untagged TypenameField. It's empty for the other untagged
+	// fields, which match their Go name case-insensitively, and for fragments, which
+	// don't have a name.
+	responseName string
+}
+
+// HasGraphQLName reports whether the field is decoded from the response key name.
+func (f *Field) HasGraphQLName(name string) bool {
+	if f.Fragment {
+		// GraphQL fragment. It doesn't have a name.
+		return false
+	}
+	if f.responseName == "" {
+		// TODO: caseconv package is relatively slow. Optimize it, then consider using it here.
+		//return caseconv.MixedCapsToLowerCamelCase(f.Name) == name
+		return strings.EqualFold(f.Name, name)
+	}
+	return f.responseName == name
+}
+
+// TypenameField is the name of the well-known struct field that's populated
+// with the __typename meta field even without a graphql tag.
+const TypenameField = "Typename"
+
+// DefaultTagKey is the struct tag key used when none is specified.
+const DefaultTagKey = "graphql"
+
+// cacheKey identifies the metadata of a struct type read with a given tag key.
+type cacheKey struct {
+	t      reflect.Type
+	tagKey string
+}
+
+var cache sync.Map // map[cacheKey]*Struct
+
+// Of returns the metadata of struct type t, reading GraphQL field selection
+// from struct tags with key tagKey, or DefaultTagKey if tagKey is empty.
+// The result is cached and must not be modified.
+func Of(t reflect.Type, tagKey string) *Struct {
+	if tagKey == "" {
+		tagKey = DefaultTagKey
+	}
+	key := cacheKey{t: t, tagKey: tagKey}
+	if s, ok := cache.Load(key); ok {
+		return s.(*Struct)
+	}
+	s, _ := cache.LoadOrStore(key, newStruct(t, tagKey))
+	return s.(*Struct)
+}
+
+func newStruct(t reflect.Type, tagKey string) *Struct {
+	s := &Struct{
+		Scalar: reflect.PtrTo(t).Implements(jsonUnmarshaler),
+		Fields: make([]Field, t.NumField()),
+	}
+	for i := range s.Fields {
+		sf := t.Field(i)
+		value, ok := sf.Tag.Lookup(tagKey)
+		f := Field{
+			Index:     i,
+			Name:      sf.Name,
+			Type:      sf.Type,
+			Exported:  sf.PkgPath == "",
+			Anonymous: sf.Anonymous,
+			Tag:       value,
+			HasTag:    ok,
+			Inline:    sf.Anonymous && !ok,
+		}
+		if ok {
+			f.Selection = value
+			value = strings.TrimSpace(value) // TODO: Parse better.
+			f.Fragment = strings.HasPrefix(value, "...")
+			if !f.Fragment {
+				f.responseName = responseName(value)
+			}
+		} else if sf.Name == TypenameField && sf.Type.Kind() == reflect.String {
+			f.Selection = "__typename"
+			f.responseName = "__typename"
+		} else {
+			f.Selection = ident.ParseMixedCaps(sf.Name).ToLowerCamelCase()
+		}
+		s.Fields[i] = f
+	}
+	return s
+}
+
+// responseName returns the response key of the field selection in tag.
+// That's the alias if present (e.g., "a" for `a: user(id: 1)`) and the field
+// name otherwise (e.g., "name" for `name @include(if: $withName)`), which
+// in both cases is the first name in the selection.
+func responseName(tag string) string {
+	tag = strings.TrimLeft(tag, " \t\r\n,")
+	return tag[:gqlname.Len(tag)]
+}
+
+var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
@@ -0,0 +1,103 @@
+package typeinfo_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/internal/typeinfo"
+)
+
+func TestOf(t *testing.T) {
+	type embedded struct {
+		Name string
+	}
+	type query struct {
+		DatabaseID int
+		Node       struct{} `graphql:"node1: node(id: \"abc\")"`
+		Fragment   struct{} `graphql:"... on User"`
+		embedded
+		unexported int
+	}
+	typ := reflect.TypeOf(query{})
+	s := typeinfo.Of(typ, "")
+	if s != typeinfo.Of(typ, "") {
+		t.Error("got different metadata for the same type, want cached")
+	}
+	if s.Scalar {
+		t.Error("got scalar struct, want non-scalar")
+	}
+	if got, want := len(s.Fields), 5; got != want {
+		t.Fatalf("got %d fields, want %d", got, want)
+	}
+
+	tests := []struct {
+		field        typeinfo.Field
+		selection    string
+		responseName string
+		inline       bool
+		fragment     bool
+		exported     bool
+	}{
+		{field: s.Fields[0], selection: "databaseId", responseName: "databaseId", exported: true},
+		{field: s.Fields[1], selection: `node1: node(id: "abc")`, responseName: "node1", exported: true},
+		{field: s.Fields[2], selection: "... on User", fragment: true, exported: true},
+		{field: s.Fields[3], selection: "embedded", inline: true},
+		{field: s.Fields[4], selection: "unexported", responseName: "unexported"},
+	}
+	for _, tc := range tests {
+		f := tc.field
+		if f.Selection != tc.selection {
+			t.Errorf("%s: got selection %q, want %q", f.Name, f.Selection, tc.selection)
+		}
+		if tc.responseName != "" && !f.HasGraphQLName(tc.responseName) {
+			t.Errorf("%s: got no match for %q, want match", f.Name, tc.responseName)
+		}
+		if f.Inline != tc.inline || f.Fragment != tc.fragment || f.Exported != tc.exported {
+			t.Errorf("%s: got inline=%v fragment=%v exported=%v, want %v %v %v", f.Name,
+				f.Inline, f.Fragment, f.Exported, tc.inline, tc.fragment, tc.exported)
+		}
+	}
+	if s.Fields[1].HasGraphQLName("node") {
+		t.Error("aliased field matched its field name, want only alias to match")
+	}
+}
+
+func TestField_HasGraphQLName(t *testing.T) {
+	tests := []struct {
+		tag  string
+		name string
+	}{
+		{tag: `user`, name: "user"},
+		{tag: ` user `, name: "user"},
+		{tag: `user(login: "a:b")`, name: "user"},
+		{tag: `a: user(login: "a")`, name: "a"},
+		{tag: `a:user`, name: "a"},
+		{tag: `b  :  user`, name: "b"},
+		{tag: `name @include(if: $withName)`, name: "name"},
+		{tag: `alias_1: name @skip(if: true)`, name: "alias_1"},
+	}
+	for _, tc := range tests {
+		typ := reflect.StructOf([]reflect.StructField{{
+			Name: "Field",
+			Type: reflect.TypeOf(""),
+			Tag:  reflect.StructTag(`graphql:"` + tc.tag + `"`),
+		}})
+		f := typeinfo.Of(typ, "").Fields[0]
+		if !f.HasGraphQLName(tc.name) {
+			t.Errorf("tag %q: got no match for %q, want match", tc.tag, tc.name)
+		}
+	}
+}
+
+func TestOf_tagKey(t *testing.T) {
+	type query struct {
+		User struct{} `graphql:"server(resolver: \"user\")" gqlclient:"u: user(id: 1)"`
+	}
+	typ := reflect.TypeOf(query{})
+	if got, want := typeinfo.Of(typ, "").Fields[0].Selection, `server(resolver: "user")`; got != want {
+		t.Errorf("got selection %q, want %q", got, want)
+	}
+	if got, want := typeinfo.Of(typ, "gqlclient").Fields[0].Selection, "u: user(id: 1)"; got != want {
+		t.Errorf("got selection %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,250 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+)
+
+// IntrospectionQuery is the document of the standard introspection query,
+// whose result ParseIntrospection parses.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// Introspect runs the standard introspection query, through the middleware of
+// the client, and returns the schema of the server.
+func (c *Client) Introspect(ctx context.Context, options ...Option) (*Schema, error) {
+	op := c.newDocumentOperation(ctx, QueryOperation, nil, "IntrospectionQuery", options)
+	op.Query = IntrospectionQuery
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return nil, c.annotateError(err, op)
+	}
+	if len(resp.Errors) > 0 {
+		return nil, c.annotateError(resp.Errors, op)
+	}
+	if resp.Data == nil {
+		return nil, c.annotateError(&DecodeError{err: errors.New("no data in introspection result")}, op)
+	}
+	var data struct {
+		Schema *Schema `json:"__schema"`
+	}
+	if err := c.codec.Unmarshal(*resp.Data, &data); err != nil {
+		return nil, c.annotateError(&DecodeError{err: err}, op)
+	}
+	if data.Schema == nil {
+		return nil, c.annotateError(&DecodeError{err: errors.New("no __schema in introspection result")}, op)
+	}
+	return data.Schema, nil
+}
+
+// Schema is a GraphQL schema, as described by the introspection system.
+type Schema struct {
+	QueryType        *TypeRef
+	MutationType     *TypeRef
+	SubscriptionType *TypeRef
+	Types            []Type
+	Directives       []Directive
+}
+
+// TypeKind is the kind of a GraphQL type.
+type TypeKind string
+
+// Kinds of GraphQL types.
+const (
+	TypeKindScalar      TypeKind = "SCALAR"
+	TypeKindObject      TypeKind = "OBJECT"
+	TypeKindInterface   TypeKind = "INTERFACE"
+	TypeKindUnion       TypeKind = "UNION"
+	TypeKindEnum        TypeKind = "ENUM"
+	TypeKindInputObject TypeKind = "INPUT_OBJECT"
+	TypeKindList        TypeKind = "LIST"
+	TypeKindNonNull     TypeKind = "NON_NULL"
+)
+
+// Type is a named type of a Schema.
+type Type struct {
+	Kind        TypeKind
+	Name        string
+	Description string
+	// Fields holds the fields of objects and interfaces.
+	Fields []Field
+	// InputFields holds the fields of input objects.
+	InputFields []InputValue
+	// Interfaces holds the interfaces implemented by objects.
+	Interfaces []TypeRef
+	// EnumValues holds the values of enums.
+	EnumValues []EnumValue
+	// PossibleTypes holds the members of unions and the implementations of interfaces.
+	PossibleTypes []TypeRef
+}
+
+// Field is a field of an object or interface type.
+type Field struct {
+	Name              string
+	Description       string
+	Args              []InputValue
+	Type              TypeRef
+	IsDeprecated      bool
+	DeprecationReason *string
+}
+
+// InputValue is an argument, or a field of an input object type.
+type InputValue struct {
+	Name        string
+	Description string
+	Type        TypeRef
+	// DefaultValue is the default value in GraphQL syntax, e.g. `"en"`, or nil if there's none.
+	DefaultValue *string
+}
+
+// EnumValue is a value of an enum type.
+type EnumValue struct {
+	Name              string
+	Description       string
+	IsDeprecated      bool
+	DeprecationReason *string
+}
+
+// Directive is a directive supported by a Schema.
+type Directive struct {
+	Name        string
+	Description string
+	// Locations holds where the directive may be used, e.g. "FIELD".
+	Locations []string
+	Args      []InputValue
+}
+
+// TypeRef refers to a type, wrapped in lists and non-null types, such as [String!]!.
+type TypeRef struct {
+	Kind TypeKind
+	// Name is the name of the type, or "" for lists and non-null types.
+	Name string
+	// OfType is the wrapped type of lists and non-null types.
+	OfType *TypeRef
+}
+
+// String returns the type in GraphQL syntax, e.g. "[String!]!".
+func (t TypeRef) String() string {
+	switch {
+	case t.Kind == TypeKindNonNull && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == TypeKindList && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// NamedType returns the name of the type wrapped in lists and non-null types, if any.
+func (t TypeRef) NamedType() string {
+	for t.OfType != nil {
+		t = *t.OfType
+	}
+	return t.Name
+}
+
+// Type returns the type of s named name, or nil if there's none.
+func (s *Schema) Type(name string) *Type {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// ParseIntrospection parses the JSON result of an introspection query, either
+// a whole GraphQL response or its data, as saved by most schema download tools.
+func ParseIntrospection(data []byte) (*Schema, error) {
+	var result struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		}
+		Schema *Schema `json:"__schema"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	if result.Data != nil && result.Data.Schema != nil {
+		return result.Data.Schema, nil
+	}
+	if result.Schema == nil {
+		return nil, errors.New("no __schema in introspection result")
+	}
+	return result.Schema, nil
+}
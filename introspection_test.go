@@ -0,0 +1,103 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestParseIntrospection(t *testing.T) {
+	const schema = `{"__schema": {
+		"queryType": {"name": "Query"},
+		"types": [{
+			"kind": "OBJECT",
+			"name": "Query",
+			"fields": [{
+				"name": "users",
+				"args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}],
+				"type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}},
+				"isDeprecated": false
+			}]
+		}]
+	}}`
+	for _, data := range []string{schema, `{"data": ` + schema + `}`} {
+		s, err := graphql.ParseIntrospection([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := s.QueryType.Name, "Query"; got != want {
+			t.Errorf("got query type: %q, want: %q", got, want)
+		}
+		query := s.Type("Query")
+		if query == nil || len(query.Fields) != 1 {
+			t.Fatalf("got query type: %+v, want one with a field", query)
+		}
+		field := query.Fields[0]
+		if got, want := field.Type.String(), "[User!]!"; got != want {
+			t.Errorf("got field type: %q, want: %q", got, want)
+		}
+		if got, want := field.Type.NamedType(), "User"; got != want {
+			t.Errorf("got named type: %q, want: %q", got, want)
+		}
+		if arg := field.Args[0]; arg.DefaultValue == nil || *arg.DefaultValue != "10" {
+			t.Errorf("got argument: %+v, want the default value 10", arg)
+		}
+		if s.Type("Missing") != nil {
+			t.Error("got a missing type")
+		}
+	}
+
+	if _, err := graphql.ParseIntrospection([]byte(`{"data": {}}`)); err == nil {
+		t.Error("got no error, want one for a missing __schema")
+	}
+}
+
+func TestClient_Introspect(t *testing.T) {
+	result, err := ioutil.ReadFile("schema/testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query string
+		}
+		if err := json.Unmarshal([]byte(mustRead(req.Body)), &in); err != nil {
+			t.Error(err)
+		}
+		if in.Query != graphql.IntrospectionQuery {
+			t.Errorf("got query: %q, want the introspection query", in.Query)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(result)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	s, err := client.Introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.MutationType.Name, "Mutation"; got != want {
+		t.Errorf("got mutation type: %q, want: %q", got, want)
+	}
+	user := s.Type("User")
+	if user == nil || user.Kind != graphql.TypeKindObject {
+		t.Fatalf("got User type: %+v, want an object", user)
+	}
+	if got, want := user.Interfaces[0].Name, "Node"; got != want {
+		t.Errorf("got interface: %q, want: %q", got, want)
+	}
+	var login *graphql.Field
+	for i := range user.Fields {
+		if user.Fields[i].Name == "login" {
+			login = &user.Fields[i]
+		}
+	}
+	if login == nil || !login.IsDeprecated || login.DeprecationReason == nil || *login.DeprecationReason != "Use name." {
+		t.Errorf("got login field: %+v, want it deprecated", login)
+	}
+}
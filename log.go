@@ -0,0 +1,131 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// WithLogger makes the client log each operation with logger, at info level, with
+// its type, name, endpoint, duration, number of attempts, and the class of its error
+// if it failed, and each attempt to send it, at debug level. Variable values are
+// replaced by "[REDACTED]", unless WithRedactor sets how to redact them.
+func (c *Client) WithLogger(logger *slog.Logger) *Client {
+	c.logger = logger
+	return c
+}
+
+// WithLogRedaction makes the client log and dump the variables of operations as returned
+// by redact, which must hide secrets such as passwords and tokens.
+// It's the same as WithRedactor(redact).
+func (c *Client) WithLogRedaction(redact RedactFunc) *Client {
+	return c.WithRedactor(redact)
+}
+
+// logAttemptsKey is the context key of the number of attempts to send an operation, as *int32.
+type logAttemptsKey struct{}
+
+// logOperation returns the handler logging the operations executed by next.
+func (c *Client) logOperation(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		attempts := new(int32)
+		start := time.Now()
+		resp, err := next(context.WithValue(ctx, logAttemptsKey{}, attempts), op)
+
+		attrs := append(c.operationAttrs(op),
+			slog.Duration("duration", time.Since(start)),
+			slog.Int("attempts", int(atomic.LoadInt32(attempts))),
+		)
+		logErr := err
+		if err == nil && resp != nil && len(resp.Errors) > 0 {
+			logErr = resp.Errors
+		}
+		if logErr != nil {
+			attrs = append(attrs, slog.String("error", logErr.Error()), slog.String("error_class", errorClass(logErr)))
+		}
+		c.logger.LogAttrs(ctx, slog.LevelInfo, "graphql operation", attrs...)
+		return resp, err
+	}
+}
+
+// logAttempt returns the handler logging each attempt of next to send an operation.
+func (c *Client) logAttempt(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		attempt := 1
+		if attempts, ok := ctx.Value(logAttemptsKey{}).(*int32); ok {
+			attempt = int(atomic.AddInt32(attempts, 1))
+		}
+		start := time.Now()
+		resp, err := next(ctx, op)
+
+		attrs := []slog.Attr{
+			slog.String("type", op.Type.String()),
+			slog.String("name", op.Name),
+			slog.String("url", op.URL),
+		}
+		if op.RequestID != "" {
+			attrs = append(attrs, slog.String("request_id", op.RequestID))
+		}
+		attrs = append(attrs, slog.Int("attempt", attempt), slog.Duration("duration", time.Since(start)))
+		if resp != nil && resp.StatusCode != 0 {
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()), slog.String("error_class", errorClass(err)))
+		}
+		c.logger.LogAttrs(ctx, slog.LevelDebug, "graphql attempt", attrs...)
+		return resp, err
+	}
+}
+
+// operationAttrs returns the attributes describing op in logs, with redacted variables.
+func (c *Client) operationAttrs(op *Operation) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("type", op.Type.String()),
+		slog.String("name", op.Name),
+		slog.String("url", op.URL),
+		slog.String("document_hash", op.DocumentHash()),
+	}
+	if op.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", op.RequestID))
+	}
+	if len(op.Variables) > 0 {
+		redactedVariables := c.redactVariables(op.Variables)
+		variables := make([]interface{}, 0, len(op.Variables))
+		for _, name := range sortedKeys(op.Variables) {
+			variables = append(variables, slog.Any(name, redactedVariables[name]))
+		}
+		attrs = append(attrs, slog.Group("variables", variables...))
+	}
+	return attrs
+}
+
+// errorClass returns the class of err, as returned by an OperationHandler, for logs.
+func errorClass(err error) string {
+	var tooLarge *ResponseTooLargeError
+	var netErr *NetworkError
+	var decodeErr *DecodeError
+	var gqlErrs Errors
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.As(err, &tooLarge):
+		return "response_too_large"
+	case errors.As(err, &netErr):
+		if netErr.StatusCode() != 0 {
+			return "http_status"
+		}
+		return "network"
+	case errors.As(err, &decodeErr):
+		return "decode"
+	case errors.As(err, &gqlErrs):
+		return "graphql"
+	}
+	return "other"
+}
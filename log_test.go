@@ -0,0 +1,111 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithLogger(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"login": null}, "errors": [{"message": "wrong password"}]}`)
+	})
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithLogger(logger)
+
+	var m struct {
+		Login *struct {
+			Token string
+		} `graphql:"login(user: $user, password: $password)"`
+	}
+	variables := map[string]interface{}{
+		"user":     graphql.String("gopher"),
+		"password": graphql.String("hunter2"),
+	}
+	resp, err := client.NamedMutateWithResponse(context.Background(), "Login", &m, variables)
+	if err == nil {
+		t.Fatal("got no error, want the GraphQL error")
+	}
+	if resp == nil {
+		t.Fatal("got no response along with the GraphQL error")
+	}
+
+	var entries []map[string]interface{}
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var entry map[string]interface{}
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	if got, want := len(entries), 2; got != want {
+		t.Fatalf("got %d log entries, want: %d", got, want)
+	}
+	attempt, operation := entries[0], entries[1]
+	if got, want := attempt["msg"], "graphql attempt"; got != want {
+		t.Errorf("got message: %v, want: %v", got, want)
+	}
+	if got, want := attempt["level"], "DEBUG"; got != want {
+		t.Errorf("got level: %v, want: %v", got, want)
+	}
+	if got, want := attempt["status"], 200.0; got != want {
+		t.Errorf("got status: %v, want: %v", got, want)
+	}
+	if got, want := operation["msg"], "graphql operation"; got != want {
+		t.Errorf("got message: %v, want: %v", got, want)
+	}
+	for key, want := range map[string]interface{}{
+		"level":       "INFO",
+		"type":        "mutation",
+		"name":        "Login",
+		"attempts":    1.0,
+		"error":       "wrong password",
+		"error_class": "graphql",
+	} {
+		if got := operation[key]; got != want {
+			t.Errorf("got %s: %v, want: %v", key, got, want)
+		}
+	}
+	if got, ok := operation["document_hash"].(string); !ok || len(got) != 64 {
+		t.Errorf("got document_hash: %v, want a SHA-256 hash", operation["document_hash"])
+	}
+	wantVariables := map[string]interface{}{"password": "[REDACTED]", "user": "[REDACTED]"}
+	if got := operation["variables"]; !equalJSON(got, wantVariables) {
+		t.Errorf("got variables: %v, want: %v", got, wantVariables)
+	}
+
+	buf.Reset()
+	client.WithLogRedaction(func(name string, value interface{}) interface{} {
+		if name == "password" {
+			return "***"
+		}
+		return value
+	})
+	if err := client.NamedMutate(context.Background(), "Login", &m, variables); err == nil {
+		t.Fatal("got no error, want the GraphQL error")
+	}
+	if bytes.Contains(buf.Bytes(), []byte("hunter2")) {
+		t.Errorf("got the password logged: %s", buf.String())
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"variables":{"password":"***","user":"gopher"}`)) {
+		t.Errorf("got no redacted variables logged: %s", buf.String())
+	}
+}
+
+func equalJSON(a, b interface{}) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	return err == nil && bytes.Equal(ja, jb)
+}
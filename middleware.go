@@ -0,0 +1,142 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// OperationType is the type of a GraphQL operation.
+type OperationType uint8
+
+const (
+	// QueryOperation is a query, including the live queries of SubscriptionClient.
+	QueryOperation OperationType = iota
+	// MutationOperation is a mutation.
+	MutationOperation
+	// SubscriptionOperation is a subscription.
+	SubscriptionOperation
+)
+
+// String returns the GraphQL keyword of t, e.g. "query".
+func (t OperationType) String() string {
+	switch t {
+	case QueryOperation:
+		return "query"
+	case MutationOperation:
+		return "mutation"
+	case SubscriptionOperation:
+		return "subscription"
+	default:
+		return "unknown"
+	}
+}
+
+// Operation is a GraphQL operation executed by Client.
+// Middleware may modify it before passing it on.
+type Operation struct {
+	Type OperationType
+	// Name is the operation name, or "" if the operation is anonymous.
+	Name string
+	// URL is the GraphQL server endpoint the operation is sent to.
+	URL string
+	// Query is the constructed GraphQL document.
+	Query     string
+	Variables map[string]interface{}
+	// Header holds the headers sent with the request,
+	// in addition to the ones set by Client.
+	Header http.Header
+	// RequestID is the request ID of the operation, if enabled by Client.WithRequestIDs.
+	RequestID string
+	// IdempotencyKey is the idempotency key of the mutation, if set by
+	// WithIdempotencyKey or enabled by Client.WithIdempotencyKeys.
+	IdempotencyKey string
+	// Extensions is the "extensions" object of the request payload, if any,
+	// as set by WithRequestExtension.
+	Extensions map[string]interface{}
+
+	options *requestOptions
+}
+
+// Response is the decoded response of a GraphQL server to an Operation.
+type Response struct {
+	// Data is the raw "data" field of the response, or nil if absent.
+	Data *json.RawMessage `json:"data"`
+	// Errors holds the GraphQL errors of the response.
+	Errors     Errors      `json:"errors"`
+	Extensions interface{} `json:"extensions"`
+
+	// StatusCode, Header and Duration describe the HTTP response carrying
+	// the operation, shared by the operations of a batched request. They're
+	// those of the original response for cached and coalesced responses.
+	StatusCode int           `json:"-"`
+	Header     http.Header   `json:"-"`
+	Duration   time.Duration `json:"-"` // From sending the request to reading the response.
+
+	body io.ReadCloser // Unread body of the response of Client.QueryStream.
+}
+
+// OperationHandler executes an Operation and returns its response.
+// GraphQL errors are reported in Response.Errors, while the returned
+// error is reserved for failures to get a response, such as a *NetworkError.
+type OperationHandler func(ctx context.Context, op *Operation) (*Response, error)
+
+// Middleware wraps the execution of an operation, e.g. for logging,
+// authentication, metrics, or retries. It returns an OperationHandler that
+// usually calls next, possibly several times, or none to short-circuit:
+//
+//	func logging(next graphql.OperationHandler) graphql.OperationHandler {
+//		return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+//			start := time.Now()
+//			resp, err := next(ctx, op)
+//			log.Printf("%s %s took %v", op.Type, op.Name, time.Since(start))
+//			return resp, err
+//		}
+//	}
+type Middleware func(next OperationHandler) OperationHandler
+
+// Use appends middlewares to the middleware chain of the client.
+// The first middleware is the outermost one, seeing operations first.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.middlewares = append(c.middlewares, middlewares...)
+	return c
+}
+
+// RequestModifier adjusts the HTTP request of an operation before it's sent,
+// e.g. to set headers, propagate traces or override the host.
+// If it returns an error, the operation fails with that error.
+type RequestModifier func(req *http.Request) error
+
+// ModifyRequest registers a modifier called with the HTTP request of every operation,
+// once it's built and before it's sent. Modifiers are called in order.
+func (c *Client) ModifyRequest(modifier RequestModifier) *Client {
+	c.requestModifiers = append(c.requestModifiers, modifier)
+	return c
+}
+
+// RawResponse is an HTTP response of the GraphQL server, before decoding.
+type RawResponse struct {
+	// Operation is the operation the response is for, or nil for batched requests.
+	Operation  *Operation
+	StatusCode int
+	Header     http.Header
+	// Duration is the time from sending the request until the body was read.
+	Duration time.Duration
+	// Body is the raw JSON body. It's only valid until the hook returns,
+	// so it must be copied to be retained.
+	Body []byte
+}
+
+// ResponseHook inspects the raw response of the GraphQL server before it's decoded,
+// e.g. to capture rate-limit headers or tracing extensions. If it returns an error,
+// the operation fails with that error.
+type ResponseHook func(ctx context.Context, resp *RawResponse) error
+
+// OnResponse registers a hook called with every response of the GraphQL server,
+// after the body is read and before it's decoded. Hooks are called in order.
+func (c *Client) OnResponse(hook ResponseHook) *Client {
+	c.responseHooks = append(c.responseHooks, hook)
+	return c
+}
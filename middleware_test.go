@@ -0,0 +1,184 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_Use(t *testing.T) {
+	var gotAuth string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotAuth = req.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var calls []string
+	record := func(id string) graphql.Middleware {
+		return func(next graphql.OperationHandler) graphql.OperationHandler {
+			return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+				calls = append(calls, id+" "+op.Type.String()+" "+op.Name+" "+op.Query)
+				return next(ctx, op)
+			}
+		}
+	}
+	auth := func(next graphql.OperationHandler) graphql.OperationHandler {
+		return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+			op.Header.Set("Authorization", "Bearer token")
+			return next(ctx, op)
+		}
+	}
+	client.Use(record("outer"), auth, record("inner"))
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	err := client.NamedQuery(context.Background(), "GetUser", &q, map[string]interface{}{"id": graphql.Int(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"outer query GetUser query GetUser($id:Int!){user(id: $id){name}}",
+		"inner query GetUser query GetUser($id:Int!){user(id: $id){name}}",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("got calls:\n%q\nwant:\n%q", calls, want)
+	}
+	if got, want := gotAuth, "Bearer token"; got != want {
+		t.Errorf("got Authorization: %q, want: %q", got, want)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Use_shortCircuit(t *testing.T) {
+	client := graphql.NewClient("/graphql", &http.Client{Transport: errorRoundTripper{err: errors.New("unreachable")}})
+	data := json.RawMessage(`{"user": {"name": "Cached"}}`)
+	client.Use(func(next graphql.OperationHandler) graphql.OperationHandler {
+		return func(ctx context.Context, op *graphql.Operation) (*graphql.Response, error) {
+			if op.Type == graphql.MutationOperation {
+				return nil, errors.New("mutations are disabled")
+			}
+			return &graphql.Response{Data: &data}, nil
+		}
+	})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Cached"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	err := client.Mutate(context.Background(), &q, nil)
+	if got, want := err, "mutations are disabled"; got == nil || got.Error() != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+}
+
+func TestClient_OnResponse(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-RateLimit-Remaining", "41")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}, "extensions": {"cost": 3}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var got *graphql.RawResponse
+	var body string
+	client.OnResponse(func(ctx context.Context, resp *graphql.RawResponse) error {
+		got = resp
+		body = string(resp.Body)
+		return nil
+	})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		t.Fatal("got no response in hook")
+	}
+	if got, want := got.StatusCode, http.StatusOK; got != want {
+		t.Errorf("got status code: %v, want: %v", got, want)
+	}
+	if got, want := got.Header.Get("X-RateLimit-Remaining"), "41"; got != want {
+		t.Errorf("got X-RateLimit-Remaining: %q, want: %q", got, want)
+	}
+	if got, want := got.Operation.Name, "GetUser"; got != want {
+		t.Errorf("got operation name: %q, want: %q", got, want)
+	}
+	if got, want := body, `{"data": {"user": {"name": "Gopher"}}, "extensions": {"cost": 3}}`; got != want {
+		t.Errorf("got body: %q, want: %q", got, want)
+	}
+	if got.Duration <= 0 {
+		t.Errorf("got duration: %v, want: > 0", got.Duration)
+	}
+
+	errRejected := errors.New("rejected")
+	client.OnResponse(func(ctx context.Context, resp *graphql.RawResponse) error {
+		return errRejected
+	})
+	if err := client.Query(context.Background(), &q, nil); !errors.Is(err, errRejected) {
+		t.Errorf("got error: %v, want: %v", err, errRejected)
+	}
+}
+
+func TestClient_ModifyRequest(t *testing.T) {
+	var got *http.Request
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = req
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+	client.ModifyRequest(func(req *http.Request) error {
+		req.Header.Set("Traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+		req.Host = "api.example.com"
+		return nil
+	})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := got.Header.Get("Traceparent"), "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01"; got != want {
+		t.Errorf("got Traceparent: %q, want: %q", got, want)
+	}
+	if got, want := got.Host, "api.example.com"; got != want {
+		t.Errorf("got Host: %q, want: %q", got, want)
+	}
+
+	errRejected := errors.New("rejected")
+	client.ModifyRequest(func(req *http.Request) error {
+		return errRejected
+	})
+	if err := client.Query(context.Background(), &q, nil); !errors.Is(err, errRejected) {
+		t.Errorf("got error: %v, want: %v", err, errRejected)
+	}
+}
@@ -0,0 +1,81 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/runtimeracer/go-graphql-client/internal/jsonutil"
+	"github.com/runtimeracer/go-graphql-client/internal/typeinfo"
+)
+
+// ErrNodeNotFound is returned by Client.Node when no node has the ID.
+var ErrNodeNotFound = errors.New("graphql: node not found")
+
+// Node refetches the node with the global ID id of a Relay-compliant schema
+// into the struct into points to, executing the query
+//
+//	query ($id:ID!){node(id: $id){__typename,... on User{...}}}
+//
+// where User is the name of the Go type of the struct, and its fields are selected
+// as those of query structs, e.g.:
+//
+//	var user User
+//	err := client.Node(ctx, id, &user)
+//
+// It returns ErrNodeNotFound if no node has the ID, and an error if the node isn't
+// of the type of the struct.
+func (c *Client) Node(ctx context.Context, id ID, into interface{}, options ...Option) error {
+	v := reflect.ValueOf(into)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("graphql: node is decoded into %T, not a pointer to a struct", into)
+	}
+	typename := v.Elem().Type().Name()
+	if typename == "" {
+		return fmt.Errorf("graphql: node type %v has no name to select it by", v.Elem().Type())
+	}
+
+	tagKey := c.decodeOpts.TagKey
+	if tagKey == "" {
+		tagKey = typeinfo.DefaultTagKey
+	}
+	node := reflect.StructOf([]reflect.StructField{
+		{Name: "Typename", Type: reflect.TypeOf(""), Tag: reflect.StructTag(fmt.Sprintf("%s:%q", tagKey, "__typename"))},
+		{Name: "Fragment", Type: v.Type(), Tag: reflect.StructTag(fmt.Sprintf("%s:%q", tagKey, "... on "+typename))},
+	})
+	q := reflect.New(reflect.StructOf([]reflect.StructField{
+		{Name: "Node", Type: reflect.PtrTo(node), Tag: reflect.StructTag(fmt.Sprintf("%s:%q", tagKey, "node(id: $id)"))},
+	}))
+
+	selection, err := query(q.Elem().Interface(), tagKey)
+	if err != nil {
+		return err
+	}
+	op := c.newDocumentOperation(ctx, QueryOperation, map[string]interface{}{"id": id}, "", options)
+	op.Query = "query " + op.Name + "($id:ID!)" + selection
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return c.annotateError(err, op)
+	}
+	if resp.Data != nil {
+		// Decode the fragment into the struct of the caller.
+		n := reflect.New(node)
+		n.Elem().Field(1).Set(v)
+		q.Elem().Field(0).Set(n)
+		if err := jsonutil.UnmarshalGraphQLWithOptions(*resp.Data, q.Interface(), c.decodeOpts); err != nil {
+			return c.annotateError(&DecodeError{err: err}, op)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return c.annotateError(resp.Errors, op)
+	}
+	// Null nodes are decoded as nil.
+	if resp.Data == nil || q.Elem().Field(0).IsNil() {
+		return ErrNodeNotFound
+	}
+	if got := q.Elem().Field(0).Elem().Field(0).String(); got != typename {
+		return fmt.Errorf("graphql: node %v is a %s, not a %s", id, got, typename)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type User struct {
+	Login graphql.String
+	Name  graphql.String `graphql:"fullName: name"`
+}
+
+func TestClient_Node(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     User
+		wantErr  string
+	}{
+		{
+			name:     "found",
+			response: `{"data": {"node": {"__typename": "User", "login": "gopher", "fullName": "Gopher"}}}`,
+			want:     User{Login: "gopher", Name: "Gopher"},
+		},
+		{
+			name:     "not found",
+			response: `{"data": {"node": null}}`,
+			wantErr:  graphql.ErrNodeNotFound.Error(),
+		},
+		{
+			name:     "other type",
+			response: `{"data": {"node": {"__typename": "Repository"}}}`,
+			wantErr:  "graphql: node VXNlcjox is a Repository, not a User",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				var in struct {
+					Query     string
+					Variables map[string]interface{}
+				}
+				if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+					t.Error(err)
+				}
+				if got, want := in.Query, "query ($id:ID!){node(id: $id){__typename,... on User{login,fullName: name}}}"; got != want {
+					t.Errorf("got query: %q, want: %q", got, want)
+				}
+				if got, want := in.Variables, map[string]interface{}{"id": "VXNlcjox"}; !reflect.DeepEqual(got, want) {
+					t.Errorf("got variables: %v, want: %v", got, want)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				mustWrite(w, tt.response)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+			var user User
+			err := client.Node(context.Background(), "VXNlcjox", &user)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error: %v, want: %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user != tt.want {
+				t.Errorf("got user: %+v, want: %+v", user, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Node_errors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"node": null}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var user User
+	if err := client.Node(context.Background(), "VXNlcjox", &user); !errors.Is(err, graphql.ErrNodeNotFound) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrNodeNotFound)
+	}
+	var anonymous struct{ Login graphql.String }
+	if err := client.Node(context.Background(), "VXNlcjox", &anonymous); err == nil {
+		t.Error("got no error for an anonymous struct")
+	}
+}
@@ -0,0 +1,70 @@
+package graphql
+
+import "encoding/json"
+
+// Optional is a nullable field of an input object, which is either unset, null,
+// or set to a value. Unlike a pointer, it tells unset fields, which mean
+// "leave unchanged" to many servers, from fields explicitly set to null.
+//
+// Optional marshals unset fields as null, so input objects leave them out
+// with a MarshalJSON method, as those generated by gqlclientgen do.
+// The zero value is unset.
+type Optional[T any] struct {
+	value T
+	state optionalState
+}
+
+type optionalState uint8
+
+const (
+	optionalUnset optionalState = iota
+	optionalNull
+	optionalValue
+)
+
+// OptionalOf returns an Optional set to value.
+func OptionalOf[T any](value T) Optional[T] {
+	return Optional[T]{value: value, state: optionalValue}
+}
+
+// OptionalNull returns an Optional set to null.
+func OptionalNull[T any]() Optional[T] {
+	return Optional[T]{state: optionalNull}
+}
+
+// IsSet reports whether o is set, to null or a value.
+func (o Optional[T]) IsSet() bool {
+	return o.state != optionalUnset
+}
+
+// IsNull reports whether o is set to null.
+func (o Optional[T]) IsNull() bool {
+	return o.state == optionalNull
+}
+
+// Get returns the value of o, and whether it's set to a value.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.state == optionalValue
+}
+
+// MarshalJSON implements json.Marshaler.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if o.state != optionalValue {
+		return []byte("null"), nil
+	}
+	return json.Marshal(o.value)
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*o = OptionalNull[T]()
+		return nil
+	}
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*o = OptionalOf(value)
+	return nil
+}
@@ -0,0 +1,61 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestOptional(t *testing.T) {
+	var unset graphql.Optional[graphql.String]
+	if unset.IsSet() || unset.IsNull() {
+		t.Error("zero value is set")
+	}
+	null := graphql.OptionalNull[graphql.String]()
+	if !null.IsSet() || !null.IsNull() {
+		t.Error("OptionalNull isn't set to null")
+	}
+	set := graphql.OptionalOf(graphql.String("gopher"))
+	if v, ok := set.Get(); !ok || v != "gopher" || set.IsNull() {
+		t.Errorf("got %q, %v, want \"gopher\", true", v, ok)
+	}
+	if _, ok := null.Get(); ok {
+		t.Error("OptionalNull has a value")
+	}
+
+	for _, tc := range []struct {
+		o    graphql.Optional[graphql.String]
+		want string
+	}{
+		{unset, "null"},
+		{null, "null"},
+		{set, `"gopher"`},
+	} {
+		got, err := json.Marshal(tc.o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("got %s, want %s", got, tc.want)
+		}
+	}
+}
+
+func TestOptional_UnmarshalJSON(t *testing.T) {
+	var v struct {
+		A, B, C graphql.Optional[graphql.Int]
+	}
+	if err := json.Unmarshal([]byte(`{"a": 1, "b": null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := v.A.Get(); !ok || got != 1 {
+		t.Errorf("got a %v, %v, want 1, true", got, ok)
+	}
+	if !v.B.IsNull() {
+		t.Error("b isn't null")
+	}
+	if v.C.IsSet() {
+		t.Error("c is set")
+	}
+}
@@ -0,0 +1,171 @@
+package graphql
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client/internal/jsonutil"
+)
+
+// Option configures a single request made by Client, e.g.:
+//
+//	err := client.Query(ctx, &q, variables, graphql.WithRequestHeader("x-hasura-role", "editor"))
+//
+// Options passed to NewClient apply to every request made by the client.
+type Option func(*requestOptions)
+
+// requestOptions holds the configuration of a single request.
+type requestOptions struct {
+	header      http.Header
+	endpoint    string
+	queryParams url.Values
+	timeout     time.Duration
+	extensions  map[string]interface{}
+
+	idempotencyKey string
+
+	cacheMode     cacheMode
+	cacheMaxAge   time.Duration
+	cacheMaxStale time.Duration
+
+	elements map[string]jsonutil.ElementFunc
+
+	stream bool // Set by Client.QueryStream.
+}
+
+// newRequestOptions applies the default options of c, then header, set by the
+// context of the call, followed by options to new request configuration.
+func (c *Client) newRequestOptions(header http.Header, options []Option) *requestOptions {
+	opts := &requestOptions{}
+	for _, o := range c.defaultOptions {
+		o(opts)
+	}
+	for k, v := range header {
+		if opts.header == nil {
+			opts.header = make(http.Header)
+		}
+		opts.header[k] = append([]string(nil), v...)
+	}
+	for _, o := range options {
+		o(opts)
+	}
+	return opts
+}
+
+// WithRequestHeader sets the header key to value on a single request,
+// replacing any value set by the client, e.g. to send a per-user
+// Authorization header or a Hasura role through a shared client.
+func WithRequestHeader(key, value string) Option {
+	return func(opts *requestOptions) {
+		if opts.header == nil {
+			opts.header = make(http.Header)
+		}
+		opts.header.Set(key, value)
+	}
+}
+
+// WithIdempotencyKey sets the idempotency key of a mutation to key, instead of
+// the random one of Client.WithIdempotencyKeys, e.g. one derived from the request
+// being served, so that the mutations of repeated calls are applied once too.
+// It's sent in the header set by Client.WithIdempotencyKeys, or
+// DefaultIdempotencyKeyHeader. Queries ignore it.
+func WithIdempotencyKey(key string) Option {
+	return func(opts *requestOptions) {
+		opts.idempotencyKey = key
+	}
+}
+
+// WithEndpoint sends a single call to url instead of the URL of the client,
+// or the one set by the context with WithURL, e.g. to the shard of a tenant
+// chosen at call time, through the transport and middleware of the client.
+// The endpoints added by WithFailover aren't tried for it. Mutations queued by
+// MutationQueue are replayed to it too.
+func WithEndpoint(url string) Option {
+	return func(opts *requestOptions) {
+		opts.endpoint = url
+	}
+}
+
+// WithQueryParam sets the URL query parameter key of the endpoint to value,
+// replacing any value of the endpoint URL or set by the client, e.g. for the
+// API keys, API versions or tenants some managed GraphQL services require
+// outside the body:
+//
+//	client := graphql.NewClient(url, nil, graphql.WithQueryParam("api-version", "2024-01"))
+func WithQueryParam(key, value string) Option {
+	return func(opts *requestOptions) {
+		if opts.queryParams == nil {
+			opts.queryParams = make(url.Values)
+		}
+		opts.queryParams.Set(key, value)
+	}
+}
+
+// queryParams returns the URL query parameters set by WithQueryParam for op.
+func (op *Operation) queryParams() url.Values {
+	if op.options == nil {
+		return nil
+	}
+	return op.options.queryParams
+}
+
+// WithRequestExtension sets the entry key of the "extensions" object of the
+// request payload to value, replacing any value set by the client, e.g. for the
+// tracing flags or tenant hints of a gateway:
+//
+//	err := client.Query(ctx, &q, variables, graphql.WithRequestExtension("tenant", "acme"))
+//
+// GET and application/graphql requests send the object as the "extensions" URL parameter.
+func WithRequestExtension(key string, value interface{}) Option {
+	return func(opts *requestOptions) {
+		if opts.extensions == nil {
+			opts.extensions = make(map[string]interface{})
+		}
+		opts.extensions[key] = value
+	}
+}
+
+// WithRequestTimeout bounds the time of every attempt to send the operation of a single call,
+// overriding the timeout set by Client.WithTimeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(opts *requestOptions) {
+		opts.timeout = timeout
+	}
+}
+
+// WithElements hands each element of the list field at path to fn once it's
+// decoded, instead of appending it to its slice, which is left empty, so that
+// huge lists, e.g. of an export endpoint, are processed without keeping them
+// in memory:
+//
+//	var q struct {
+//		Export struct {
+//			Rows []Row
+//		}
+//	}
+//	err := client.Query(ctx, &q, nil, graphql.WithElements("export.rows", func(row Row) error {
+//		return w.Write(row.Fields())
+//	}))
+//
+// path is made of the response keys of the field and its enclosing fields,
+// joined by dots, and applies to every occurrence of the list in lists of
+// enclosing objects. T must be the element type of the slice. The response
+// is still read whole, so only the memory of the decoded elements is spared.
+// The call fails with the error of fn, if any.
+func WithElements[T any](path string, fn func(elem T) error) Option {
+	want := reflect.TypeOf((*T)(nil)).Elem()
+	return func(opts *requestOptions) {
+		if opts.elements == nil {
+			opts.elements = make(map[string]jsonutil.ElementFunc)
+		}
+		opts.elements[path] = func(elem reflect.Value) error {
+			if elem.Type() != want {
+				return fmt.Errorf("elements of %q are of type %v, not %v", path, elem.Type(), want)
+			}
+			return fn(elem.Interface().(T))
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+)
+
+// PaginationSettings configures a Paginator.
+// Zero values are replaced by the documented defaults.
+type PaginationSettings struct {
+	// PageSize is the number of items requested per page, set as the variable
+	// named PageSizeVariable. If zero, the variables are left as they are,
+	// e.g. to let the query set the page size.
+	PageSize int
+	// PageSizeVariable is the name of the variable taking the page size.
+	// It defaults to "first".
+	PageSizeVariable string
+	// CursorVariable is the name of the variable taking the cursor after which
+	// the next page starts. It defaults to "after". Its value in the variables
+	// passed to Paginate, if any, is the cursor of the first page.
+	CursorVariable string
+	// MaxPages, if positive, is the number of pages after which pagination stops.
+	MaxPages int
+	// Stop, if set, is called once page n, counted from 1, is populated into
+	// the query. Returning true makes it the last page.
+	Stop func(n int) bool
+	// MaxRetries is the number of times a page is retried when the server
+	// rate limits it (see RetryAfter) or fails with a retryable error (see
+	// IsRetryable). It defaults to 5, and negative values disable retries.
+	MaxRetries int
+	// Backoff is the wait before retrying a page when the server doesn't tell
+	// how long to wait, doubled on every retry of the page up to a minute.
+	// It defaults to 1s.
+	Backoff time.Duration
+}
+
+// Paginator executes a query selecting a Relay-style connection page by page,
+// following the end cursor of its page info. Pages are populated into the
+// query, until the connection has no next page:
+//
+//	var q struct {
+//		Repository struct {
+//			Issues struct {
+//				Nodes    []struct{ Title graphql.String }
+//				PageInfo struct {
+//					HasNextPage graphql.Boolean
+//					EndCursor   graphql.String
+//				}
+//			} `graphql:"issues(first: $first, after: $after)"`
+//		} `graphql:"repository(owner: $owner, name: $name)"`
+//	}
+//	p := client.Paginate(&q, variables, graphql.PaginationSettings{PageSize: 100})
+//	for p.Next(ctx) {
+//		for _, issue := range q.Repository.Issues.Nodes {
+//			// ...
+//		}
+//	}
+//	if err := p.Err(); err != nil {
+//		// ...
+//	}
+//
+// The connection is the first struct found in the query with a PageInfo
+// field of HasNextPage and EndCursor fields. The GraphQL type of the cursor
+// variable is the nullable type of EndCursor, e.g. String for graphql.String.
+//
+// Fetching all the pages of a large connection is throttled by the rate
+// limiter of the client, if any. When the server rate limits a page, it's
+// retried after the delay set by its Retry-After or X-RateLimit-Reset header,
+// and when a page exhausts the rate limit, as told by X-RateLimit-Remaining,
+// the next one waits until it's reset.
+type Paginator struct {
+	c         *Client
+	q         interface{}
+	variables map[string]interface{}
+	name      string
+	settings  PaginationSettings
+	options   []Option
+
+	// pageInfo is the index sequence of the PageInfo field in the query.
+	pageInfo   []int
+	cursorType reflect.Type // The type of the cursor variable, a pointer.
+	cursor     interface{}  // The cursor of the next page, once a page was fetched.
+
+	// resumeAt is when the next page may be fetched, once the rate limit is reset.
+	resumeAt time.Time
+
+	pages int
+	done  bool
+	err   error
+}
+
+// Paginate returns a Paginator executing the query q with variables,
+// and options, page by page.
+func (c *Client) Paginate(q interface{}, variables map[string]interface{}, settings PaginationSettings, options ...Option) *Paginator {
+	return c.NamedPaginate("", q, variables, settings, options...)
+}
+
+// NamedPaginate returns a Paginator executing the query q with operation name, like Paginate.
+func (c *Client) NamedPaginate(name string, q interface{}, variables map[string]interface{}, settings PaginationSettings, options ...Option) *Paginator {
+	if settings.PageSizeVariable == "" {
+		settings.PageSizeVariable = "first"
+	}
+	if settings.CursorVariable == "" {
+		settings.CursorVariable = "after"
+	}
+	if settings.MaxRetries == 0 {
+		settings.MaxRetries = 5
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = time.Second
+	}
+	p := &Paginator{c: c, q: q, variables: variables, name: name, settings: settings, options: options}
+	t := reflect.TypeOf(q)
+	if t == nil || t.Kind() != reflect.Ptr {
+		p.err = fmt.Errorf("graphql: paginated query is a %v, not a pointer", t)
+		return p
+	}
+	index, endCursor, ok := findPageInfo(t.Elem(), make(map[reflect.Type]bool))
+	if !ok {
+		p.err = fmt.Errorf("graphql: paginated query %v has no PageInfo field with HasNextPage and EndCursor fields", t.Elem())
+		return p
+	}
+	for endCursor.Kind() == reflect.Ptr {
+		endCursor = endCursor.Elem()
+	}
+	p.pageInfo, p.cursorType = index, reflect.PtrTo(endCursor)
+	return p
+}
+
+// Next executes the query for the next page, populating it into the query.
+// It returns false when there are no more pages, or on error, reported by Err.
+func (p *Paginator) Next(ctx context.Context) bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	if p.settings.MaxPages > 0 && p.pages >= p.settings.MaxPages {
+		p.done = true
+		return false
+	}
+
+	variables := make(map[string]interface{}, len(p.variables)+2)
+	for k, v := range p.variables {
+		variables[k] = v
+	}
+	if p.settings.PageSize > 0 {
+		variables[p.settings.PageSizeVariable] = Int(p.settings.PageSize)
+	}
+	if p.pages > 0 {
+		variables[p.settings.CursorVariable] = p.cursor
+	} else if _, ok := variables[p.settings.CursorVariable]; !ok {
+		variables[p.settings.CursorVariable] = reflect.Zero(p.cursorType).Interface()
+	}
+
+	if err := sleep(ctx, time.Until(p.resumeAt)); err != nil {
+		p.err = err
+		return false
+	}
+	v := reflect.ValueOf(p.q).Elem()
+	if err := p.fetch(ctx, v, variables); err != nil {
+		p.err = err
+		return false
+	}
+	p.pages++
+
+	hasNextPage, endCursor := p.pageInfoOf(v)
+	switch {
+	case !hasNextPage, p.settings.Stop != nil && p.settings.Stop(p.pages):
+		p.done = true
+	case !endCursor.IsValid() || endCursor.IsZero():
+		p.err = fmt.Errorf("graphql: page %d has a next page but no end cursor", p.pages)
+	case p.pages > 1 && endCursor.Interface() == reflect.ValueOf(p.cursor).Elem().Interface():
+		p.err = fmt.Errorf("graphql: page %d has the end cursor of the previous page", p.pages)
+	default:
+		cursor := reflect.New(p.cursorType.Elem())
+		cursor.Elem().Set(endCursor)
+		p.cursor = cursor.Interface()
+	}
+	return true
+}
+
+// fetch executes the query v for a page with variables, retrying it
+// when the server rate limits it or fails with a retryable error.
+func (p *Paginator) fetch(ctx context.Context, v reflect.Value, variables map[string]interface{}) error {
+	backoff := p.settings.Backoff
+	for retries := 0; ; retries++ {
+		// Slices of the previous attempt or page mustn't be appended to.
+		v.Set(reflect.Zero(v.Type()))
+		resp, err := p.c.doWithResponse(ctx, QueryOperation, p.q, variables, p.name, p.options)
+		if err == nil {
+			if delay, limited := rateLimitDelay(resp.Header, time.Now()); limited {
+				p.resumeAt = time.Now().Add(delay)
+			}
+			return nil
+		}
+		delay, limited := RetryAfter(err)
+		switch {
+		case retries >= p.settings.MaxRetries || !limited && !IsRetryable(err):
+			return err
+		case delay == 0:
+			delay = backoff
+			backoff = min(2*backoff, time.Minute)
+		}
+		if sleep(ctx, delay) != nil {
+			return err
+		}
+	}
+}
+
+// Err returns the error which stopped pagination, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
+
+// Pages returns the number of pages fetched so far.
+func (p *Paginator) Pages() int {
+	return p.pages
+}
+
+// pageInfoOf returns the page info of the page populated into the query v.
+// A null connection, or null parent of it, has no next page.
+func (p *Paginator) pageInfoOf(v reflect.Value) (hasNextPage bool, endCursor reflect.Value) {
+	for _, i := range p.pageInfo {
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return false, reflect.Value{}
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return false, reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	hasNext := v.FieldByName("HasNextPage")
+	for hasNext.Kind() == reflect.Ptr {
+		if hasNext.IsNil() {
+			return false, reflect.Value{}
+		}
+		hasNext = hasNext.Elem()
+	}
+	endCursor = v.FieldByName("EndCursor")
+	for endCursor.Kind() == reflect.Ptr {
+		if endCursor.IsNil() {
+			return hasNext.Bool(), reflect.Value{}
+		}
+		endCursor = endCursor.Elem()
+	}
+	return hasNext.Bool(), endCursor
+}
+
+// findPageInfo returns the index sequence of the first PageInfo field found
+// in the struct type t, depth first, along with the type of its EndCursor field.
+func findPageInfo(t reflect.Type, seen map[reflect.Type]bool) (index []int, endCursor reflect.Type, ok bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		if t.Kind() == reflect.Slice {
+			return nil, nil, false // Connections within lists aren't paginated.
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || seen[t] {
+		return nil, nil, false
+	}
+	seen[t] = true
+	if f, ok := t.FieldByName("PageInfo"); ok {
+		if endCursor, ok := pageInfoType(f.Type); ok {
+			return f.Index, endCursor, true
+		}
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		if index, endCursor, ok := findPageInfo(f.Type, seen); ok {
+			return append([]int{i}, index...), endCursor, true
+		}
+	}
+	return nil, nil, false
+}
+
+// pageInfoType reports whether t is a page info struct, returning the type of its EndCursor field.
+func pageInfoType(t reflect.Type) (reflect.Type, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	hasNext, ok := t.FieldByName("HasNextPage")
+	if !ok || len(hasNext.Index) != 1 {
+		return nil, false
+	}
+	for hasNext.Type.Kind() == reflect.Ptr {
+		hasNext.Type = hasNext.Type.Elem()
+	}
+	endCursor, ok := t.FieldByName("EndCursor")
+	if !ok || len(endCursor.Index) != 1 || hasNext.Type.Kind() != reflect.Bool {
+		return nil, false
+	}
+	return endCursor.Type, true
+}
+
+// Pages returns an iterator over the pages of the connection selected by the
+// query type Q, executed by c with variables, as by Client.Paginate. Every page
+// is a new Q, and the next one is fetched while the current one is handled:
+//
+//	for page, err := range graphql.Pages[issuesQuery](ctx, client, variables, graphql.PaginationSettings{PageSize: 100}) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+//
+// Breaking out of the loop cancels the request of the next page.
+// An error ends the iteration.
+func Pages[Q any](ctx context.Context, c *Client, variables map[string]interface{}, settings PaginationSettings, options ...Option) iter.Seq2[*Q, error] {
+	return func(yield func(*Q, error) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var q Q
+		p := c.Paginate(&q, variables, settings, options...)
+		next := func() (*Q, bool) {
+			if !p.Next(ctx) {
+				return nil, false
+			}
+			// Pages are decoded into the zeroed query, so they don't share any memory.
+			page := new(Q)
+			*page = q
+			return page, true
+		}
+
+		page, ok := next()
+		for ok {
+			prefetched := make(chan struct{})
+			var nextPage *Q
+			var nextOK bool
+			go func() {
+				defer close(prefetched)
+				nextPage, nextOK = next()
+			}()
+			more := yield(page, nil)
+			if !more {
+				cancel()
+			}
+			<-prefetched
+			if !more {
+				return
+			}
+			page, ok = nextPage, nextOK
+		}
+		if err := p.Err(); err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// Nodes returns an iterator over the nodes of the connection selected by the
+// query type Q, as returned by nodes for each page of Pages:
+//
+//	issues := graphql.Nodes(ctx, client, variables, settings, func(q *issuesQuery) []Issue {
+//		return q.Repository.Issues.Nodes
+//	})
+//	for issue, err := range issues {
+//		// ...
+//	}
+func Nodes[Q, T any](ctx context.Context, c *Client, variables map[string]interface{}, settings PaginationSettings, nodes func(*Q) []T, options ...Option) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for page, err := range Pages[Q](ctx, c, variables, settings, options...) {
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			for _, node := range nodes(page) {
+				if !yield(node, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type issuesQuery struct {
+	Repository *struct {
+		Issues struct {
+			Nodes    []struct{ Title graphql.String }
+			PageInfo struct {
+				HasNextPage graphql.Boolean
+				EndCursor   graphql.String
+			}
+		} `graphql:"issues(first: $first, after: $after)"`
+	} `graphql:"repository(name: $name)"`
+}
+
+// issuesHandler serves pages of the issues of issuesQuery, whose cursors are the page numbers.
+func issuesHandler(t *testing.T, pages int, requests *[]map[string]interface{}) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var in struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+			t.Error(err)
+		}
+		if got, want := in.Query, "query ($after:String$first:Int!$name:String!){repository(name: $name){issues(first: $first, after: $after){nodes{title},pageInfo{hasNextPage,endCursor}}}}"; got != want {
+			t.Errorf("got query: %q, want: %q", got, want)
+		}
+		*requests = append(*requests, in.Variables)
+		page := 1
+		if after, ok := in.Variables["after"].(string); ok {
+			fmt.Sscan(after, &page)
+			page++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, fmt.Sprintf(`{"data": {"repository": {"issues": {"nodes": [{"title": "Issue %d"}], "pageInfo": {"hasNextPage": %t, "endCursor": "%d"}}}}}`, page, page < pages, page))
+	})
+	return mux
+}
+
+func TestClient_Paginate(t *testing.T) {
+	var requests []map[string]interface{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: issuesHandler(t, 3, &requests)}})
+
+	var q issuesQuery
+	p := client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 10})
+	var titles []string
+	for p.Next(context.Background()) {
+		for _, issue := range q.Repository.Issues.Nodes {
+			titles = append(titles, string(issue.Title))
+		}
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := titles, []string{"Issue 1", "Issue 2", "Issue 3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got titles: %q, want: %q", got, want)
+	}
+	if got, want := p.Pages(), 3; got != want {
+		t.Errorf("got %d pages, want: %d", got, want)
+	}
+	want := []map[string]interface{}{
+		{"name": "graphql", "first": 10.0, "after": nil},
+		{"name": "graphql", "first": 10.0, "after": "1"},
+		{"name": "graphql", "first": 10.0, "after": "2"},
+	}
+	if !reflect.DeepEqual(requests, want) {
+		t.Errorf("got variables: %v, want: %v", requests, want)
+	}
+}
+
+func TestClient_Paginate_stop(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings graphql.PaginationSettings
+		want     int
+	}{
+		{"MaxPages", graphql.PaginationSettings{PageSize: 1, MaxPages: 2}, 2},
+		{"Stop", graphql.PaginationSettings{PageSize: 1, Stop: func(n int) bool { return n == 3 }}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []map[string]interface{}
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: issuesHandler(t, 5, &requests)}})
+
+			var q issuesQuery
+			p := client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, tt.settings)
+			for p.Next(context.Background()) {
+			}
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got := len(requests); got != tt.want {
+				t.Errorf("got %d requests, want: %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_Paginate_errors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": {"issues": {"nodes": [], "pageInfo": {"hasNextPage": true, "endCursor": "1"}}}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q issuesQuery
+	p := client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 1})
+	for p.Next(context.Background()) {
+	}
+	if got, want := fmt.Sprint(p.Err()), "graphql: page 2 has the end cursor of the previous page"; got != want {
+		t.Errorf("got error: %v, want: %v", got, want)
+	}
+
+	var noConnection struct {
+		User struct{ Name graphql.String }
+	}
+	p = client.Paginate(&noConnection, nil, graphql.PaginationSettings{})
+	if p.Next(context.Background()) {
+		t.Error("got a page, want none")
+	}
+	if err := p.Err(); err == nil || !strings.Contains(err.Error(), "has no PageInfo field") {
+		t.Errorf("got error: %v, want no PageInfo field", err)
+	}
+}
+
+func TestClient_Paginate_nullConnection(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"repository": null}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q issuesQuery
+	p := client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 1})
+	if !p.Next(context.Background()) {
+		t.Fatalf("got no page, error: %v", p.Err())
+	}
+	if p.Next(context.Background()) {
+		t.Error("got a second page, want none")
+	}
+	if err := p.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClient_Paginate_rateLimited(t *testing.T) {
+	var requests []map[string]interface{}
+	pages := issuesHandler(t, 2, &requests)
+	limited := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if limited < 2 {
+			limited++
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		pages.ServeHTTP(w, req)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q issuesQuery
+	p := client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 1, Backoff: time.Millisecond})
+	for p.Next(context.Background()) {
+	}
+	if err := p.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(requests), 2; got != want {
+		t.Errorf("got %d pages, want: %d", got, want)
+	}
+
+	// Without retries, the page fails.
+	limited = 0
+	p = client.Paginate(&q, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 1, MaxRetries: -1})
+	if p.Next(context.Background()) {
+		t.Fatal("got a page, want an error")
+	}
+	if _, ok := graphql.RetryAfter(p.Err()); !ok {
+		t.Errorf("got error: %v, want a rate limit error", p.Err())
+	}
+}
+
+func TestPages(t *testing.T) {
+	var requests []map[string]interface{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: issuesHandler(t, 3, &requests)}})
+
+	var titles []string
+	variables := map[string]interface{}{"name": graphql.String("graphql")}
+	for page, err := range graphql.Pages[issuesQuery](context.Background(), client, variables, graphql.PaginationSettings{PageSize: 10}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, issue := range page.Repository.Issues.Nodes {
+			titles = append(titles, string(issue.Title))
+		}
+	}
+	if got, want := titles, []string{"Issue 1", "Issue 2", "Issue 3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got titles: %q, want: %q", got, want)
+	}
+}
+
+func TestNodes(t *testing.T) {
+	var requests []map[string]interface{}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: issuesHandler(t, 3, &requests)}})
+
+	type issue = struct{ Title graphql.String }
+	issues := graphql.Nodes(context.Background(), client, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 10}, func(q *issuesQuery) []issue {
+		return q.Repository.Issues.Nodes
+	})
+	var titles []string
+	for issue, err := range issues {
+		if err != nil {
+			t.Fatal(err)
+		}
+		titles = append(titles, string(issue.Title))
+	}
+	if got, want := titles, []string{"Issue 1", "Issue 2", "Issue 3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got titles: %q, want: %q", got, want)
+	}
+}
+
+func TestPages_break(t *testing.T) {
+	var requests []map[string]interface{}
+	pages := issuesHandler(t, 3, &requests)
+	prefetching, canceled := make(chan struct{}), make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if len(requests) == 1 {
+			// Hold the prefetched second page until the loop is broken.
+			close(prefetching)
+			<-req.Context().Done()
+			close(canceled)
+			return
+		}
+		pages.ServeHTTP(w, req)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	n := 0
+	for _, err := range graphql.Pages[issuesQuery](context.Background(), client, map[string]interface{}{"name": graphql.String("graphql")}, graphql.PaginationSettings{PageSize: 1}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		<-prefetching
+		break
+	}
+	if n != 1 {
+		t.Errorf("got %d pages, want: 1", n)
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Error("the request of the next page wasn't canceled")
+	}
+}
+
+func TestPages_error(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var errs []error
+	for page, err := range graphql.Pages[issuesQuery](context.Background(), client, nil, graphql.PaginationSettings{}) {
+		if page != nil {
+			t.Errorf("got page: %v, want none", page)
+		}
+		errs = append(errs, err)
+	}
+	var netErr *graphql.NetworkError
+	if len(errs) != 1 || !errors.As(errs[0], &netErr) {
+		t.Errorf("got errors: %v, want a single *graphql.NetworkError", errs)
+	}
+}
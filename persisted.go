@@ -0,0 +1,155 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrNotPersisted is returned when a client using a PersistedManifest executes
+// an operation whose document isn't in the manifest.
+var ErrNotPersisted = errors.New("graphql: operation isn't in the persisted manifest")
+
+// PersistedManifest maps documents of operations to the IDs they're
+// allow-listed with on the GraphQL server. Its JSON encoding is an object
+// of documents keyed by their IDs, as consumed by most servers.
+//
+// Manifests are generated at build time by registering the query structs
+// of the application, and loaded at run time with LoadPersistedManifest.
+type PersistedManifest struct {
+	tagKey string
+
+	mu   sync.RWMutex
+	docs map[string]string // ID to document.
+	ids  map[string]string // Document to ID.
+}
+
+// NewPersistedManifest returns an empty manifest.
+func NewPersistedManifest() *PersistedManifest {
+	return &PersistedManifest{
+		tagKey: DefaultTagKey,
+		docs:   make(map[string]string),
+		ids:    make(map[string]string),
+	}
+}
+
+// LoadPersistedManifest reads a manifest in the JSON format written by PersistedManifest.WriteTo.
+func LoadPersistedManifest(r io.Reader) (*PersistedManifest, error) {
+	m := NewPersistedManifest()
+	var docs map[string]string
+	if err := json.NewDecoder(r).Decode(&docs); err != nil {
+		return nil, err
+	}
+	for id, doc := range docs {
+		m.Add(id, doc)
+	}
+	return m, nil
+}
+
+// WithTagKey sets the struct tag key used to construct the documents
+// of registered operations. It must match the key of the Client.
+func (m *PersistedManifest) WithTagKey(key string) *PersistedManifest {
+	m.tagKey = key
+	return m
+}
+
+// Add adds document with id to the manifest.
+func (m *PersistedManifest) Add(id, document string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.docs[id] = document
+	m.ids[document] = id
+}
+
+// AddQuery registers the query derived from q with the given operation name and variables,
+// in the same form Client.NamedQuery sends it, and returns its ID.
+// Only the types of the variables matter, not their values. They must include
+// the variables the client adds, such as the default variables of
+// Client.WithDefaultVariables the selection refers to, which the document declares.
+// Alternatively, Add the document returned by Client.Plan.
+// It panics if q selects itself, as its document would be infinite.
+func (m *PersistedManifest) AddQuery(name string, q interface{}, variables map[string]interface{}) string {
+	return m.add(constructQuery(q, variables, name, m.tagKey))
+}
+
+// AddMutation registers the mutation derived from v like AddQuery.
+func (m *PersistedManifest) AddMutation(name string, v interface{}, variables map[string]interface{}) string {
+	return m.add(constructMutation(v, variables, name, m.tagKey))
+}
+
+func (m *PersistedManifest) add(document string, err error) string {
+	if err != nil {
+		panic(err)
+	}
+	id := DocumentID(document)
+	m.Add(id, document)
+	return id
+}
+
+// ID returns the ID of document, and whether it's in the manifest.
+func (m *PersistedManifest) ID(document string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.ids[document]
+	return id, ok
+}
+
+// Document returns the document with id, and whether it's in the manifest.
+func (m *PersistedManifest) Document(id string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	doc, ok := m.docs[id]
+	return doc, ok
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *PersistedManifest) MarshalJSON() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return json.Marshal(m.docs)
+}
+
+// WriteTo writes the manifest to w as indented JSON, with sorted IDs.
+func (m *PersistedManifest) WriteTo(w io.Writer) (int64, error) {
+	m.mu.RLock()
+	b, err := json.MarshalIndent(m.docs, "", "  ")
+	m.mu.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+// DocumentID returns the ID of document in a PersistedManifest,
+// the hex-encoded SHA-256 hash of the document, as servers hashing the documents
+// they receive compute it. HashDocument hashes its canonical form instead.
+func DocumentID(document string) string {
+	sum := sha256.Sum256([]byte(document))
+	return hex.EncodeToString(sum[:])
+}
+
+// WithPersistedManifest makes the client send only the IDs of operations
+// in manifest, along with their variables, instead of the documents,
+// for servers accepting only allow-listed persisted operations.
+// Operations missing from the manifest fail with ErrNotPersisted.
+func (c *Client) WithPersistedManifest(manifest *PersistedManifest) *Client {
+	c.manifest = manifest
+	return c
+}
+
+// WithPersistedOnly makes the client refuse to send operations whose documents
+// aren't in manifest, failing them with ErrNotPersisted, like servers enforcing
+// an allow-list of persisted operations do, so that ad-hoc queries of production
+// binaries fail before reaching the server. Unlike with WithPersistedManifest,
+// the documents are sent as usual.
+//
+// Operations are checked as they're sent, batched or over the websocket of
+// WithWebSocketTransport alike, with the documents left by the middleware.
+func (c *Client) WithPersistedOnly(manifest *PersistedManifest) *Client {
+	c.allowList = manifest
+	return c
+}
@@ -0,0 +1,142 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestPersistedManifest(t *testing.T) {
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	m := graphql.NewPersistedManifest()
+	id := m.AddQuery("GetUser", &q, map[string]interface{}{"id": graphql.Int(0)})
+
+	const doc = `query GetUser($id:Int!){user(id: $id){name}}`
+	if got, want := id, graphql.DocumentID(doc); got != want {
+		t.Errorf("got id: %q, want: %q", got, want)
+	}
+	if got, ok := m.Document(id); !ok || got != doc {
+		t.Errorf("got document: %q, %v, want: %q, true", got, ok, doc)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := graphql.LoadPersistedManifest(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := loaded.ID(doc); !ok || got != id {
+		t.Errorf("got loaded id: %q, %v, want: %q, true", got, ok, id)
+	}
+}
+
+func TestClient_Query_persistedManifest(t *testing.T) {
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	variables := map[string]interface{}{"id": graphql.Int(1)}
+	m := graphql.NewPersistedManifest()
+	id := m.AddQuery("GetUser", &q, variables)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body := mustRead(req.Body)
+		if got, want := body, `{"id":"`+id+`","variables":{"id":1}}`+"\n"; got != want {
+			t.Errorf("got body: %v, want %v", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithPersistedManifest(m)
+
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+
+	err := client.Query(context.Background(), &q, variables)
+	if !errors.Is(err, graphql.ErrNotPersisted) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrNotPersisted)
+	}
+}
+
+func TestClient_WithPersistedOnly(t *testing.T) {
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(id: $id)"`
+	}
+	variables := map[string]interface{}{"id": graphql.Int(1)}
+	m := graphql.NewPersistedManifest()
+	m.AddQuery("GetUser", &q, variables)
+
+	var queries []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		// Single operations, or batches of them.
+		var in []struct{ Query string }
+		body := mustRead(req.Body)
+		if err := json.Unmarshal([]byte(body), &in); err != nil {
+			in = make([]struct{ Query string }, 1)
+			if err := json.Unmarshal([]byte(body), &in[0]); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		var responses []string
+		for _, op := range in {
+			queries = append(queries, op.Query)
+			responses = append(responses, `{"data": {"user": {"name": "Gopher"}}}`)
+		}
+		if len(in) == 1 && !strings.HasPrefix(body, "[") {
+			mustWrite(w, responses[0])
+			return
+		}
+		mustWrite(w, "["+strings.Join(responses, ",")+"]")
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithPersistedOnly(m)
+
+	if err := client.NamedQuery(context.Background(), "GetUser", &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	err := client.Query(context.Background(), &q, variables)
+	if !errors.Is(err, graphql.ErrNotPersisted) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrNotPersisted)
+	}
+
+	b := client.NewBatch()
+	persisted := b.NamedQuery("GetUser", &q, variables)
+	adHoc := b.Query(&q, variables)
+	if err := b.Exec(context.Background()); !errors.Is(err, graphql.ErrNotPersisted) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrNotPersisted)
+	}
+	if err := persisted.Err(); err != nil {
+		t.Error(err)
+	}
+	if err := adHoc.Err(); !errors.Is(err, graphql.ErrNotPersisted) {
+		t.Errorf("got error of the ad-hoc query: %v, want: %v", err, graphql.ErrNotPersisted)
+	}
+
+	// Only the persisted documents were sent, in full.
+	want := []string{"query GetUser($id:Int!){user(id: $id){name}}", "query GetUser($id:Int!){user(id: $id){name}}"}
+	if !reflect.DeepEqual(queries, want) {
+		t.Errorf("got queries: %q, want: %q", queries, want)
+	}
+}
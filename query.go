@@ -1,148 +1,359 @@
 package graphql
 
 import (
-	"bytes"
-	"encoding/json"
-	"github.com/runtimeracer/go-graphql-client/ident"
-	"io"
+	"context"
+	"fmt"
 	"reflect"
-	"sort"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/runtimeracer/go-graphql-client/internal/typeinfo"
 )
 
-func constructQuery(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "query " + name + "(" + queryArguments(variables) + ")" + query
+// DefaultTagKey is the struct tag key holding GraphQL field selections,
+// e.g. `graphql:"user(id: $id)"`. Clients created afterwards use it unless
+// configured otherwise; change it during program initialization to use another key
+// across the whole program, e.g. where "graphql" tags are already used by a server library.
+var DefaultTagKey = typeinfo.DefaultTagKey
+
+// constructOperation constructs the document of a GraphQL operation of type typ derived from v.
+func constructOperation(typ OperationType, v interface{}, variables map[string]interface{}, name string, tagKey string) (string, error) {
+	switch typ {
+	case MutationOperation:
+		return constructMutation(v, variables, name, tagKey)
+	case SubscriptionOperation:
+		return constructSubscription(v, variables, name, tagKey)
+	default:
+		return constructQuery(v, variables, name, tagKey)
 	}
+}
+
+// Plan constructs the document of the query derived from q, as Query does, without
+// sending it, for preflight validation, manifest generation, and debugging. It returns
+// the document and the GraphQL types of the variables, e.g. "Int!", by name, including
+// those of the default variables of the client and of its variable transformers,
+// which are run with a background context.
+func (c *Client) Plan(q interface{}, variables map[string]interface{}) (document string, varTypes map[string]string, err error) {
+	return c.plan(QueryOperation, q, variables)
+}
+
+// PlanMutation constructs the document of the mutation derived from m without sending it, as Plan does.
+func (c *Client) PlanMutation(m interface{}, variables map[string]interface{}) (document string, varTypes map[string]string, err error) {
+	return c.plan(MutationOperation, m, variables)
+}
 
-	if name != "" {
-		return "query " + name + query
+func (c *Client) plan(typ OperationType, v interface{}, variables map[string]interface{}) (string, map[string]string, error) {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("%s must be a pointer to struct, got %T", typ, v)
+	}
+	if q, err := query(v, c.decodeOpts.TagKey); err != nil {
+		return "", nil, err
+	} else if q == "{}" {
+		return "", nil, fmt.Errorf("%T selects no fields", v)
+	}
+	variables, err := c.prepareVariables(context.Background(), typ, v, variables)
+	if err != nil {
+		return "", nil, err
+	}
+	varTypes := make(map[string]string, len(variables))
+	for name, value := range variables {
+		if value == nil {
+			return "", nil, fmt.Errorf("variable %q is nil, so its type is unknown", name)
+		}
+		var b strings.Builder
+		writeArgumentType(&b, reflect.TypeOf(value), true)
+		if varType := strings.Trim(b.String(), "[]!"); varType == "" {
+			return "", nil, fmt.Errorf("variable %q has the unnamed type %T", name, value)
+		}
+		varTypes[name] = b.String()
 	}
-	return query
+	name := ""
+	if c.deriveNames {
+		name = derivedOperationName(typ, v)
+	}
+	document, err := constructOperation(typ, v, variables, name, c.decodeOpts.TagKey)
+	return document, varTypes, err
 }
 
-func constructMutation(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "mutation " + name + "(" + queryArguments(variables) + ")" + query
+// derivedOperationName returns the operation name derived from the type of v, e.g.
+// "GetUser" for a *GetUserQuery, or "" if v is of an unnamed type, such as an anonymous struct.
+// The suffix naming the operation type, "Query", "Mutation" or "Subscription", is dropped.
+func derivedOperationName(typ OperationType, v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
 	}
-	if name != "" {
-		return "mutation " + name + query
+	if t == nil {
+		return ""
 	}
-	return "mutation" + query
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i] // Type arguments of a generic type.
+	}
+	suffix := strings.ToUpper(typ.String()[:1]) + typ.String()[1:]
+	if trimmed := strings.TrimSuffix(name, suffix); trimmed != "" {
+		name = trimmed
+	}
+	if !isName(name) {
+		return ""
+	}
+	return name
 }
 
-func constructSubscription(v interface{}, variables map[string]interface{}, name string) string {
-	query := query(v)
-	if len(variables) > 0 {
-		return "subscription " + name + "(" + queryArguments(variables) + ")" + query
+// isName reports whether s is a valid GraphQL name.
+func isName(s string) bool {
+	for i, r := range s {
+		switch {
+		case r == '_', 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z':
+		case '0' <= r && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+func constructQuery(v interface{}, variables map[string]interface{}, name string, tagKey string) (string, error) {
+	return constructDocument("query", v, variables, name, "", tagKey)
+}
+
+func constructMutation(v interface{}, variables map[string]interface{}, name string, tagKey string) (string, error) {
+	return constructDocument("mutation", v, variables, name, "", tagKey)
+}
+
+func constructSubscription(v interface{}, variables map[string]interface{}, name string, tagKey string) (string, error) {
+	return constructDocument("subscription", v, variables, name, "", tagKey)
+}
+
+// constructLiveQuery constructs a query with the @live directive.
+func constructLiveQuery(v interface{}, variables map[string]interface{}, name string, tagKey string) (string, error) {
+	return constructDocument("query", v, variables, name, "@live", tagKey)
+}
+
+// constructDocument constructs the document of an operation of type keyword,
+// e.g. "query", derived from v, with the directives of the operation, if any.
+// It's written at once into a builder of its final size.
+//
+// A query without variables, name and directives is written in the shorthand form.
+func constructDocument(keyword string, v interface{}, variables map[string]interface{}, name, directives, tagKey string) (string, error) {
+	selection, err := query(v, tagKey)
+	if err != nil {
+		return "", err
+	}
+	if keyword == "query" && len(variables) == 0 && name == "" && directives == "" {
+		return selection, nil
 	}
-	if name != "" {
-		return "subscription " + name + query
+	var b strings.Builder
+	b.Grow(len(keyword) + 1 + len(name) + argumentsSize(variables) + len(directives) + len(selection))
+	b.WriteString(keyword)
+	if len(variables) > 0 || name != "" || keyword == "query" {
+		b.WriteByte(' ')
+	}
+	b.WriteString(name)
+	if len(variables) > 0 {
+		b.WriteByte('(')
+		writeArguments(&b, variables)
+		b.WriteByte(')')
 	}
-	return "subscription" + query
+	b.WriteString(directives)
+	b.WriteString(selection)
+	return b.String(), nil
 }
 
 // queryArguments constructs a minified arguments string for variables.
 //
 // E.g., map[string]interface{}{"a": Int(123), "b": NewBoolean(true)} -> "$a:Int!$b:Boolean".
 func queryArguments(variables map[string]interface{}) string {
+	var b strings.Builder
+	b.Grow(argumentsSize(variables))
+	writeArguments(&b, variables)
+	return b.String()
+}
+
+// argumentsSize estimates the length of the arguments written by writeArguments,
+// assuming short type names.
+func argumentsSize(variables map[string]interface{}) int {
+	n := 0
+	if len(variables) > 0 {
+		n += 2 // Parentheses.
+	}
+	for k := range variables {
+		n += len(k) + len("$:String!")
+	}
+	return n
+}
+
+// writeArguments writes the minified arguments of variables to b, as returned by queryArguments.
+func writeArguments(b *strings.Builder, variables map[string]interface{}) {
 	// Sort keys in order to produce deterministic output for testing purposes.
 	// TODO: If tests can be made to work with non-deterministic output, then no need to sort.
-	keys := make([]string, 0, len(variables))
+	var array [16]string // Spares allocating the keys of most operations.
+	keys := array[:0]
 	for k := range variables {
 		keys = append(keys, k)
 	}
-	sort.Strings(keys)
+	slices.Sort(keys)
 
-	var buf bytes.Buffer
 	for _, k := range keys {
-		io.WriteString(&buf, "$")
-		io.WriteString(&buf, k)
-		io.WriteString(&buf, ":")
-		writeArgumentType(&buf, reflect.TypeOf(variables[k]), true)
+		b.WriteByte('$')
+		b.WriteString(k)
+		b.WriteByte(':')
+		writeArgumentType(b, reflect.TypeOf(variables[k]), true)
 		// Don't insert a comma here.
 		// Commas in GraphQL are insignificant, and we want minified output.
 		// See https://facebook.github.io/graphql/October2016/#sec-Insignificant-Commas.
 	}
-	return buf.String()
 }
 
-// writeArgumentType writes a minified GraphQL type for t to w.
+// writeArgumentType writes a minified GraphQL type for t to b.
 // value indicates whether t is a value (required) type or pointer (optional) type.
 // If value is true, then "!" is written at the end of t.
-func writeArgumentType(w io.Writer, t reflect.Type, value bool) {
+func writeArgumentType(b *strings.Builder, t reflect.Type, value bool) {
 	if t.Kind() == reflect.Ptr {
 		// Pointer is an optional type, so no "!" at the end of the pointer's underlying type.
-		writeArgumentType(w, t.Elem(), false)
+		writeArgumentType(b, t.Elem(), false)
 		return
 	}
 
 	switch t.Kind() {
 	case reflect.Slice, reflect.Array:
 		// List. E.g., "[Int]".
-		io.WriteString(w, "[")
-		writeArgumentType(w, t.Elem(), true)
-		io.WriteString(w, "]")
+		b.WriteByte('[')
+		writeArgumentType(b, t.Elem(), true)
+		b.WriteByte(']')
 	default:
 		// Named type. E.g., "Int".
 		name := t.Name()
 		if name == "string" { // HACK: Workaround for https://github.com/shurcooL/githubv4/issues/12.
 			name = "ID"
 		}
-		io.WriteString(w, name)
+		b.WriteString(name)
 	}
 
 	if value {
 		// Value is a required type, so add "!" to the end.
-		io.WriteString(w, "!")
+		b.WriteByte('!')
 	}
 }
 
+// queryCacheKey identifies the selection set of a query struct type read with a given tag key.
+type queryCacheKey struct {
+	t      reflect.Type
+	tagKey string
+}
+
+// querySelection is the selection set of a query struct type, or the error constructing it.
+type querySelection struct {
+	query string
+	err   error
+}
+
+var queryCache sync.Map // map[queryCacheKey]querySelection
+
 // query uses writeQuery to recursively construct
-// a minified query string from the provided struct v.
+// a minified query string from the provided struct v,
+// reading field selections from struct tags with key tagKey.
+// The result is cached per type, so that executing the same query
+// struct again skips reflection.
 //
 // E.g., struct{Foo Int, BarBaz *Boolean} -> "{foo,barBaz}".
-func query(v interface{}) string {
-	var buf bytes.Buffer
-	writeQuery(&buf, reflect.TypeOf(v), false)
-	return buf.String()
+func query(v interface{}, tagKey string) (string, error) {
+	key := queryCacheKey{t: reflect.TypeOf(v), tagKey: tagKey}
+	if q, ok := queryCache.Load(key); ok {
+		return q.(querySelection).query, q.(querySelection).err
+	}
+	var b strings.Builder
+	err := writeQuery(&b, key.t, false, tagKey)
+	q, _ := queryCache.LoadOrStore(key, querySelection{query: b.String(), err: err})
+	return q.(querySelection).query, q.(querySelection).err
 }
 
-// writeQuery writes a minified query for t to w.
+// writeQuery writes a minified query for t to b.
 // If inline is true, the struct fields of t are inlined into parent struct.
-func writeQuery(w io.Writer, t reflect.Type, inline bool) {
+// It fails if t is recursive, as its selection set would be infinite.
+func writeQuery(b *strings.Builder, t reflect.Type, inline bool, tagKey string) error {
+	return writeSelection(b, t, inline, tagKey, nil)
+}
+
+// writeSelection writes the selection of t like writeQuery,
+// within the selections of the struct types of parents.
+func writeSelection(b *strings.Builder, t reflect.Type, inline bool, tagKey string, parents []reflect.Type) error {
 	switch t.Kind() {
-	case reflect.Ptr, reflect.Slice:
-		writeQuery(w, t.Elem(), false)
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return writeSelection(b, t.Elem(), false, tagKey, parents)
 	case reflect.Struct:
+		info := typeinfo.Of(t, tagKey)
 		// If the type implements json.Unmarshaler, it's a scalar. Don't expand it.
-		if reflect.PtrTo(t).Implements(jsonUnmarshaler) {
-			return
+		if info.Scalar {
+			return nil
 		}
+		for _, parent := range parents {
+			if parent == t {
+				return fmt.Errorf("graphql: %v selects itself, so its selection set is infinite", t)
+			}
+		}
+		parents = append(parents, t)
 		if !inline {
-			io.WriteString(w, "{")
+			b.WriteByte('{')
 		}
-		for i := 0; i < t.NumField(); i++ {
+		for i := range info.Fields {
 			if i != 0 {
-				io.WriteString(w, ",")
+				b.WriteByte(',')
 			}
-			f := t.Field(i)
-			value, ok := f.Tag.Lookup("graphql")
-			inlineField := f.Anonymous && !ok
-			if !inlineField {
-				if ok {
-					io.WriteString(w, value)
-				} else {
-					io.WriteString(w, ident.ParseMixedCaps(f.Name).ToLowerCamelCase())
-				}
+			f := &info.Fields[i]
+			if !f.Inline {
+				b.WriteString(f.Selection)
+			}
+			if err := writeSelection(b, f.Type, f.Inline, tagKey, parents); err != nil {
+				return err
 			}
-			writeQuery(w, f.Type, inlineField)
 		}
 		if !inline {
-			io.WriteString(w, "}")
+			b.WriteByte('}')
 		}
 	}
+	return nil
+}
+
+// checkDocument checks document against the limits set by WithDocumentLimits.
+func (c *Client) checkDocument(document string) error {
+	if c.maxDocumentDepth <= 0 && c.maxDocumentSize <= 0 {
+		return nil
+	}
+	err := &DocumentTooLargeError{
+		Depth:    documentDepth(document),
+		MaxDepth: c.maxDocumentDepth,
+		Size:     len(document),
+		MaxSize:  c.maxDocumentSize,
+	}
+	if (err.MaxDepth > 0 && err.Depth > err.MaxDepth) || (err.MaxSize > 0 && err.Size > err.MaxSize) {
+		return err
+	}
+	return nil
 }
 
-var jsonUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+// documentDepth returns the maximum nesting of the selection sets of document,
+// skipping the braces of string values.
+func documentDepth(document string) int {
+	depth, max := 0, 0
+	inString := false
+	for i := 0; i < len(document); i++ {
+		switch c := document[i]; {
+		case inString && c == '\\':
+			i++ // Skip the escaped character.
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+			if depth > max {
+				max = depth
+			}
+		case c == '}':
+			depth--
+		}
+	}
+	return max
+}
@@ -2,6 +2,8 @@ package graphql
 
 import (
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -30,6 +32,19 @@ func TestConstructQuery(t *testing.T) {
 			}{},
 			want: `{viewer{login,createdAt,id,databaseId},rateLimit{cost,limit,remaining,resetAt}}`,
 		},
+		{
+			inV: struct {
+				Viewer struct {
+					Followers *[]struct {
+						Login String
+					}
+					PinnedItems [2]struct {
+						Name String
+					}
+				}
+			}{},
+			want: `{viewer{followers{login},pinnedItems{name}}}`,
+		},
 		{
 			name: "GetRepository",
 			inV: struct {
@@ -230,9 +245,21 @@ func TestConstructQuery(t *testing.T) {
 			}{},
 			want: `{viewer{login,createdAt,id,databaseId}}`,
 		},
+		{
+			inV: struct {
+				Viewer struct {
+					Typename string
+					Login    String
+				}
+			}{},
+			want: `{viewer{__typename,login}}`,
+		},
 	}
 	for _, tc := range tests {
-		got := constructQuery(tc.inV, tc.inVariables, tc.name)
+		got, err := constructQuery(tc.inV, tc.inVariables, tc.name, DefaultTagKey)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
@@ -263,11 +290,14 @@ func TestConstructMutation(t *testing.T) {
 					Content:   ReactionContentThumbsUp,
 				},
 			},
-			want: `mutation($input:AddReactionInput!){addReaction(input:$input){subject{reactionGroups{users{totalCount}}}}}`,
+			want: `mutation ($input:AddReactionInput!){addReaction(input:$input){subject{reactionGroups{users{totalCount}}}}}`,
 		},
 	}
 	for _, tc := range tests {
-		got := constructMutation(tc.inV, tc.inVariables, "")
+		got, err := constructMutation(tc.inV, tc.inVariables, "", DefaultTagKey)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
@@ -500,7 +530,98 @@ func TestConstructSubscription(t *testing.T) {
 		},
 	}
 	for _, tc := range tests {
-		got := constructSubscription(tc.inV, tc.inVariables, tc.name)
+		got, err := constructSubscription(tc.inV, tc.inVariables, tc.name, DefaultTagKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
+		}
+	}
+}
+
+func TestQuery_recursive(t *testing.T) {
+	type category struct {
+		Name     String
+		Children []category
+	}
+	type tree struct {
+		Name   String
+		Parent *struct {
+			Name  String
+			Trees []tree
+		}
+	}
+	for _, v := range []interface{}{
+		struct{ Root category }{},
+		struct{ Root tree }{},
+	} {
+		if _, err := query(v, DefaultTagKey); err == nil || !strings.Contains(err.Error(), "selects itself") {
+			t.Errorf("got error: %v for %T, want a recursive selection error", err, v)
+		}
+		if _, err := constructQuery(v, nil, "Tree", DefaultTagKey); err == nil {
+			t.Errorf("got no error constructing %T, want a recursive selection error", v)
+		}
+	}
+
+	// Types selected in several places aren't recursive.
+	var q struct {
+		Viewer, User struct {
+			Avatar struct{ URL String }
+		}
+	}
+	if got, err := query(q, DefaultTagKey); err != nil || got != "{viewer{avatar{url}},user{avatar{url}}}" {
+		t.Errorf("got query: %q, error: %v", got, err)
+	}
+}
+
+func TestDocumentDepth(t *testing.T) {
+	for _, tc := range []struct {
+		document string
+		want     int
+	}{
+		{"", 0},
+		{"{user{name}}", 2},
+		{"query ($id:ID!){node(id: $id){__typename,... on User{name,friends{name}}}}", 4},
+		{`{search(text: "{{\"{"){count}}`, 2},
+	} {
+		if got := documentDepth(tc.document); got != tc.want {
+			t.Errorf("got depth of %q: %d, want: %d", tc.document, got, tc.want)
+		}
+	}
+}
+
+func TestConstructLiveQuery(t *testing.T) {
+	tests := []struct {
+		inV         interface{}
+		inVariables map[string]interface{}
+		name        string
+		want        string
+	}{
+		{
+			inV: struct {
+				Viewer struct {
+					Login String
+				}
+			}{},
+			want: `query @live{viewer{login}}`,
+		},
+		{
+			inV: struct {
+				User struct {
+					Name String
+				} `graphql:"user(login: $login)"`
+			}{},
+			inVariables: map[string]interface{}{"login": String("gopher")},
+			name:        "GetUser",
+			want:        `query GetUser($login:String!)@live{user(login: $login){name}}`,
+		},
+	}
+	for _, tc := range tests {
+		got, err := constructLiveQuery(tc.inV, tc.inVariables, tc.name, DefaultTagKey)
+		if err != nil {
+			t.Fatal(err)
+		}
 		if got != tc.want {
 			t.Errorf("\ngot:  %q\nwant: %q\n", got, tc.want)
 		}
@@ -601,3 +722,139 @@ type AddReactionInput struct {
 	// A unique identifier for the client performing the mutation. (Optional.)
 	ClientMutationID *String `json:"clientMutationId,omitempty"`
 }
+
+func TestClient_Plan(t *testing.T) {
+	client := NewClient("/graphql", nil)
+	var q struct {
+		User struct {
+			Name String
+		} `graphql:"user(id: $id, first: $first)"`
+	}
+	document, varTypes, err := client.Plan(&q, map[string]interface{}{
+		"id":    ID("1"),
+		"first": (*Int)(nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := document, "query ($first:Int$id:ID!){user(id: $id, first: $first){name}}"; got != want {
+		t.Errorf("got document: %q, want: %q", got, want)
+	}
+	if got, want := varTypes, map[string]string{"id": "ID!", "first": "Int"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got variable types: %v, want: %v", got, want)
+	}
+
+	var m struct {
+		AddStar struct {
+			ClientMutationID String
+		} `graphql:"addStar(input: $input)"`
+	}
+	document, _, err = client.PlanMutation(&m, map[string]interface{}{"input": []String{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := document, "mutation ($input:[String!]!){addStar(input: $input){clientMutationId}}"; got != want {
+		t.Errorf("got document: %q, want: %q", got, want)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		q         interface{}
+		variables map[string]interface{}
+		want      string
+	}{
+		{"not a pointer", "{user{name}}", nil, "query must be a pointer to struct, got string"},
+		{"no fields", &struct{}{}, nil, "*struct {} selects no fields"},
+		{"nil variable", &q, map[string]interface{}{"id": nil}, `variable "id" is nil, so its type is unknown`},
+		{"unnamed variable type", &q, map[string]interface{}{"id": map[string]interface{}{}}, `variable "id" has the unnamed type map[string]interface {}`},
+	} {
+		if _, _, err := client.Plan(tc.q, tc.variables); err == nil || err.Error() != tc.want {
+			t.Errorf("%s: got error: %v, want: %s", tc.name, err, tc.want)
+		}
+	}
+}
+
+// benchmarkQuery is a representative nested query struct, with arguments,
+// lists, and a named type selected in several places.
+type benchmarkQuery struct {
+	Repository struct {
+		Issues struct {
+			Nodes []struct {
+				Number Int
+				Title  String
+				Author struct {
+					Login String
+				}
+				Labels struct {
+					Nodes []struct {
+						Name  String
+						Color String
+					}
+				} `graphql:"labels(first: 10)"`
+			}
+			PageInfo PageInfo
+		} `graphql:"issues(first: $first, after: $after)"`
+		PullRequests struct {
+			Nodes []struct {
+				Number    Int
+				Mergeable String
+				Author    struct {
+					Login String
+				}
+			}
+			PageInfo PageInfo
+		} `graphql:"pullRequests(first: $first, states: $states)"`
+	} `graphql:"repository(owner: $owner, name: $name)"`
+}
+
+var benchmarkVariables = map[string]interface{}{
+	"owner":  String("runtimeracer"),
+	"name":   String("go-graphql-client"),
+	"first":  Int(100),
+	"after":  (*String)(nil),
+	"states": []String{"OPEN"},
+}
+
+func BenchmarkConstructQuery(b *testing.B) {
+	var q benchmarkQuery
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		constructQuery(q, benchmarkVariables, "GetIssues", DefaultTagKey)
+	}
+}
+
+func BenchmarkConstructMutation(b *testing.B) {
+	type AddCommentInput struct {
+		SubjectID ID
+		Body      String
+	}
+	var m struct {
+		AddComment struct {
+			Subject struct {
+				ID ID
+			}
+		} `graphql:"addComment(input: $input)"`
+	}
+	variables := map[string]interface{}{"input": AddCommentInput{}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		constructMutation(m, variables, "", DefaultTagKey)
+	}
+}
+
+// BenchmarkWriteQuery measures the selection set construction skipped by the cache of query.
+func BenchmarkWriteQuery(b *testing.B) {
+	t := reflect.TypeOf(benchmarkQuery{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var sb strings.Builder
+		writeQuery(&sb, t, false, DefaultTagKey)
+	}
+}
+
+func BenchmarkQueryArguments(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		queryArguments(benchmarkVariables)
+	}
+}
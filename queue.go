@@ -0,0 +1,337 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrMutationQueued is returned by MutationQueue for the mutations it queued
+// instead of sending them, whose results aren't populated.
+var ErrMutationQueued = errors.New("graphql: mutation queued")
+
+// QueueSettings configures a MutationQueue. Zero values are replaced by the documented defaults.
+type QueueSettings struct {
+	// IsOffline reports whether a mutation failed with err for lack of
+	// connectivity, and is to be queued. It defaults to reporting whether err
+	// is a *NetworkError without status code, as the server couldn't be reached,
+	// rather than one of an error response, which it may send after executing
+	// the mutation.
+	IsOffline func(err error) bool
+	// OnConflict is called when a replayed mutation fails otherwise, e.g. with
+	// GraphQL errors as the data it changes changed meanwhile. If it returns nil,
+	// the mutation is dropped and the replay goes on, and otherwise the replay
+	// stops with its error, keeping the mutation first in the queue, e.g. to be
+	// replayed once the conflict is resolved. By default, the mutation is dropped.
+	OnConflict func(ctx context.Context, m QueuedMutation, err error) error
+}
+
+// QueuedMutation is a mutation stored by a MutationQueue.
+type QueuedMutation struct {
+	// ID identifies the mutation in the queue.
+	ID string `json:"id"`
+	// Name is the operation name, or "" if the mutation is anonymous.
+	Name string `json:"name,omitempty"`
+	// Query is the document of the mutation.
+	Query string `json:"query"`
+	// Variables are the variables of the mutation, as encoded in JSON.
+	Variables map[string]json.RawMessage `json:"variables,omitempty"`
+	// URL is the endpoint the mutation is sent to, as set by the client, the
+	// context of the call with WithURL, or WithEndpoint.
+	URL string `json:"url,omitempty"`
+	// Header holds the headers set by the options and context of the call,
+	// except for credentials (see MutationQueue).
+	Header http.Header `json:"header,omitempty"`
+	// QueryParams holds the URL query parameters set by WithQueryParam.
+	QueryParams url.Values `json:"queryParams,omitempty"`
+	// Extensions is the "extensions" object of the request payload, if any,
+	// as encoded in JSON.
+	Extensions map[string]json.RawMessage `json:"extensions,omitempty"`
+	// IdempotencyKey is the idempotency key of the mutation, if any, sent
+	// with every attempt, including the one which failed.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+	// QueuedAt is when the mutation was queued.
+	QueuedAt time.Time `json:"queuedAt"`
+}
+
+// MutationQueue executes mutations with a client, and stores those which
+// can't be sent to a file, to be replayed in order by Replay once connectivity
+// returns, e.g. for edge agents reporting to a central API:
+//
+//	queue, err := graphql.NewMutationQueue(client, "/var/lib/agent/mutations.json", graphql.QueueSettings{})
+//	// ...
+//	err = queue.Mutate(ctx, &m, variables)
+//	if err != nil && !errors.Is(err, graphql.ErrMutationQueued) {
+//		return err
+//	}
+//	// ... periodically:
+//	n, err := queue.Replay(ctx)
+//
+// While mutations are queued, the following ones are queued without being
+// sent, so that they're applied in order, and mutations are sent one at a time.
+// Queued mutations are replayed to the endpoint, with the headers, URL query
+// parameters and extensions they were to be sent with, except for the
+// credential headers Authorization, Proxy-Authorization and Cookie, which aren't
+// stored, so that they're neither written to disk nor sent once expired. Replayed
+// mutations are sent with the credentials of the client and the context of
+// Replay instead, e.g. those of its TokenProvider. Mutations with uploads are
+// never queued.
+type MutationQueue struct {
+	c        *Client
+	path     string
+	settings QueueSettings
+
+	replayMu sync.Mutex // Serializes replays.
+
+	mu      sync.Mutex
+	pending []QueuedMutation
+}
+
+// credentialHeaders are the headers MutationQueue doesn't store.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// queueFile is the encoding of the file of a MutationQueue.
+type queueFile struct {
+	Mutations []QueuedMutation `json:"mutations"`
+}
+
+// NewMutationQueue returns a MutationQueue executing mutations with c, and storing
+// them to the file path, loading the mutations stored there by a previous queue.
+func NewMutationQueue(c *Client, path string, settings QueueSettings) (*MutationQueue, error) {
+	if settings.IsOffline == nil {
+		settings.IsOffline = isConnectionError
+	}
+	q := &MutationQueue{c: c, path: path, settings: settings}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var f queueFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("graphql: mutation queue %s: %w", path, err)
+	}
+	q.pending = f.Mutations
+	return q, nil
+}
+
+// Mutate executes the mutation derived from m as Client.Mutate does, unless
+// mutations are queued. If they are, or it fails for lack of connectivity, the
+// mutation is queued, and ErrMutationQueued returned.
+func (q *MutationQueue) Mutate(ctx context.Context, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return q.NamedMutate(ctx, "", m, variables, options...)
+}
+
+// NamedMutate executes the mutation derived from m with operation name as MutationQueue.Mutate does.
+func (q *MutationQueue) NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) error {
+	var callOpts requestOptions
+	for _, o := range options {
+		o(&callOpts)
+	}
+	key := callOpts.idempotencyKey
+	if key == "" && q.c.idempotencyKeyHeader != "" {
+		// Assign the key now, to send it again in replays.
+		key = uuid.New().String()
+		options = append(options[:len(options):len(options)], WithIdempotencyKey(key))
+	}
+
+	// Hold the lock until the mutation is sent or queued, so that the mutations
+	// following it aren't sent before it's queued.
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.pending) == 0 {
+		err := q.c.NamedMutate(ctx, name, m, variables, options...)
+		if err == nil || !q.settings.IsOffline(err) {
+			return err
+		}
+	}
+
+	if len(extractUploads(variables)) > 0 {
+		return errors.New("graphql: mutations with uploads can't be queued")
+	}
+	op, err := q.c.newOperation(ctx, MutationOperation, m, variables, name, options)
+	if err != nil {
+		return err
+	}
+	mutation := QueuedMutation{
+		ID:             uuid.New().String(),
+		Name:           op.Name,
+		Query:          op.Query,
+		URL:            op.URL,
+		QueryParams:    op.queryParams(),
+		IdempotencyKey: key,
+		QueuedAt:       time.Now(),
+	}
+	if mutation.Variables, err = marshalEntries(op.Variables); err != nil {
+		return err
+	}
+	if mutation.Extensions, err = marshalEntries(op.Extensions); err != nil {
+		return err
+	}
+	header := op.Header.Clone()
+	for _, k := range credentialHeaders {
+		header.Del(k)
+	}
+	if len(header) > 0 {
+		mutation.Header = header
+	}
+
+	q.pending = append(q.pending, mutation)
+	if err := q.save(); err != nil {
+		q.pending = q.pending[:len(q.pending)-1]
+		return err
+	}
+	return ErrMutationQueued
+}
+
+// Replay sends the queued mutations in order, and removes those sent from the
+// queue, until one fails for lack of connectivity, whose error is returned,
+// or a conflict callback fails. It returns the number of mutations sent.
+func (q *MutationQueue) Replay(ctx context.Context) (int, error) {
+	q.replayMu.Lock()
+	defer q.replayMu.Unlock()
+	if q.c.readOnly {
+		return 0, ErrReadOnly
+	}
+	sent := 0
+	for {
+		q.mu.Lock()
+		if len(q.pending) == 0 {
+			q.mu.Unlock()
+			return sent, nil
+		}
+		m := q.pending[0]
+		q.mu.Unlock()
+
+		err := q.send(ctx, m)
+		if err != nil && q.settings.IsOffline(err) {
+			return sent, err
+		}
+		if err != nil && q.settings.OnConflict != nil {
+			if err := q.settings.OnConflict(ctx, m, err); err != nil {
+				return sent, err
+			}
+		}
+		if err == nil {
+			sent++
+		}
+
+		q.mu.Lock()
+		q.pending = q.pending[1:]
+		err = q.save()
+		q.mu.Unlock()
+		if err != nil {
+			return sent, err
+		}
+	}
+}
+
+// Pending returns the queued mutations, in order.
+func (q *MutationQueue) Pending() []QueuedMutation {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]QueuedMutation(nil), q.pending...)
+}
+
+// Len returns the number of queued mutations.
+func (q *MutationQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// marshalEntries returns the entries of m encoded in JSON, or nil if m is empty.
+func marshalEntries(m map[string]interface{}) (map[string]json.RawMessage, error) {
+	if len(m) == 0 {
+		return nil, nil
+	}
+	entries := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		entries[k] = data
+	}
+	return entries, nil
+}
+
+// send sends the queued mutation m, and returns its error or GraphQL errors.
+func (q *MutationQueue) send(ctx context.Context, m QueuedMutation) error {
+	options := []Option{func(opts *requestOptions) {
+		for k, v := range m.Header {
+			if opts.header == nil {
+				opts.header = make(http.Header)
+			}
+			opts.header[k] = v
+		}
+		for k, v := range m.QueryParams {
+			if opts.queryParams == nil {
+				opts.queryParams = make(url.Values)
+			}
+			opts.queryParams[k] = v
+		}
+		for k, v := range m.Extensions {
+			if opts.extensions == nil {
+				opts.extensions = make(map[string]interface{})
+			}
+			opts.extensions[k] = v
+		}
+		if m.IdempotencyKey != "" {
+			opts.idempotencyKey = m.IdempotencyKey
+		}
+	}}
+	variables := make(map[string]interface{}, len(m.Variables))
+	for k, v := range m.Variables {
+		variables[k] = v
+	}
+	op := q.c.newDocumentOperation(ctx, MutationOperation, variables, m.Name, options)
+	if m.URL != "" {
+		// Set after the options, so that the endpoints of WithFailover are
+		// still tried for mutations queued for the URL of the client.
+		op.URL = m.URL
+	}
+	op.Query = m.Query
+	resp, err := q.c.execute(ctx, op)
+	if err != nil {
+		return q.c.annotateError(err, op)
+	}
+	if len(resp.Errors) > 0 {
+		return q.c.annotateError(resp.Errors, op)
+	}
+	return nil
+}
+
+// save writes the queued mutations to the file of q. q.mu must be held.
+func (q *MutationQueue) save() error {
+	data, err := json.Marshal(queueFile{Mutations: q.pending})
+	if err != nil {
+		return err
+	}
+	// Write a temporary file renamed into place, so that the file is never partially written.
+	f, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), q.path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,296 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// queueServer serves mutations adding a report, recording them, unless it's down,
+// refusing connections, or unavailable, responding with 503 Service Unavailable.
+type queueServer struct {
+	down        bool
+	unavailable bool
+	conflict    string // Text of the reports failing with a GraphQL error.
+	reports     []string
+	keys        []string
+	roles       []string
+	urls        []string // Request URIs.
+	auths       []string // Authorization headers.
+	extensions  []string
+
+	// If set, requests refused while down signal started, and wait for release first.
+	started, release chan struct{}
+}
+
+func (s *queueServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if s.down {
+		if s.started != nil {
+			s.started <- struct{}{}
+			<-s.release
+		}
+		return nil, syscall.ECONNREFUSED
+	}
+	return localRoundTripper{handler: s}.RoundTrip(req)
+}
+
+func (s *queueServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if s.unavailable {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var in struct {
+		Variables struct {
+			Text string
+		}
+		Extensions json.RawMessage
+	}
+	if err := json.NewDecoder(req.Body).Decode(&in); err != nil {
+		panic(err)
+	}
+	s.reports = append(s.reports, in.Variables.Text)
+	s.keys = append(s.keys, req.Header.Get(graphql.DefaultIdempotencyKeyHeader))
+	s.roles = append(s.roles, req.Header.Get("X-Role"))
+	s.urls = append(s.urls, req.URL.RequestURI())
+	s.auths = append(s.auths, req.Header.Get("Authorization"))
+	s.extensions = append(s.extensions, string(in.Extensions))
+	w.Header().Set("Content-Type", "application/json")
+	if in.Variables.Text == s.conflict {
+		mustWrite(w, `{"errors": [{"message": "conflict"}]}`)
+		return
+	}
+	mustWrite(w, `{"data": {"addReport": {"id": "1"}}}`)
+}
+
+type addReportMutation struct {
+	AddReport struct {
+		ID string
+	} `graphql:"addReport(text: $text)"`
+}
+
+func TestMutationQueue(t *testing.T) {
+	server := &queueServer{down: true}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: server}).
+		WithIdempotencyKeys("")
+	path := filepath.Join(t.TempDir(), "mutations.json")
+	queue, err := graphql.NewMutationQueue(client, path, graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m addReportMutation
+	err = queue.NamedMutate(context.Background(), "AddReport", &m, map[string]interface{}{"text": "a"},
+		graphql.WithRequestHeader("X-Role", "agent"))
+	if !errors.Is(err, graphql.ErrMutationQueued) {
+		t.Fatalf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+	}
+	// Queued without being sent, as the first mutation is queued.
+	server.down = false
+	if err := queue.Mutate(context.Background(), &m, map[string]interface{}{"text": "b"}); !errors.Is(err, graphql.ErrMutationQueued) {
+		t.Fatalf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+	}
+	if len(server.reports) != 0 {
+		t.Fatalf("got reports: %q, want none", server.reports)
+	}
+
+	// The mutations outlive the queue.
+	queue, err = graphql.NewMutationQueue(client, path, graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := queue.Pending()
+	if len(pending) != 2 || pending[0].Name != "AddReport" || pending[0].IdempotencyKey == "" {
+		t.Fatalf("got pending mutations: %+v", pending)
+	}
+	n, err := queue.Replay(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || queue.Len() != 0 {
+		t.Errorf("got %d mutations replayed, %d queued, want: 2, 0", n, queue.Len())
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(server.reports, want) {
+		t.Errorf("got reports: %q, want: %q", server.reports, want)
+	}
+	if server.keys[0] != pending[0].IdempotencyKey || server.keys[1] != pending[1].IdempotencyKey {
+		t.Errorf("got idempotency keys: %q, want those of %+v", server.keys, pending)
+	}
+	if want := []string{"agent", ""}; !reflect.DeepEqual(server.roles, want) {
+		t.Errorf("got roles: %q, want: %q", server.roles, want)
+	}
+
+	// Once the queue is empty, mutations are sent.
+	if err := queue.Mutate(context.Background(), &m, map[string]interface{}{"text": "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.AddReport.ID, "1"; got != want {
+		t.Errorf("got ID: %q, want: %q", got, want)
+	}
+
+	// The server may have executed the mutations failed with error responses.
+	server.unavailable = true
+	var netErr *graphql.NetworkError
+	if err := queue.Mutate(context.Background(), &m, map[string]interface{}{"text": "d"}); !errors.As(err, &netErr) || netErr.StatusCode() != http.StatusServiceUnavailable {
+		t.Errorf("got error: %v, want: 503", err)
+	}
+	if queue.Len() != 0 {
+		t.Errorf("got %d mutations queued, want: 0", queue.Len())
+	}
+}
+
+func TestMutationQueue_operation(t *testing.T) {
+	server := &queueServer{down: true}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: server})
+	path := filepath.Join(t.TempDir(), "mutations.json")
+	queue, err := graphql.NewMutationQueue(client, path, graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := graphql.WithHeader(graphql.WithURL(context.Background(), "/tenants/acme/graphql"), "X-Role", "agent")
+	var m addReportMutation
+	err = queue.Mutate(ctx, &m, map[string]interface{}{"text": "a"},
+		graphql.WithQueryParam("api-version", "2024-01"),
+		graphql.WithRequestExtension("tenant", "acme"),
+		graphql.WithRequestHeader("Authorization", "Bearer expired"))
+	if !errors.Is(err, graphql.ErrMutationQueued) {
+		t.Fatalf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+	}
+	if got := queue.Pending()[0].Header; got.Get("Authorization") != "" {
+		t.Errorf("got queued headers: %v, want no Authorization", got)
+	}
+
+	// The mutation is replayed, from the file, as it was to be sent, with the credentials of the client.
+	queue, err = graphql.NewMutationQueue(client.With(graphql.WithRequestHeader("Authorization", "Bearer fresh")), path, graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.down = false
+	if n, err := queue.Replay(context.Background()); n != 1 || err != nil {
+		t.Fatalf("got %d mutations replayed, error: %v, want: 1, nil", n, err)
+	}
+	if want := []string{"/tenants/acme/graphql?api-version=2024-01"}; !reflect.DeepEqual(server.urls, want) {
+		t.Errorf("got URLs: %q, want: %q", server.urls, want)
+	}
+	if want := []string{"agent"}; !reflect.DeepEqual(server.roles, want) {
+		t.Errorf("got roles: %q, want: %q", server.roles, want)
+	}
+	if want := []string{`{"tenant":"acme"}`}; !reflect.DeepEqual(server.extensions, want) {
+		t.Errorf("got extensions: %q, want: %q", server.extensions, want)
+	}
+	if want := []string{"Bearer fresh"}; !reflect.DeepEqual(server.auths, want) {
+		t.Errorf("got Authorization headers: %q, want: %q", server.auths, want)
+	}
+}
+
+func TestMutationQueue_endpoint(t *testing.T) {
+	server := &queueServer{down: true}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: server})
+	queue, err := graphql.NewMutationQueue(client, filepath.Join(t.TempDir(), "mutations.json"), graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m addReportMutation
+	err = queue.Mutate(context.Background(), &m, map[string]interface{}{"text": "a"}, graphql.WithEndpoint("/shards/2/graphql"))
+	if !errors.Is(err, graphql.ErrMutationQueued) {
+		t.Fatalf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+	}
+	server.down = false
+	if n, err := queue.Replay(context.Background()); n != 1 || err != nil {
+		t.Fatalf("got %d mutations replayed, error: %v, want: 1, nil", n, err)
+	}
+	if want := []string{"/shards/2/graphql"}; !reflect.DeepEqual(server.urls, want) {
+		t.Errorf("got URLs: %q, want: %q", server.urls, want)
+	}
+}
+
+func TestMutationQueue_conflict(t *testing.T) {
+	server := &queueServer{down: true, conflict: "a"}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: server})
+	var conflicts []string
+	resolved := false
+	unresolved := errors.New("unresolved")
+	queue, err := graphql.NewMutationQueue(client, filepath.Join(t.TempDir(), "mutations.json"), graphql.QueueSettings{
+		OnConflict: func(ctx context.Context, m graphql.QueuedMutation, err error) error {
+			conflicts = append(conflicts, string(m.Variables["text"])+": "+err.Error())
+			if !resolved {
+				return unresolved
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m addReportMutation
+	for _, text := range []string{"a", "b"} {
+		if err := queue.Mutate(context.Background(), &m, map[string]interface{}{"text": text}); !errors.Is(err, graphql.ErrMutationQueued) {
+			t.Fatalf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+		}
+	}
+
+	// Still offline.
+	if n, err := queue.Replay(context.Background()); n != 0 || !graphql.IsRetryable(err) {
+		t.Errorf("got %d mutations replayed, error: %v, want a retryable error", n, err)
+	}
+	server.down = false
+	if n, err := queue.Replay(context.Background()); n != 0 || !errors.Is(err, unresolved) {
+		t.Errorf("got %d mutations replayed, error: %v, want: %v", n, err, unresolved)
+	}
+	if queue.Len() != 2 {
+		t.Errorf("got %d mutations queued, want: 2", queue.Len())
+	}
+	resolved = true
+	if n, err := queue.Replay(context.Background()); n != 1 || err != nil {
+		t.Errorf("got %d mutations replayed, error: %v, want: 1, nil", n, err)
+	}
+	if want := []string{`"a": conflict`, `"a": conflict`}; !reflect.DeepEqual(conflicts, want) {
+		t.Errorf("got conflicts: %q, want: %q", conflicts, want)
+	}
+	if want := []string{"a", "a", "b"}; !reflect.DeepEqual(server.reports, want) {
+		t.Errorf("got reports: %q, want: %q", server.reports, want)
+	}
+}
+
+func TestMutationQueue_order(t *testing.T) {
+	server := &queueServer{down: true, started: make(chan struct{}), release: make(chan struct{})}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: server})
+	queue, err := graphql.NewMutationQueue(client, filepath.Join(t.TempDir(), "mutations.json"), graphql.QueueSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b is called while a is failing, before a is queued, and isn't sent before it.
+	var wg sync.WaitGroup
+	mutate := func(text string) {
+		defer wg.Done()
+		var m addReportMutation
+		if err := queue.Mutate(context.Background(), &m, map[string]interface{}{"text": text}); !errors.Is(err, graphql.ErrMutationQueued) {
+			t.Errorf("got error: %v, want: %v", err, graphql.ErrMutationQueued)
+		}
+	}
+	wg.Add(2)
+	go mutate("a")
+	<-server.started
+	go mutate("b")
+	time.Sleep(10 * time.Millisecond)
+	close(server.release)
+	wg.Wait()
+
+	var got []string
+	for _, m := range queue.Pending() {
+		got = append(got, string(m.Variables["text"]))
+	}
+	if want := []string{`"a"`, `"b"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got queued mutations: %q, want: %q", got, want)
+	}
+}
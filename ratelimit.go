@@ -0,0 +1,98 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimiter paces the requests of a Client, e.g. a *rate.Limiter
+// of golang.org/x/time/rate.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or returns an error
+	// if it can't be before ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// WithRateLimiter makes the client wait for limiter before sending every
+// request, including the attempts of failover and the pages of Paginate.
+// Cached responses don't wait. A limiter may be shared by several clients.
+func (c *Client) WithRateLimiter(limiter RateLimiter) *Client {
+	c.limiter = limiter
+	return c
+}
+
+// rateLimit waits for the rate limiter of c before calling next.
+func (c *Client) rateLimit(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		if err := c.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+		return next(ctx, op)
+	}
+}
+
+// RetryAfter returns how long to wait before retrying the operation having
+// failed with err, if the server rate limited it: err is a *NetworkError with
+// the 429 status code, or 403 with a Retry-After header or an exhausted
+// X-RateLimit-Remaining, as sent by GitHub. The delay is taken from the
+// Retry-After header, in seconds or as a date, or from the X-RateLimit-Reset
+// header, in Unix seconds, and is 0 if neither is set.
+func RetryAfter(err error) (time.Duration, bool) {
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) {
+		return 0, false
+	}
+	delay, limited := rateLimitDelay(netErr.header, time.Now())
+	switch netErr.statusCode {
+	case http.StatusTooManyRequests:
+		return delay, true
+	case http.StatusForbidden:
+		return delay, limited
+	}
+	return 0, false
+}
+
+// rateLimitDelay returns how long the rate limit headers of a response
+// ask to wait before the next request, at now, and whether they do.
+func rateLimitDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	if v := header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now)), true
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		return nonNegative(time.Unix(reset, 0).Sub(now)), true
+	}
+	return 0, true
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// sleep waits for d, or returns the error of ctx if it's done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
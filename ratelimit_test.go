@@ -0,0 +1,101 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// countingLimiter counts the waits for it, failing once they exceed max.
+type countingLimiter struct {
+	waits, max int
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	l.waits++
+	if l.waits > l.max {
+		return errors.New("rate limit exceeded")
+	}
+	return nil
+}
+
+func TestClient_WithRateLimiter(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	limiter := &countingLimiter{max: 2}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithRateLimiter(limiter)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := client.Query(context.Background(), &q, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err == nil || err.Error() != "rate limit exceeded" {
+		t.Errorf("got error: %v, want: rate limit exceeded", err)
+	}
+	if got, want := limiter.waits, 3; got != want {
+		t.Errorf("got %d waits, want: %d", got, want)
+	}
+	if got, want := calls, 2; got != want {
+		t.Errorf("got %d calls, want: %d", got, want)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	tests := []struct {
+		name        string
+		statusCode  int
+		header      map[string]string
+		wantLimited bool
+		wantMin     time.Duration
+	}{
+		{"429 with seconds", http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}, true, 30 * time.Second},
+		{"429 with date", http.StatusTooManyRequests, map[string]string{"Retry-After": time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)}, true, 59 * time.Minute},
+		{"429 without header", http.StatusTooManyRequests, nil, true, 0},
+		{"403 with reset", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": reset}, true, 59 * time.Minute},
+		{"403 with remaining", http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "10", "X-RateLimit-Reset": reset}, false, 0},
+		{"503", http.StatusServiceUnavailable, map[string]string{"Retry-After": "30"}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				for k, v := range tt.header {
+					w.Header().Set(k, v)
+				}
+				w.WriteHeader(tt.statusCode)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+			var q struct {
+				User struct {
+					Name string
+				}
+			}
+			err := client.Query(context.Background(), &q, nil)
+			delay, limited := graphql.RetryAfter(err)
+			if limited != tt.wantLimited {
+				t.Fatalf("got limited: %v, want: %v", limited, tt.wantLimited)
+			}
+			if delay < tt.wantMin || delay > tt.wantMin+time.Minute {
+				t.Errorf("got delay: %v, want about: %v", delay, tt.wantMin)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+)
+
+// Redactor redacts the values of variables wherever they leave the process
+// other than in requests: in logs, debug dumps, error annotations, and
+// subscription logs, so that passwords and tokens in mutation inputs don't leak.
+type Redactor interface {
+	// Redact returns the value to show in place of the value of the variable name.
+	Redact(name string, value interface{}) interface{}
+}
+
+// RedactFunc returns the value of the variable name to log in place of value,
+// e.g. a placeholder for secrets.
+type RedactFunc func(name string, value interface{}) interface{}
+
+// Redact calls f(name, value).
+func (f RedactFunc) Redact(name string, value interface{}) interface{} {
+	return f(name, value)
+}
+
+// redacted is the placeholder of the variable values left out of logs.
+const redacted = "[REDACTED]"
+
+// RedactFields returns a Redactor replacing by "[REDACTED]" the values of the
+// variables, and of the fields of input objects at any depth, whose names match
+// any of patterns, as by path.Match ignoring case, e.g. "password" or "*token*".
+// The other values are shown as they're sent. It panics if a pattern is malformed.
+func RedactFields(patterns ...string) Redactor {
+	r := fieldRedactor{patterns: make([]string, len(patterns))}
+	for i, pattern := range patterns {
+		r.patterns[i] = strings.ToLower(pattern)
+		if _, err := path.Match(r.patterns[i], ""); err != nil {
+			panic(fmt.Sprintf("graphql: malformed redaction pattern %q: %v", pattern, err))
+		}
+	}
+	return r
+}
+
+// fieldRedactor is the Redactor returned by RedactFields.
+type fieldRedactor struct {
+	patterns []string // Lower case.
+}
+
+func (r fieldRedactor) Redact(name string, value interface{}) interface{} {
+	if r.matches(name) {
+		return redacted
+	}
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return value
+	}
+	// Walk the value as it's sent, with the field names of its JSON encoding.
+	b, err := json.Marshal(value)
+	if err != nil {
+		return redacted
+	}
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return redacted
+	}
+	return r.redactFields(v)
+}
+
+// redactFields redacts the fields matching r in the JSON value v.
+func (r fieldRedactor) redactFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if r.matches(k) {
+				v[k] = redacted
+			} else {
+				v[k] = r.redactFields(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = r.redactFields(v[i])
+		}
+	}
+	return v
+}
+
+func (r fieldRedactor) matches(name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range r.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRedactor makes the client show the variables of operations as redacted
+// by redactor in logs, debug dumps, and the OperationErrors of WithQueryInErrors,
+// which then hold their redacted values. Without a redactor, all values are
+// replaced by "[REDACTED]".
+func (c *Client) WithRedactor(redactor Redactor) *Client {
+	c.redactor = redactor
+	return c
+}
+
+// RedactedVariables returns a copy of the variables of op, redacted as in logs,
+// e.g. for the span attributes of a tracing Middleware.
+func (c *Client) RedactedVariables(op *Operation) map[string]interface{} {
+	return c.redactVariables(op.Variables)
+}
+
+// redactVariables returns a copy of variables redacted for logs and debug dumps.
+func (c *Client) redactVariables(variables map[string]interface{}) map[string]interface{} {
+	return redactVariables(c.redactor, variables)
+}
+
+// redactVariables returns a copy of variables redacted by redactor,
+// or with all values redacted if it's nil.
+func redactVariables(redactor Redactor, variables map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(variables))
+	for name, value := range variables {
+		if redactor != nil {
+			out[name] = redactor.Redact(name, value)
+		} else {
+			out[name] = redacted
+		}
+	}
+	return out
+}
@@ -0,0 +1,144 @@
+package graphql_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type loginInput struct {
+	User        string `json:"user"`
+	Password    string `json:"password"`
+	Credentials []struct {
+		APIToken string `json:"apiToken"`
+	} `json:"credentials"`
+}
+
+func TestRedactFields(t *testing.T) {
+	input := loginInput{User: "gopher", Password: "hunter2"}
+	input.Credentials = append(input.Credentials, struct {
+		APIToken string `json:"apiToken"`
+	}{APIToken: "t0ken"})
+	r := graphql.RedactFields("PASSWORD", "*token*")
+
+	for _, tc := range []struct {
+		name  string
+		value interface{}
+		want  string
+	}{
+		{"input", input, `{"credentials":[{"apiToken":"[REDACTED]"}],"password":"[REDACTED]","user":"gopher"}`},
+		{"input", &input, `{"credentials":[{"apiToken":"[REDACTED]"}],"password":"[REDACTED]","user":"gopher"}`},
+		{"resetToken", graphql.String("t0ken"), `"[REDACTED]"`},
+		{"id", graphql.ID("1"), `"1"`},
+		{"ids", []graphql.ID{"1", "2"}, `["1","2"]`},
+	} {
+		got, err := json.Marshal(r.Redact(tc.name, tc.value))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.want {
+			t.Errorf("got %s redacted as %s, want: %s", tc.name, got, tc.want)
+		}
+	}
+	if input.Password != "hunter2" {
+		t.Error("got the redacted value modified")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("got no panic for a malformed pattern")
+		}
+	}()
+	graphql.RedactFields("[")
+}
+
+func TestClient_WithRedactor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"errors": [{"message": "wrong password"}]}`)
+	})
+	var buf bytes.Buffer
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDebug(graphql.DebugWriter(&buf)).
+		WithQueryInErrors().
+		WithRedactor(graphql.RedactFields("password"))
+
+	var m struct {
+		Login struct {
+			Token string
+		} `graphql:"login(input: $input)"`
+	}
+	variables := map[string]interface{}{"input": loginInput{User: "gopher", Password: "hunter2"}}
+	err := client.Mutate(context.Background(), &m, variables)
+	var opErr *graphql.OperationError
+	if !errors.As(err, &opErr) {
+		t.Fatalf("got error: %v, want: *graphql.OperationError", err)
+	}
+	if strings.Contains(err.Error(), "hunter2") || !strings.Contains(err.Error(), `"password":"[REDACTED]","user":"gopher"`) {
+		t.Errorf("got error: %v, want the redacted variables", err)
+	}
+	if got := fmt.Sprint(opErr.Values["input"]); !strings.Contains(got, "[REDACTED]") {
+		t.Errorf("got values: %v, want the redacted variables", opErr.Values)
+	}
+	if strings.Contains(buf.String(), "hunter2") || !strings.Contains(buf.String(), `"password":"[REDACTED]"`) {
+		t.Errorf("got dump: %s, want the redacted variables", buf.String())
+	}
+
+	op := &graphql.Operation{Variables: variables}
+	if got := fmt.Sprint(client.RedactedVariables(op)); strings.Contains(got, "hunter2") {
+		t.Errorf("got redacted variables: %s, want the password redacted", got)
+	}
+}
+
+func TestSubscriptionClient_WithRedactor(t *testing.T) {
+	server := newWebSocketServer(t, func(payload json.RawMessage) []string {
+		return []string{`{"data": {"login": {"token": "t"}}}`}
+	})
+	defer server.Close()
+
+	var mu sync.Mutex
+	var logs []string
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http")).
+		WithRedactor(graphql.RedactFields("password")).
+		WithLog(func(args ...interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			logs = append(logs, fmt.Sprint(args...))
+		})
+	defer sc.Close()
+	go sc.Run()
+	client := graphql.NewClient("/graphql", nil).WithWebSocketTransport(sc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var m struct {
+		Login struct {
+			Token string
+		} `graphql:"login(input: $input)"`
+	}
+	if err := client.Mutate(ctx, &m, map[string]interface{}{"input": loginInput{User: "gopher", Password: "hunter2"}}); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	var started bool
+	for _, log := range logs {
+		if strings.Contains(log, "hunter2") {
+			t.Errorf("got the password logged: %s", log)
+		}
+		started = started || strings.Contains(log, "login(input: $input)")
+	}
+	if !started {
+		t.Errorf("got logs: %q, want the start message", logs)
+	}
+}
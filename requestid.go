@@ -0,0 +1,82 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// DefaultRequestIDHeader is the header carrying request IDs, unless set otherwise by Client.WithRequestIDs.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// WithRequestIDs makes the client give each operation a request ID, sent
+// in header, or DefaultRequestIDHeader if header is "", and included in
+// logs and errors, as *RequestIDError. The ID is taken from the context
+// of the call if set by ContextWithRequestID, and a random UUID otherwise.
+//
+// Batched requests carry the IDs of their operations, separated by commas.
+func (c *Client) WithRequestIDs(header string) *Client {
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+	c.requestIDHeader = header
+	return c
+}
+
+// requestIDKey is the context key of the request ID set by ContextWithRequestID.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID id, used
+// by clients having request IDs enabled instead of generating one, for
+// end-to-end correlation with the request being served.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID set by ContextWithRequestID, if any.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// assignRequestID sets the request ID of op, if enabled by WithRequestIDs and not set yet.
+func (c *Client) assignRequestID(ctx context.Context, op *Operation) {
+	if c.requestIDHeader == "" || op.RequestID != "" {
+		return
+	}
+	if id, ok := RequestIDFromContext(ctx); ok && id != "" {
+		op.RequestID = id
+		return
+	}
+	op.RequestID = uuid.New().String()
+}
+
+// requestIDs returns the value of the request ID header of a request sending ops.
+func requestIDs(ops []*Operation) string {
+	ids := make([]string, 0, len(ops))
+	for _, op := range ops {
+		if op.RequestID != "" {
+			ids = append(ids, op.RequestID)
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+// RequestIDError annotates an error with the request ID of the operation that caused it.
+// It's returned instead of the plain error if enabled by Client.WithRequestIDs.
+type RequestIDError struct {
+	RequestID string
+	Err       error
+}
+
+// Error implements error interface.
+func (e *RequestIDError) Error() string {
+	return fmt.Sprintf("%v (request ID: %s)", e.Err, e.RequestID)
+}
+
+// Unwrap returns the underlying error.
+func (e *RequestIDError) Unwrap() error {
+	return e.Err
+}
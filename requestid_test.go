@@ -0,0 +1,56 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithRequestIDs(t *testing.T) {
+	var gotIDs []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		gotIDs = append(gotIDs, req.Header.Get("X-Correlation-ID"))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"errors": [{"message": "forbidden"}]}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithRequestIDs("X-Correlation-ID")
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	err := client.Query(context.Background(), &q, nil)
+	var idErr *graphql.RequestIDError
+	if !errors.As(err, &idErr) {
+		t.Fatalf("got error: %v, want a *graphql.RequestIDError", err)
+	}
+	if len(gotIDs) != 1 || gotIDs[0] == "" || gotIDs[0] != idErr.RequestID {
+		t.Errorf("got request IDs: %q, want the ID of the error %q", gotIDs, idErr.RequestID)
+	}
+	if !strings.HasSuffix(err.Error(), "(request ID: "+idErr.RequestID+")") {
+		t.Errorf("got error: %v, want the request ID", err)
+	}
+	var gqlErrs graphql.Errors
+	if !errors.As(err, &gqlErrs) {
+		t.Errorf("got error: %v, want it to wrap graphql.Errors", err)
+	}
+
+	ctx := graphql.ContextWithRequestID(context.Background(), "upstream-id")
+	err = client.Query(ctx, &q, nil)
+	if !errors.As(err, &idErr) || idErr.RequestID != "upstream-id" {
+		t.Errorf("got error: %v, want the request ID of the context", err)
+	}
+	if got, want := gotIDs[1], "upstream-id"; got != want {
+		t.Errorf("got request ID: %q, want: %q", got, want)
+	}
+	if id, ok := graphql.RequestIDFromContext(ctx); !ok || id != "upstream-id" {
+		t.Errorf("got request ID from context: %q, %v, want: upstream-id, true", id, ok)
+	}
+}
@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetrySettings configures the retries of Client.WithRetries. Zero values are
+// replaced by the documented defaults.
+type RetrySettings struct {
+	// MaxRetries is the number of times an operation is retried. It defaults to 3,
+	// and negative values disable retries.
+	MaxRetries int
+	// Backoff is the wait before retrying an operation when the server doesn't
+	// tell how long to wait, doubled on every retry up to a minute. It defaults to 1s.
+	Backoff time.Duration
+	// MaxDelay, if positive, is the longest wait asked by a server which is honored.
+	// Operations asked to wait longer fail right away.
+	MaxDelay time.Duration
+}
+
+// WithRetries makes the client retry the operations failing with a retryable
+// error (see IsRetryable), or rate limited by the server (see RetryAfter),
+// as configured by settings. The waits asked by the Retry-After or
+// X-RateLimit-Reset headers of the responses are honored, instead of
+// backing off, as long as the context of the call isn't done.
+//
+// Mutations are only retried when rate limited, as the server didn't execute
+// them, or when they have an idempotency key (see WithIdempotencyKeys).
+// Operations with uploads aren't retried either, as their readers are consumed,
+// and the operations of a Batch are sent together, and aren't retried.
+func (c *Client) WithRetries(settings RetrySettings) *Client {
+	if settings.MaxRetries == 0 {
+		settings.MaxRetries = 3
+	}
+	if settings.Backoff <= 0 {
+		settings.Backoff = time.Second
+	}
+	c.retry = &settings
+	return c
+}
+
+// retryHandler returns the handler retrying the operations failed by next.
+func (c *Client) retryHandler(next OperationHandler) OperationHandler {
+	settings := c.retry
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		if _, ok := ctx.Value(batchSlotKey{}).(*batchSlot); ok {
+			return next(ctx, op)
+		}
+		if len(extractUploads(op.Variables)) > 0 {
+			// The readers of the uploads are consumed by the first attempt.
+			return next(ctx, op)
+		}
+		backoff := settings.Backoff
+		for retries := 0; ; retries++ {
+			resp, err := next(ctx, op)
+			if err == nil || retries >= settings.MaxRetries || ctx.Err() != nil {
+				return resp, err
+			}
+			delay, limited := RetryAfter(err)
+			if !limited {
+				if !IsRetryable(err) || op.Type == MutationOperation && op.IdempotencyKey == "" {
+					return resp, err
+				}
+				// E.g. the Retry-After header of a 503 response.
+				var netErr *NetworkError
+				if errors.As(err, &netErr) {
+					delay, _ = rateLimitDelay(netErr.header, time.Now())
+				}
+			}
+			switch {
+			case settings.MaxDelay > 0 && delay > settings.MaxDelay:
+				return resp, err
+			case delay == 0:
+				delay = backoff
+				backoff = min(2*backoff, time.Minute)
+			}
+			if sleep(ctx, delay) != nil {
+				return resp, err
+			}
+		}
+	}
+}
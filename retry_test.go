@@ -0,0 +1,167 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// flakyHandler fails the first requests with status and header, and serves the next ones.
+type flakyHandler struct {
+	failures int
+	status   int
+	header   map[string]string
+	sent     int
+}
+
+func (h *flakyHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.sent++
+	if h.sent <= h.failures {
+		for k, v := range h.header {
+			w.Header().Set(k, v)
+		}
+		w.WriteHeader(h.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+}
+
+func TestClient_WithRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler *flakyHandler
+		wait    time.Duration
+	}{
+		{"backoff", &flakyHandler{failures: 2, status: http.StatusBadGateway}, 0},
+		{"Retry-After", &flakyHandler{failures: 1, status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "1"}}, time.Second},
+		{"Retry-After of 503", &flakyHandler{failures: 1, status: http.StatusServiceUnavailable, header: map[string]string{"Retry-After": "1"}}, time.Second},
+		{"X-RateLimit-Reset", &flakyHandler{failures: 1, status: http.StatusForbidden, header: map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     "0",
+		}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: tt.handler}}).
+				WithRetries(graphql.RetrySettings{Backoff: time.Millisecond})
+			start := time.Now()
+			if got, want := queryUserName(t, client), "Gopher"; got != want {
+				t.Errorf("got name: %q, want: %q", got, want)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wait {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.wait)
+			}
+			if got, want := tt.handler.sent, tt.handler.failures+1; got != want {
+				t.Errorf("got %d requests, want: %d", got, want)
+			}
+		})
+	}
+}
+
+func TestClient_WithRetries_limits(t *testing.T) {
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	rateLimited := func() *flakyHandler {
+		return &flakyHandler{failures: 10, status: http.StatusTooManyRequests, header: map[string]string{"Retry-After": "120"}}
+	}
+
+	// Too long a wait.
+	handler := rateLimited()
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: handler}}).
+		WithRetries(graphql.RetrySettings{MaxDelay: time.Minute})
+	if err := client.Query(context.Background(), &q, nil); err == nil {
+		t.Error("got no error")
+	}
+	if handler.sent != 1 {
+		t.Errorf("got %d requests, want: 1", handler.sent)
+	}
+
+	// The wait is bounded by the context.
+	handler = rateLimited()
+	client = graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: handler}}).
+		WithRetries(graphql.RetrySettings{})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := client.Query(ctx, &q, nil); err == nil {
+		t.Error("got no error")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("waited %v despite the context", elapsed)
+	}
+
+	// Retries are limited.
+	handler = &flakyHandler{failures: 10, status: http.StatusBadGateway}
+	client = graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: handler}}).
+		WithRetries(graphql.RetrySettings{MaxRetries: 2, Backoff: time.Millisecond})
+	if err := client.Query(context.Background(), &q, nil); err == nil {
+		t.Error("got no error")
+	}
+	if handler.sent != 3 {
+		t.Errorf("got %d requests, want: 3", handler.sent)
+	}
+
+	// Permanent errors aren't retried.
+	handler = &flakyHandler{failures: 10, status: http.StatusBadRequest}
+	client = graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: handler}}).
+		WithRetries(graphql.RetrySettings{Backoff: time.Millisecond})
+	if err := client.Query(context.Background(), &q, nil); err == nil {
+		t.Error("got no error")
+	}
+	if handler.sent != 1 {
+		t.Errorf("got %d requests, want: 1", handler.sent)
+	}
+}
+
+func TestClient_WithRetries_mutations(t *testing.T) {
+	var m struct {
+		User struct {
+			Name string
+		} `graphql:"user(name: \"Gopher\")"`
+	}
+	for _, tt := range []struct {
+		name    string
+		handler *flakyHandler
+		options []graphql.Option
+		sent    int
+	}{
+		{"without idempotency key", &flakyHandler{failures: 1, status: http.StatusBadGateway}, nil, 1},
+		{"with idempotency key", &flakyHandler{failures: 1, status: http.StatusBadGateway}, []graphql.Option{graphql.WithIdempotencyKey("42")}, 2},
+		{"rate limited", &flakyHandler{failures: 1, status: http.StatusTooManyRequests}, nil, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: tt.handler}}).
+				WithRetries(graphql.RetrySettings{Backoff: time.Millisecond})
+			_ = client.Mutate(context.Background(), &m, nil, tt.options...)
+			if tt.handler.sent != tt.sent {
+				t.Errorf("got %d requests, want: %d", tt.handler.sent, tt.sent)
+			}
+		})
+	}
+}
+
+func TestClient_WithRetries_uploads(t *testing.T) {
+	h := &flakyHandler{failures: 1, status: http.StatusServiceUnavailable}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: h}}).
+		WithRetries(graphql.RetrySettings{Backoff: time.Millisecond})
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(avatar: $avatar)"`
+	}
+	variables := map[string]interface{}{"avatar": graphql.Upload{File: strings.NewReader("avatar"), Name: "avatar.png"}}
+	if err := client.Query(context.Background(), &q, variables); err == nil {
+		t.Error("got no error, want: 503")
+	}
+	if h.sent != 1 {
+		t.Errorf("got %d requests, want: 1", h.sent)
+	}
+}
@@ -0,0 +1,143 @@
+// Package schema validates the GraphQL operations derived from query structs of
+// package graphql against a local schema, loaded from SDL or an introspection
+// result, reporting unknown fields, wrong argument types and missing required
+// arguments before any request is sent.
+package schema
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// Schema is a GraphQL schema operations are validated against.
+type Schema struct {
+	schema *graphqlgo.Schema
+	tagKey string
+}
+
+// Parse parses a schema in the GraphQL schema definition language.
+func Parse(sdl string) (*Schema, error) {
+	s, err := graphqlgo.ParseSchema(sdl, nil, graphqlgo.UseStringDescriptions())
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{schema: s, tagKey: graphql.DefaultTagKey}, nil
+}
+
+// FromIntrospection returns the schema described by an introspection result,
+// e.g. as returned by graphql.ParseIntrospection.
+func FromIntrospection(schema *graphql.Schema) (*Schema, error) {
+	return Parse(SDL(schema))
+}
+
+// Load loads the schema of the file at path: an introspection result if it has
+// the .json extension, and SDL otherwise, e.g. schema.graphql.
+func Load(path string) (*Schema, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		introspection, err := graphql.ParseIntrospection(data)
+		if err != nil {
+			return nil, err
+		}
+		return FromIntrospection(introspection)
+	}
+	return Parse(string(data))
+}
+
+// Introspection returns s as described by the introspection system, e.g. to
+// look up the types of fields.
+func (s *Schema) Introspection() (*graphql.Schema, error) {
+	data, err := s.schema.ToJSON()
+	if err != nil {
+		return nil, err
+	}
+	return graphql.ParseIntrospection(data)
+}
+
+// WithTagKey makes s read field selections of query structs from the struct tags
+// with key, as graphql.Client.WithTagKey does, and returns s.
+func (s *Schema) WithTagKey(key string) *Schema {
+	s.tagKey = key
+	return s
+}
+
+// Validate validates the GraphQL document against s.
+// The error is an Errors if the document is invalid.
+// The values of variables aren't known, so only their types are validated.
+func (s *Schema) Validate(document string) error {
+	var errs Errors
+	for _, e := range s.schema.Validate(document) {
+		if e.Rule == "VariablesOfCorrectType" {
+			// Reported for the missing values of non-null variables.
+			continue
+		}
+		err := &Error{Message: e.Message, Rule: e.Rule, Document: document}
+		for _, l := range e.Locations {
+			err.Locations = append(err.Locations, graphql.Location{Line: l.Line, Column: l.Column})
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateQuery validates the query derived from q with variables, as
+// graphql.Client.Query sends it, against s.
+func (s *Schema) ValidateQuery(q interface{}, variables map[string]interface{}) error {
+	document, _, err := s.client().Plan(q, variables)
+	if err != nil {
+		return err
+	}
+	return s.Validate(document)
+}
+
+// ValidateMutation validates the mutation derived from m with variables, as
+// graphql.Client.Mutate sends it, against s.
+func (s *Schema) ValidateMutation(m interface{}, variables map[string]interface{}) error {
+	document, _, err := s.client().PlanMutation(m, variables)
+	if err != nil {
+		return err
+	}
+	return s.Validate(document)
+}
+
+// client returns a client constructing documents as s expects them.
+func (s *Schema) client() *graphql.Client {
+	return graphql.NewClient("", nil).WithTagKey(s.tagKey)
+}
+
+// Error is a violation of the schema by a document.
+type Error struct {
+	Message string
+	// Locations holds the positions of the violation in Document.
+	Locations []graphql.Location
+	// Rule is the name of the violated validation rule, e.g. "FieldsOnCorrectType".
+	Rule     string
+	Document string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errors are the violations of the schema by a document.
+type Errors []*Error
+
+// Error implements error interface.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i := range e {
+		messages[i] = e[i].Message
+	}
+	return strings.Join(messages, "; ")
+}
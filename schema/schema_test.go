@@ -0,0 +1,132 @@
+package schema_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+func TestSchema_ValidateQuery(t *testing.T) {
+	s, err := schema.Load("testdata/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var valid struct {
+		User struct {
+			Name         graphql.String
+			Repositories []struct {
+				Name graphql.String
+			} `graphql:"repositories(first: 10)"`
+		} `graphql:"user(id: $id)"`
+	}
+	if err := s.ValidateQuery(&valid, map[string]interface{}{"id": graphql.ID("1")}); err != nil {
+		t.Errorf("got error: %v, want none", err)
+	}
+
+	var m struct {
+		Rename struct {
+			Name graphql.String
+		} `graphql:"rename(input: $input)"`
+	}
+	type RenameInput struct {
+		ID   graphql.ID     `json:"id"`
+		Name graphql.String `json:"name"`
+	}
+	if err := s.ValidateMutation(&m, map[string]interface{}{"input": RenameInput{}}); err != nil {
+		t.Errorf("got error: %v, want none", err)
+	}
+
+	tests := []struct {
+		name      string
+		q         interface{}
+		variables map[string]interface{}
+		want      []string
+	}{
+		{
+			name: "unknown field",
+			q: &struct {
+				User struct {
+					Email graphql.String
+				} `graphql:"user(id: \"1\")"`
+			}{},
+			want: []string{`Cannot query field "email" on type "User".`},
+		},
+		{
+			name: "wrong argument type",
+			q: &struct {
+				User struct {
+					Name graphql.String
+				} `graphql:"user(id: $id)"`
+			}{},
+			variables: map[string]interface{}{"id": graphql.Int(1)},
+			want:      []string{`Variable "$id" of type "Int!" used in position expecting type "ID!".`},
+		},
+		{
+			name: "missing required argument",
+			q: &struct {
+				User struct {
+					Repositories []struct {
+						Name graphql.String
+					}
+				} `graphql:"user(id: \"1\")"`
+			}{},
+			want: []string{`Field "repositories" argument "first" of type "Int!" is required but not provided.`},
+		},
+	}
+	for _, tc := range tests {
+		err := s.ValidateQuery(tc.q, tc.variables)
+		errs, ok := err.(schema.Errors)
+		if !ok {
+			t.Errorf("%s: got error: %v, want schema.Errors", tc.name, err)
+			continue
+		}
+		var got []string
+		for _, e := range errs {
+			got = append(got, e.Message)
+			if len(e.Locations) == 0 || e.Rule == "" {
+				t.Errorf("%s: got error %+v without location or rule", tc.name, e)
+			}
+		}
+		if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+			t.Errorf("%s: got errors: %q, want: %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestLoad_introspection(t *testing.T) {
+	s, err := schema.Load("testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var q struct {
+		Search []struct {
+			User struct {
+				Login graphql.String
+			} `graphql:"... on User"`
+		} `graphql:"search(text: \"go\")"`
+	}
+	if err := s.ValidateQuery(&q, nil); err != nil {
+		t.Errorf("got error: %v, want none", err)
+	}
+}
+
+func TestSchema_Introspection(t *testing.T) {
+	s, err := schema.Load("testdata/schema.graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspection, err := s.Introspection()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := introspection.Type("User")
+	if user == nil || user.Kind != graphql.TypeKindObject || user.Description != "A user of the service." {
+		t.Fatalf("got type User %+v", user)
+	}
+	if got, want := introspection.QueryType.Name, "Query"; got != want {
+		t.Errorf("got query type %q, want %q", got, want)
+	}
+}
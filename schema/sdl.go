@@ -0,0 +1,224 @@
+package schema
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// builtinScalars are the scalars defined by the GraphQL specification, left out of SDL.
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// builtinDirectives are the directives defined by the GraphQL specification, left out of SDL.
+var builtinDirectives = map[string]bool{
+	"skip":        true,
+	"include":     true,
+	"deprecated":  true,
+	"specifiedBy": true,
+}
+
+// SDL returns schema in the GraphQL schema definition language, with the types
+// sorted by name, leaving out the built-in scalars, directives and introspection types.
+func SDL(schema *graphql.Schema) string {
+	var b strings.Builder
+	writeSDL(&b, schema)
+	return b.String()
+}
+
+// WriteSDL writes schema to w in the GraphQL schema definition language, as SDL does.
+func WriteSDL(w io.Writer, schema *graphql.Schema) error {
+	_, err := io.WriteString(w, SDL(schema))
+	return err
+}
+
+func writeSDL(b *strings.Builder, schema *graphql.Schema) {
+	var sections []string
+	if root := rootOperations(schema); root != "" {
+		sections = append(sections, root)
+	}
+
+	directives := append([]graphql.Directive(nil), schema.Directives...)
+	sort.Slice(directives, func(i, j int) bool { return directives[i].Name < directives[j].Name })
+	for _, d := range directives {
+		if builtinDirectives[d.Name] {
+			continue
+		}
+		var s strings.Builder
+		writeDescription(&s, d.Description, "")
+		s.WriteString("directive @" + d.Name)
+		writeArgs(&s, d.Args)
+		s.WriteString(" on " + strings.Join(d.Locations, " | "))
+		sections = append(sections, s.String())
+	}
+
+	types := append([]graphql.Type(nil), schema.Types...)
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	for _, t := range types {
+		if strings.HasPrefix(t.Name, "__") || t.Kind == graphql.TypeKindScalar && builtinScalars[t.Name] {
+			continue
+		}
+		sections = append(sections, typeSDL(t))
+	}
+	for i, s := range sections {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(s)
+		b.WriteString("\n")
+	}
+}
+
+// rootOperations returns the schema definition, or "" if the root operation
+// types have their default names, which makes it superfluous.
+func rootOperations(schema *graphql.Schema) string {
+	roots := []struct {
+		operation string
+		ref       *graphql.TypeRef
+		dflt      string
+	}{
+		{"query", schema.QueryType, "Query"},
+		{"mutation", schema.MutationType, "Mutation"},
+		{"subscription", schema.SubscriptionType, "Subscription"},
+	}
+	needed := false
+	for _, r := range roots {
+		if r.ref != nil && r.ref.Name != r.dflt {
+			needed = true
+		}
+	}
+	if !needed {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("schema {\n")
+	for _, r := range roots {
+		if r.ref != nil {
+			b.WriteString("  " + r.operation + ": " + r.ref.Name + "\n")
+		}
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func typeSDL(t graphql.Type) string {
+	var b strings.Builder
+	writeDescription(&b, t.Description, "")
+	switch t.Kind {
+	case graphql.TypeKindScalar:
+		b.WriteString("scalar " + t.Name)
+	case graphql.TypeKindObject, graphql.TypeKindInterface:
+		if t.Kind == graphql.TypeKindObject {
+			b.WriteString("type " + t.Name)
+		} else {
+			b.WriteString("interface " + t.Name)
+		}
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, iface := range t.Interfaces {
+				names[i] = iface.Name
+			}
+			b.WriteString(" implements " + strings.Join(names, " & "))
+		}
+		b.WriteString(" {\n")
+		for _, f := range t.Fields {
+			writeDescription(&b, f.Description, "  ")
+			b.WriteString("  " + f.Name)
+			writeArgs(&b, f.Args)
+			b.WriteString(": " + f.Type.String())
+			writeDeprecation(&b, f.IsDeprecated, f.DeprecationReason)
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case graphql.TypeKindUnion:
+		names := make([]string, len(t.PossibleTypes))
+		for i, member := range t.PossibleTypes {
+			names[i] = member.Name
+		}
+		b.WriteString("union " + t.Name + " = " + strings.Join(names, " | "))
+	case graphql.TypeKindEnum:
+		b.WriteString("enum " + t.Name + " {\n")
+		for _, v := range t.EnumValues {
+			writeDescription(&b, v.Description, "  ")
+			b.WriteString("  " + v.Name)
+			writeDeprecation(&b, v.IsDeprecated, v.DeprecationReason)
+			b.WriteString("\n")
+		}
+		b.WriteString("}")
+	case graphql.TypeKindInputObject:
+		b.WriteString("input " + t.Name + " {\n")
+		for _, f := range t.InputFields {
+			writeDescription(&b, f.Description, "  ")
+			b.WriteString("  " + inputValueSDL(f) + "\n")
+		}
+		b.WriteString("}")
+	}
+	return b.String()
+}
+
+func writeArgs(b *strings.Builder, args []graphql.InputValue) {
+	if len(args) == 0 {
+		return
+	}
+	b.WriteString("(")
+	for i, arg := range args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(inputValueSDL(arg))
+	}
+	b.WriteString(")")
+}
+
+func inputValueSDL(v graphql.InputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func writeDescription(b *strings.Builder, description, indent string) {
+	if description == "" {
+		return
+	}
+	b.WriteString(indent + quote(description) + "\n")
+}
+
+func writeDeprecation(b *strings.Builder, deprecated bool, reason *string) {
+	if !deprecated {
+		return
+	}
+	b.WriteString(" @deprecated")
+	if reason != nil && *reason != "" {
+		b.WriteString("(reason: " + quote(*reason) + ")")
+	}
+}
+
+// quote returns s as a GraphQL string literal.
+func quote(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // Strings always encode.
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Download fetches the schema of the server of client by introspection,
+// and writes it to w in the GraphQL schema definition language.
+func Download(ctx context.Context, client *graphql.Client, w io.Writer) error {
+	schema, err := client.Introspect(ctx)
+	if err != nil {
+		return err
+	}
+	return WriteSDL(w, schema)
+}
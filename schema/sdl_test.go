@@ -0,0 +1,75 @@
+package schema_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/schema"
+)
+
+func TestSDL(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	introspection, err := graphql.ParseIntrospection(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdl := schema.SDL(introspection)
+	for _, want := range []string{
+		"\"A user of the service.\"\ntype User implements Node {\n",
+		"  \"The login of the user.\"\n  login: String! @deprecated(reason: \"Use name.\")\n",
+		"  repositories(first: Int!, orderBy: Order = ASC): [Repository!]!\n",
+		"enum Order {\n  ASC\n  DESC\n}\n",
+		"input RenameInput {\n  id: ID!\n  name: String!\n}\n",
+		"scalar DateTime\n",
+		"type Mutation {\n  rename(input: RenameInput!): User\n}\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("got SDL:\n%s\nwant it to contain:\n%s", sdl, want)
+		}
+	}
+	for _, unwanted := range []string{"schema {", "scalar String", "__Type", "directive @skip"} {
+		if strings.Contains(sdl, unwanted) {
+			t.Errorf("got SDL:\n%s\nwant it not to contain %q", sdl, unwanted)
+		}
+	}
+	if _, err := schema.Parse(sdl); err != nil {
+		t.Errorf("got SDL failing to parse: %v", err)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	result, err := ioutil.ReadFile("testdata/introspection.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if got, want := req.Header.Get("Authorization"), "Bearer token"; got != want {
+			t.Errorf("got Authorization header: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(result)
+	}))
+	defer server.Close()
+	client := graphql.NewClient(server.URL, nil, graphql.WithRequestHeader("Authorization", "Bearer token"))
+
+	var buf bytes.Buffer
+	if err := schema.Download(context.Background(), client, &buf); err != nil {
+		t.Fatal(err)
+	}
+	introspection, err := graphql.ParseIntrospection(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), schema.SDL(introspection); got != want {
+		t.Errorf("got SDL:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,338 @@
+// Package schemacheck defines an Analyzer checking the query structs used with
+// package graphql against a schema, reporting the fields they select which
+// don't exist in the schema, are deprecated, or whose types changed such that
+// responses can't be decoded to the Go types anymore. Run against the latest
+// schema of a server in CI, it catches breaking API changes before production
+// decoding errors do.
+//
+// It's run by the graphql-schemacheck command, standalone or by go vet.
+package schemacheck
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"github.com/runtimeracer/go-graphql-client/ident"
+	"github.com/runtimeracer/go-graphql-client/internal/selection"
+	"github.com/runtimeracer/go-graphql-client/schema"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `check the query structs used with the client against a schema
+
+The schemacheck analyzer reports the fields selected by the query structs
+passed to the client which don't exist in the schema set by the -schema flag,
+are deprecated, or whose Go types don't match their types in the schema.`
+
+// Analyzer checks the query structs passed to the functions of package graphql against a schema.
+var Analyzer = &analysis.Analyzer{
+	Name:     "schemacheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+var (
+	// schemaPath is the path of the schema, as loaded by schema.Load.
+	schemaPath string
+	// tagKey is the struct tag key of field selections, as set by graphql.Client.WithTagKey.
+	tagKey string
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&schemaPath, "schema", "", "schema file, in SDL or as introspection result (.json)")
+	Analyzer.Flags.StringVar(&tagKey, "tagkey", "graphql", "struct tag key of the field selections")
+}
+
+// graphqlPath is the import path of package graphql.
+const graphqlPath = "github.com/runtimeracer/go-graphql-client"
+
+var schemas struct {
+	sync.Mutex
+	byPath map[string]*graphql.Schema
+}
+
+// loadSchema loads the schema at path once, as the analyzer runs for many packages.
+func loadSchema(path string) (*graphql.Schema, error) {
+	schemas.Lock()
+	defer schemas.Unlock()
+	if s, ok := schemas.byPath[path]; ok {
+		return s, nil
+	}
+	s, err := schema.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	introspection, err := s.Introspection()
+	if err != nil {
+		return nil, err
+	}
+	if schemas.byPath == nil {
+		schemas.byPath = make(map[string]*graphql.Schema)
+	}
+	schemas.byPath[path] = introspection
+	return introspection, nil
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if schemaPath == "" {
+		return nil, errors.New("no schema to check against, set the -schema flag")
+	}
+	s, err := loadSchema(schemaPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %v", err)
+	}
+	c := &checker{pass: pass, schema: s, reported: make(map[string]bool)}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	inspect.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		c.checkCall(n.(*ast.CallExpr))
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass   *analysis.Pass
+	schema *graphql.Schema
+	// reported holds the reported diagnostics, so that query structs used by
+	// several calls are reported once.
+	reported map[string]bool
+}
+
+// checkCall checks the calls of functions of package graphql taking a query
+// struct and its variables, which are the parameters preceding and named variables.
+// Their names tell the type of the operation, e.g. NamedMutate.
+func (c *checker) checkCall(call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != graphqlPath {
+		return
+	}
+	params := fn.Type().(*types.Signature).Params()
+	for i := 1; i < params.Len() && i < len(call.Args); i++ {
+		if params.At(i).Name() != "variables" {
+			continue
+		}
+		root, operation := c.schema.QueryType, "query"
+		switch {
+		case strings.Contains(fn.Name(), "Mutat"):
+			root, operation = c.schema.MutationType, "mutation"
+		case strings.Contains(fn.Name(), "Subscri"):
+			root, operation = c.schema.SubscriptionType, "subscription"
+		}
+		if root == nil || c.schema.Type(root.Name) == nil {
+			c.reportf(call.Pos(), "the schema has no %s type", operation)
+			return
+		}
+		c.checkSelections(call, c.pass.TypesInfo.TypeOf(call.Args[i-1]), c.schema.Type(root.Name), make(map[string]bool))
+		return
+	}
+}
+
+func (c *checker) reportf(pos token.Pos, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	key := fmt.Sprint(pos, message)
+	if c.reported[key] {
+		return
+	}
+	c.reported[key] = true
+	c.pass.Reportf(pos, "%s", message)
+}
+
+// position returns where to report about the field f: where it's declared if
+// that's in the analyzed package, and at call otherwise.
+func (c *checker) position(f *types.Var, call *ast.CallExpr) token.Pos {
+	if f.Pkg() == c.pass.Pkg {
+		return f.Pos()
+	}
+	return call.Pos()
+}
+
+// typeString returns t as written in the analyzed package.
+func (c *checker) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == c.pass.Pkg {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// checkSelections checks the fields of the query struct type t, selected on the type parent.
+func (c *checker) checkSelections(call *ast.CallExpr, t types.Type, parent *graphql.Type, seen map[string]bool) {
+	st, ok := structType(t)
+	if !ok {
+		return
+	}
+	key := t.String() + " on " + parent.Name
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if !f.Exported() {
+			continue
+		}
+		tag, hasTag := reflect.StructTag(st.Tag(i)).Lookup(tagKey)
+		var sel *selection.Selection
+		switch {
+		case hasTag:
+			var err error
+			if sel, err = selection.Parse(tag); err != nil {
+				continue // Reported by tagcheck.
+			}
+		case f.Anonymous():
+			// Embedded structs are inlined into the selection set of parent.
+			c.checkSelections(call, f.Type(), parent, seen)
+			continue
+		case f.Name() == "Typename":
+			continue
+		default:
+			sel = &selection.Selection{Name: ident.ParseMixedCaps(f.Name()).ToLowerCamelCase()}
+		}
+
+		if sel.Fragment {
+			fragmentType := parent
+			if sel.TypeCondition != "" {
+				if fragmentType = c.schema.Type(sel.TypeCondition); fragmentType == nil {
+					c.reportf(c.position(f, call), "type %s of fragment %s doesn't exist in the schema", sel.TypeCondition, f.Name())
+					continue
+				}
+			}
+			c.checkSelections(call, f.Type(), fragmentType, seen)
+			continue
+		}
+		if sel.Name == "__typename" {
+			continue
+		}
+		field := lookupField(parent, sel.Name)
+		if field == nil {
+			c.reportf(c.position(f, call), "field %s.%s selected by %s doesn't exist in the schema", parent.Name, sel.Name, f.Name())
+			continue
+		}
+		if field.IsDeprecated {
+			reason := ""
+			if field.DeprecationReason != nil && *field.DeprecationReason != "" {
+				reason = ": " + *field.DeprecationReason
+			}
+			c.reportf(c.position(f, call), "field %s.%s selected by %s is deprecated%s", parent.Name, sel.Name, f.Name(), reason)
+		}
+		if why := c.checkType(call, f.Type(), field.Type, seen); why != "" {
+			c.reportf(c.position(f, call), "field %s.%s of type %s can't be decoded to %s of type %s: %s",
+				parent.Name, sel.Name, field.Type, f.Name(), c.typeString(f.Type()), why)
+		}
+	}
+}
+
+// checkType checks that values of the GraphQL type ref can be decoded to the Go type t,
+// returning why they can't, or checking the selections of t on object types.
+func (c *checker) checkType(call *ast.CallExpr, t types.Type, ref graphql.TypeRef, seen map[string]bool) string {
+	for {
+		p, ok := t.Underlying().(*types.Pointer)
+		if !ok {
+			break
+		}
+		t = p.Elem()
+	}
+	if ref.Kind == graphql.TypeKindNonNull && ref.OfType != nil {
+		ref = *ref.OfType
+	}
+	if isScalar(t) {
+		return "" // Decodes anything it's written to.
+	}
+	slice, isSlice := t.Underlying().(*types.Slice)
+	if ref.Kind == graphql.TypeKindList && ref.OfType != nil {
+		if !isSlice {
+			return "it's a list"
+		}
+		return c.checkType(call, slice.Elem(), *ref.OfType, seen)
+	}
+	if isSlice {
+		return "it's not a list"
+	}
+
+	named := c.schema.Type(ref.Name)
+	if named == nil {
+		return ""
+	}
+	_, isStruct := t.Underlying().(*types.Struct)
+	switch named.Kind {
+	case graphql.TypeKindObject, graphql.TypeKindInterface, graphql.TypeKindUnion:
+		if !isStruct {
+			return "it's an object"
+		}
+		c.checkSelections(call, t, named, seen)
+		return ""
+	}
+	if isStruct {
+		return "it's a scalar"
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return "" // E.g. an interface, like graphql.ID.
+	}
+	info := basic.Info()
+	switch {
+	case named.Kind == graphql.TypeKindScalar && ref.Name == "Int":
+		if info&(types.IsInteger|types.IsFloat) == 0 {
+			return "it's a number"
+		}
+	case named.Kind == graphql.TypeKindScalar && ref.Name == "Float":
+		if info&types.IsFloat == 0 {
+			return "it's a floating-point number"
+		}
+	case named.Kind == graphql.TypeKindScalar && ref.Name == "Boolean":
+		if info&types.IsBoolean == 0 {
+			return "it's a boolean"
+		}
+	case named.Kind == graphql.TypeKindEnum || ref.Name == "String" || ref.Name == "ID":
+		if info&types.IsString == 0 {
+			return "it's a string"
+		}
+	}
+	return ""
+}
+
+// structType returns the struct type of t, or of the type t points to.
+func structType(t types.Type) (*types.Struct, bool) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if isScalar(t) {
+		return nil, false
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	return st, ok
+}
+
+// lookupField returns the field name of the object or interface type t, or nil if there's none.
+func lookupField(t *graphql.Type, name string) *graphql.Field {
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+	return nil
+}
+
+// isScalar reports whether the pointer type of t implements json.Unmarshaler,
+// which makes it a scalar decoding any JSON value, whose fields aren't part of the query.
+func isScalar(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok {
+		return false
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(named), true, named.Obj().Pkg(), "UnmarshalJSON")
+	_, ok = obj.(*types.Func)
+	return ok
+}
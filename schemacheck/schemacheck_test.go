@@ -0,0 +1,15 @@
+package schemacheck_test
+
+import (
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/schemacheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	if err := schemacheck.Analyzer.Flags.Set("schema", "testdata/schema.graphql"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), schemacheck.Analyzer, "a")
+}
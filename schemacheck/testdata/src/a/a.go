@@ -0,0 +1,53 @@
+package a
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type Repository struct {
+	Name  graphql.String
+	Stars graphql.Int // want `field Repository.stars selected by Stars doesn't exist in the schema`
+}
+
+type UserFields struct {
+	ID graphql.ID
+}
+
+type UserQuery struct {
+	User *struct {
+		UserFields
+		Typename     graphql.String
+		Name         graphql.Int      // want `field User.name of type String! can't be decoded to Name of type graphql.Int: it's a string`
+		Login        graphql.String   // want `field User.login selected by Login is deprecated: Use name.`
+		Repositories []Repository     `graphql:"repositories(first: 10)"`
+		Owner        graphql.String   `graphql:"owner: name"`
+		Raw          json.RawMessage  `graphql:"raw: repositories(first: 1)"`
+		Names        []graphql.String `graphql:"name"` // want `field User.name of type String! can't be decoded to Names of type \[\]graphql.String: it's not a list`
+	} `graphql:"user(id: $id)"`
+	Search []struct {
+		OnUser struct {
+			Name graphql.String
+		} `graphql:"... on User"`
+		OnTeam struct { // want `type Team of fragment OnTeam doesn't exist in the schema`
+			Name graphql.String
+		} `graphql:"... on Team"`
+	} `graphql:"search(text: $text)"`
+	Now graphql.String `graphql:"now"`
+}
+
+func calls(ctx context.Context, client *graphql.Client) {
+	var q UserQuery
+	client.Query(ctx, &q, nil)
+	client.Query(ctx, &q, nil)
+
+	var m struct {
+		Rename struct {
+			Repositories graphql.String `graphql:"repositories(first: 1)"` // want `field User.repositories of type \[Repository!\]! can't be decoded to Repositories of type graphql.String: it's a list`
+		} `graphql:"rename(input: $input)"`
+		Delete graphql.Boolean `graphql:"delete(id: $id)"` // want `field Mutation.delete selected by Delete doesn't exist in the schema`
+	}
+	client.NamedMutate(ctx, "Rename", &m, nil)
+}
@@ -0,0 +1,27 @@
+// Package graphql is a stub of the client for the tests of schemacheck.
+package graphql
+
+import "context"
+
+type (
+	ID     interface{}
+	Int    int32
+	String string
+)
+
+type Option func()
+
+type Client struct{}
+
+func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) error {
+	return nil
+}
+
+func (c *Client) NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return nil
+}
+
+type (
+	Boolean bool
+	Float   float64
+)
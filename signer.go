@@ -0,0 +1,42 @@
+package graphql
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// Signer signs the HTTP requests of operations, e.g. with AWS Signature
+// Version 4 for AppSync, or with an HMAC for authenticated gateways.
+type Signer interface {
+	// Sign signs req, whose body is body, typically by setting headers.
+	// It's called last, just before sending req.
+	// body is nil for multipart uploads, as they're streamed.
+	Sign(req *http.Request, body []byte) error
+}
+
+// SignerFunc is an adapter to use a function as a Signer.
+type SignerFunc func(req *http.Request, body []byte) error
+
+// Sign calls f(req, body).
+func (f SignerFunc) Sign(req *http.Request, body []byte) error {
+	return f(req, body)
+}
+
+// NewHMACSigner returns a Signer setting header to the hex-encoded
+// HMAC-SHA256 of the request body with key.
+func NewHMACSigner(header string, key []byte) Signer {
+	return SignerFunc(func(req *http.Request, body []byte) error {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	})
+}
+
+// WithSigner makes the client sign the HTTP request of every operation with signer.
+func (c *Client) WithSigner(signer Signer) *Client {
+	c.signer = signer
+	return c
+}
@@ -0,0 +1,45 @@
+package graphql_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithSigner(t *testing.T) {
+	key := []byte("secret")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body := mustRead(req.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(body))
+		if got, want := req.Header.Get("X-Signature"), hex.EncodeToString(mac.Sum(nil)); got != want {
+			t.Errorf("got X-Signature: %q, want: %q", got, want)
+		}
+		if got, want := req.Header.Get("X-Trace"), "1"; got != want {
+			t.Errorf("got X-Trace: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithSigner(graphql.NewHMACSigner("X-Signature", key)).
+		ModifyRequest(func(req *http.Request) error {
+			req.Header.Set("X-Trace", "1")
+			return nil
+		})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, map[string]interface{}{"id": graphql.Int(1)}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// QueryStream executes a query like QueryRaw, but returns the body of the
+// response as it's received, the JSON object with its "data" and "errors",
+// rather than buffering it, e.g. to pipe a large response to a file or another
+// service. The body must be closed, which ends the request:
+//
+//	body, err := client.QueryStream(ctx, &q, variables)
+//	if err != nil {
+//		return err
+//	}
+//	defer body.Close()
+//	_, err = io.Copy(w, body)
+//
+// Failed requests are reported as by QueryRaw, but the GraphQL errors of
+// responses with the 200 status code are left in the body. Streamed queries
+// aren't cached, deduplicated, batched, hedged or sent over websocket
+// transports, and response hooks and debug dumps don't see their bodies.
+// Timeouts bound the reading of the body too.
+func (c *Client) QueryStream(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) (io.ReadCloser, error) {
+	return c.doStream(ctx, q, variables, "", options)
+}
+
+// NamedQueryStream executes a query with operation name like QueryStream.
+func (c *Client) NamedQueryStream(ctx context.Context, name string, q interface{}, variables map[string]interface{}, options ...Option) (io.ReadCloser, error) {
+	return c.doStream(ctx, q, variables, name, options)
+}
+
+func (c *Client) doStream(ctx context.Context, q interface{}, variables map[string]interface{}, name string, options []Option) (io.ReadCloser, error) {
+	op, err := c.newOperation(ctx, QueryOperation, q, variables, name, options)
+	if err != nil {
+		return nil, err
+	}
+	op.options.stream = true
+	resp, err := c.execute(ctx, op)
+	if err != nil {
+		return nil, c.annotateError(err, op)
+	}
+	if resp.body != nil {
+		return resp.body, nil
+	}
+	// A middleware responded: stream the response it built.
+	if len(resp.Errors) > 0 {
+		return nil, c.annotateError(resp.Errors, op)
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(body)), nil
+}
+
+// streamed reports whether op is executed by QueryStream.
+func (op *Operation) streamed() bool {
+	return op.options != nil && op.options.stream
+}
+
+// sendStream sends op to the server in a request of its own, and returns
+// its response with the unread body, unless the request failed.
+func (c *Client) sendStream(ctx context.Context, op *Operation) (*Response, error) {
+	ctx, cancel := c.withTimeout(ctx, op.options)
+	req, err := c.newRequest(ctx, op)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, &NetworkError{err: err}
+	}
+	body := resp.Body
+	if c.decompression != nil {
+		if body, err = c.decompression.body(resp); err != nil {
+			resp.Body.Close()
+			cancel()
+			return nil, &NetworkError{err: err}
+		}
+	}
+	stream := &streamBody{ReadCloser: body, body: resp.Body, cancel: cancel, limit: c.maxResponseSize}
+	if c.maxResponseSize > 0 && resp.ContentLength > c.maxResponseSize {
+		stream.Close()
+		return nil, &ResponseTooLargeError{Limit: c.maxResponseSize}
+	}
+	duration := time.Since(start)
+
+	if resp.StatusCode != http.StatusOK {
+		// Read the body for the errors, as sendSingle does.
+		defer stream.Close()
+		data, err := ioutil.ReadAll(io.LimitReader(stream, maxErrorBodySize))
+		if err != nil {
+			return nil, &NetworkError{err: err}
+		}
+		if result, err := c.unmarshalGraphQLResult(data); err == nil && len(result.Errors) > 0 {
+			return result.withHTTP(resp, duration), nil
+		}
+		return nil, newStatusError(resp, data)
+	}
+	if err := checkContentType(resp, nil); err != nil {
+		defer stream.Close()
+		snippet, _ := ioutil.ReadAll(io.LimitReader(stream, maxSnippetSize))
+		return nil, &DecodeError{err: checkContentType(resp, snippet)}
+	}
+	return (&Response{body: stream}).withHTTP(resp, duration), nil
+}
+
+// streamBody is the body of a streamed response. Closing it closes the
+// response body and releases the context of the request.
+type streamBody struct {
+	io.ReadCloser // Decompressed body.
+
+	body   io.ReadCloser // Response body.
+	cancel context.CancelFunc
+	limit  int64 // Maximum response size, or 0 if unlimited.
+	read   int64
+}
+
+func (s *streamBody) Read(p []byte) (int, error) {
+	n, err := s.ReadCloser.Read(p)
+	if s.limit > 0 {
+		if allowed := s.limit - s.read; int64(n) > allowed {
+			s.read = s.limit
+			return int(allowed), &ResponseTooLargeError{Limit: s.limit}
+		}
+		s.read += int64(n)
+	}
+	return n, err
+}
+
+func (s *streamBody) Close() error {
+	err := s.ReadCloser.Close()
+	if s.body != s.ReadCloser {
+		s.body.Close()
+	}
+	s.cancel()
+	return err
+}
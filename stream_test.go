@@ -0,0 +1,138 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_QueryStream(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if got, want := mustRead(req.Body), `{"query":"query Export{export{rows}}"}`+"\n"; got != want {
+			t.Errorf("got body: %q, want: %q", got, want)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"export": {"rows": [`)
+		w.(http.Flusher).Flush()
+		mustWrite(w, `"a", "b"]}}, "errors": [{"message": "partial export"}]}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Export struct {
+			Rows []string
+		}
+	}
+	body, err := client.NamedQueryStream(context.Background(), "Export", &q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	got, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GraphQL errors are left in the body.
+	if want := `{"data": {"export": {"rows": ["a", "b"]}}, "errors": [{"message": "partial export"}]}`; string(got) != want {
+		t.Errorf("got body: %q, want: %q", got, want)
+	}
+	if q.Export.Rows != nil {
+		t.Errorf("got rows decoded: %q, want none", q.Export.Rows)
+	}
+}
+
+func TestClient_QueryStream_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  string
+		body    string
+		wantErr func(err error) bool
+	}{
+		{
+			name:   "status",
+			status: http.StatusBadGateway,
+			body:   "bad gateway",
+			wantErr: func(err error) bool {
+				var e *graphql.NetworkError
+				return errors.As(err, &e) && e.Body() == "bad gateway"
+			},
+		},
+		{
+			name:   "status with GraphQL errors",
+			status: http.StatusBadRequest,
+			body:   `{"errors": [{"message": "invalid query"}]}`,
+			wantErr: func(err error) bool {
+				var e graphql.Errors
+				return errors.As(err, &e) && e[0].Message == "invalid query"
+			},
+		},
+		{
+			name:   "Content-Type",
+			status: http.StatusOK,
+			header: "text/html",
+			body:   "<html>",
+			wantErr: func(err error) bool {
+				var e *graphql.MalformedResponseError
+				return errors.As(err, &e) && e.ContentType() == "text/html"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Content-Type", tt.header)
+				}
+				w.WriteHeader(tt.status)
+				mustWrite(w, tt.body)
+			})
+			client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+			var q struct {
+				User struct{ Name string }
+			}
+			body, err := client.QueryStream(context.Background(), &q, nil)
+			if body != nil {
+				body.Close()
+				t.Fatal("got a body, want none")
+			}
+			if !tt.wantErr(err) {
+				t.Errorf("got error: %v", err)
+			}
+		})
+	}
+}
+
+func TestClient_QueryStream_maxResponseSize(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "`)
+		w.(http.Flusher).Flush()
+		mustWrite(w, strings.Repeat("a", 100)+`"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).WithMaxResponseSize(64)
+	var q struct {
+		User struct{ Name string }
+	}
+	body, err := client.QueryStream(context.Background(), &q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	got, err := ioutil.ReadAll(body)
+	var tooLarge *graphql.ResponseTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("got error: %v, want a *graphql.ResponseTooLargeError", err)
+	}
+	if len(got) != 64 {
+		t.Errorf("got %d bytes, want: 64", len(got))
+	}
+}
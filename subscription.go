@@ -79,6 +79,11 @@ type subscription struct {
 	variables map[string]interface{}
 	handler   func(data *json.RawMessage, err error)
 	started   Boolean
+	// result receives the response of a query or mutation sent by
+	// Client.WithWebSocketTransport, for which handler is unset.
+	result chan webSocketResult
+	// extensions are those of a query or mutation sent by Client.WithWebSocketTransport.
+	extensions map[string]interface{}
 }
 
 // SubscriptionClient is a GraphQL subscription client.
@@ -101,6 +106,11 @@ type SubscriptionClient struct {
 	onError          func(sc *SubscriptionClient, err error) error
 	errorChan        chan error
 	disabledLogTypes []OperationMessageType
+	tagKey           string
+	redactor         Redactor
+
+	variableTransformers []VariableTransformer
+	defaultVariables     map[string]interface{}
 }
 
 func NewSubscriptionClient(url string) *SubscriptionClient {
@@ -112,6 +122,7 @@ func NewSubscriptionClient(url string) *SubscriptionClient {
 		createConn:    newWebsocketConn,
 		retryTimeout:  time.Minute,
 		errorChan:     make(chan error),
+		tagKey:        DefaultTagKey,
 	}
 }
 
@@ -168,6 +179,20 @@ func (sc *SubscriptionClient) WithoutLogTypes(types ...OperationMessageType) *Su
 	return sc
 }
 
+// WithTagKey sets the struct tag key holding GraphQL field selections. Default is DefaultTagKey
+func (sc *SubscriptionClient) WithTagKey(key string) *SubscriptionClient {
+	sc.tagKey = key
+	return sc
+}
+
+// WithRedactor makes the variables of the start messages printed by the logging
+// function of WithLog redacted by redactor, including those of the operations
+// routed by Client.WithWebSocketTransport. By default, they're printed as sent.
+func (sc *SubscriptionClient) WithRedactor(redactor Redactor) *SubscriptionClient {
+	sc.redactor = redactor
+	return sc
+}
+
 // WithReadLimit set max size of response message
 func (sc *SubscriptionClient) WithReadLimit(limit int64) *SubscriptionClient {
 	sc.readLimit = limit
@@ -200,6 +225,30 @@ func (sc *SubscriptionClient) setIsRunning(value Boolean) {
 	sc.subscribersMu.Unlock()
 }
 
+func (sc *SubscriptionClient) getIsRunning() Boolean {
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
+	return sc.isRunning
+}
+
+// getConn returns the websocket connection of sc, or nil if it's not connected.
+// The connection is swapped under the lock, as operations sent by execute stop
+// themselves from the goroutines of their callers.
+func (sc *SubscriptionClient) getConn() WebsocketConn {
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
+	return sc.conn
+}
+
+// setConn sets the websocket connection of sc, and returns the previous one.
+func (sc *SubscriptionClient) setConn(conn WebsocketConn) WebsocketConn {
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
+	prev := sc.conn
+	sc.conn = conn
+	return prev
+}
+
 func (sc *SubscriptionClient) init() error {
 
 	now := time.Now()
@@ -209,17 +258,17 @@ func (sc *SubscriptionClient) init() error {
 
 	for {
 		var err error
-		var conn WebsocketConn
 		// allow custom websocket client
-		if sc.conn == nil {
+		conn := sc.getConn()
+		if conn == nil {
 			conn, err = newWebsocketConn(sc)
 			if err == nil {
-				sc.conn = conn
+				sc.setConn(conn)
 			}
 		}
 
 		if err == nil {
-			sc.conn.SetReadLimit(sc.readLimit)
+			conn.SetReadLimit(sc.readLimit)
 			// send connection init event to the server
 			err = sc.sendConnectionInit()
 		}
@@ -269,24 +318,69 @@ func (sc *SubscriptionClient) sendConnectionInit() (err error) {
 	}
 
 	sc.printLog(msg, GQL_CONNECTION_INIT)
-	return sc.conn.WriteJSON(msg)
+	return sc.getConn().WriteJSON(msg)
 }
 
 // Subscribe sends start message to server and open a channel to receive data.
 // The handler callback function will receive raw message data or error. If the call return error, onError event will be triggered
 // The function returns subscription ID and error. You can use subscription ID to unsubscribe the subscription
 func (sc *SubscriptionClient) Subscribe(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
-	return sc.do(v, variables, handler, "")
+	query, variables, err := sc.prepare(SubscriptionOperation, "", v, variables)
+	if err != nil {
+		return "", err
+	}
+	return sc.do(query, variables, handler)
 }
 
 // NamedSubscribe sends start message to server and open a channel to receive data, with operation name
 func (sc *SubscriptionClient) NamedSubscribe(name string, v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
-	return sc.do(v, variables, handler, name)
+	query, variables, err := sc.prepare(SubscriptionOperation, name, v, variables)
+	if err != nil {
+		return "", err
+	}
+	return sc.do(query, variables, handler)
+}
+
+// Live sends a query derived from v with the @live directive, which servers supporting
+// it keep open, pushing the whole result again whenever it changes. The handler receives
+// every result, and the query is stopped by Unsubscribe, as for Subscribe.
+func (sc *SubscriptionClient) Live(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
+	query, variables, err := sc.prepare(QueryOperation, "", v, variables)
+	if err != nil {
+		return "", err
+	}
+	return sc.do(query, variables, handler)
+}
+
+// NamedLive sends a query with the @live directive and operation name, like Live.
+func (sc *SubscriptionClient) NamedLive(name string, v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
+	query, variables, err := sc.prepare(QueryOperation, name, v, variables)
+	if err != nil {
+		return "", err
+	}
+	return sc.do(query, variables, handler)
+}
+
+// prepare returns the document of the subscription, or live query if typ is
+// QueryOperation, derived from v with operation name, and its variables, with
+// the default variables and variable transformers of sc applied to them.
+func (sc *SubscriptionClient) prepare(typ OperationType, name string, v interface{}, variables map[string]interface{}) (string, map[string]interface{}, error) {
+	variables = withDefaultVariables(sc.defaultVariables, v, sc.tagKey, variables)
+	variables, err := transformVariables(context.Background(), sc.variableTransformers, typ, variables)
+	if err != nil {
+		return "", nil, err
+	}
+	var query string
+	if typ == SubscriptionOperation {
+		query, err = constructSubscription(v, variables, name, sc.tagKey)
+	} else {
+		query, err = constructLiveQuery(v, variables, name, sc.tagKey)
+	}
+	return query, variables, err
 }
 
-func (sc *SubscriptionClient) do(v interface{}, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error, name string) (string, error) {
+func (sc *SubscriptionClient) do(query string, variables map[string]interface{}, handler func(message *json.RawMessage, err error) error) (string, error) {
 	id := uuid.New().String()
-	query := constructSubscription(v, variables, name)
 
 	sub := subscription{
 		query:     query,
@@ -295,15 +389,14 @@ func (sc *SubscriptionClient) do(v interface{}, variables map[string]interface{}
 	}
 
 	// if the websocket client is running, start subscription immediately
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
 	if sc.isRunning {
 		if err := sc.startSubscription(id, &sub); err != nil {
 			return "", err
 		}
 	}
-
-	sc.subscribersMu.Lock()
 	sc.subscriptions[id] = &sub
-	sc.subscribersMu.Unlock()
 
 	return id, nil
 }
@@ -315,11 +408,13 @@ func (sc *SubscriptionClient) startSubscription(id string, sub *subscription) er
 	}
 
 	in := struct {
-		Query     string                 `json:"query"`
-		Variables map[string]interface{} `json:"variables,omitempty"`
+		Query      string                 `json:"query"`
+		Variables  map[string]interface{} `json:"variables,omitempty"`
+		Extensions map[string]interface{} `json:"extensions,omitempty"`
 	}{
-		Query:     sub.query,
-		Variables: sub.variables,
+		Query:      sub.query,
+		Variables:  sub.variables,
+		Extensions: sub.extensions,
 	}
 
 	payload, err := json.Marshal(in)
@@ -334,7 +429,17 @@ func (sc *SubscriptionClient) startSubscription(id string, sub *subscription) er
 		Payload: payload,
 	}
 
-	sc.printLog(msg, GQL_START)
+	if sc.redactor != nil && sc.log != nil {
+		// Print the start message with the redacted variables.
+		in.Variables = redactVariables(sc.redactor, sub.variables)
+		redactedPayload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		sc.printLog(OperationMessage{ID: id, Type: GQL_START, Payload: redactedPayload}, GQL_START)
+	} else {
+		sc.printLog(msg, GQL_START)
+	}
 	if err := sc.conn.WriteJSON(msg); err != nil {
 		return err
 	}
@@ -357,16 +462,19 @@ func (sc *SubscriptionClient) Run() error {
 		return fmt.Errorf("retry timeout. exiting...")
 	}
 
-	// lazily start subscriptions
+	// lazily start subscriptions, along with the operations sent meanwhile
+	sc.subscribersMu.Lock()
 	for k, v := range sc.subscriptions {
 		if err := sc.startSubscription(k, v); err != nil {
+			sc.subscribersMu.Unlock()
 			sc.Unsubscribe(k)
 			return err
 		}
 	}
-	sc.setIsRunning(true)
+	sc.isRunning = true
+	sc.subscribersMu.Unlock()
 
-	for sc.isRunning {
+	for sc.getIsRunning() {
 		select {
 		case <-sc.context.Done():
 			return nil
@@ -378,8 +486,13 @@ func (sc *SubscriptionClient) Run() error {
 			}
 		default:
 
+			conn := sc.getConn()
+			if conn == nil {
+				// closed by Close meanwhile
+				return nil
+			}
 			var message OperationMessage
-			if err := sc.conn.ReadJSON(&message); err != nil {
+			if err := conn.ReadJSON(&message); err != nil {
 				// manual EOF check
 				if err == io.EOF || strings.Contains(err.Error(), "EOF") {
 					return sc.Reset()
@@ -412,17 +525,17 @@ func (sc *SubscriptionClient) Run() error {
 				if err != nil {
 					continue
 				}
+				sc.subscribersMu.Lock()
 				sub, ok := sc.subscriptions[id.String()]
+				sc.subscribersMu.Unlock()
 				if !ok {
 					continue
 				}
-				var out struct {
-					Data   *json.RawMessage
-					Errors errors
-					//Extensions interface{} // Unused.
+				if sub.result != nil {
+					sc.deliver(id.String(), message.Type, message.Payload)
+					continue
 				}
-
-				err = json.Unmarshal(message.Payload, &out)
+				out, err := decodePayload(message.Type, message.Payload)
 				if err != nil {
 					go sub.handler(nil, err)
 					continue
@@ -437,7 +550,11 @@ func (sc *SubscriptionClient) Run() error {
 				sc.printLog(message, GQL_CONNECTION_ERROR)
 			case GQL_COMPLETE:
 				sc.printLog(message, GQL_COMPLETE)
-				sc.Unsubscribe(message.ID)
+				// Operations sent by execute are answered before they're
+				// completed, unless the server had no result for them.
+				if !sc.finish(message.ID, nil, ErrWebSocketCompleted) {
+					sc.Unsubscribe(message.ID)
+				}
 			case GQL_CONNECTION_KEEP_ALIVE:
 				sc.printLog(message, GQL_CONNECTION_KEEP_ALIVE)
 			case GQL_CONNECTION_ACK:
@@ -452,7 +569,7 @@ func (sc *SubscriptionClient) Run() error {
 	}
 
 	// if the running status is false, stop retrying
-	if !sc.isRunning {
+	if !sc.getIsRunning() {
 		return nil
 	}
 
@@ -462,7 +579,9 @@ func (sc *SubscriptionClient) Run() error {
 // Unsubscribe sends stop message to server and close subscription channel
 // The input parameter is subscription ID that is returned from Subscribe function
 func (sc *SubscriptionClient) Unsubscribe(id string) error {
+	sc.subscribersMu.Lock()
 	_, ok := sc.subscriptions[id]
+	sc.subscribersMu.Unlock()
 	if !ok {
 		return fmt.Errorf("subscription id %s doesn't not exist", id)
 	}
@@ -476,7 +595,7 @@ func (sc *SubscriptionClient) Unsubscribe(id string) error {
 }
 
 func (sc *SubscriptionClient) stopSubscription(id string) error {
-	if sc.conn != nil {
+	if conn := sc.getConn(); conn != nil {
 		// send stop message to the server
 		msg := OperationMessage{
 			ID:   id,
@@ -484,7 +603,7 @@ func (sc *SubscriptionClient) stopSubscription(id string) error {
 		}
 
 		sc.printLog(msg, GQL_STOP)
-		if err := sc.conn.WriteJSON(msg); err != nil {
+		if err := conn.WriteJSON(msg); err != nil {
 			return err
 		}
 
@@ -494,14 +613,14 @@ func (sc *SubscriptionClient) stopSubscription(id string) error {
 }
 
 func (sc *SubscriptionClient) terminate() error {
-	if sc.conn != nil {
+	if conn := sc.getConn(); conn != nil {
 		// send terminate message to the server
 		msg := OperationMessage{
 			Type: GQL_CONNECTION_TERMINATE,
 		}
 
 		sc.printLog(msg, GQL_CONNECTION_TERMINATE)
-		return sc.conn.WriteJSON(msg)
+		return conn.WriteJSON(msg)
 	}
 
 	return nil
@@ -509,40 +628,63 @@ func (sc *SubscriptionClient) terminate() error {
 
 // Reset restart websocket connection and subscriptions
 func (sc *SubscriptionClient) Reset() error {
-	if !sc.isRunning {
+	if !sc.getIsRunning() {
 		return nil
 	}
 
-	for id, sub := range sc.subscriptions {
-		_ = sc.stopSubscription(id)
+	sc.subscribersMu.Lock()
+	for _, sub := range sc.subscriptions {
 		sub.started = false
 	}
+	sc.subscribersMu.Unlock()
+	for _, id := range sc.subscriptionIDs() {
+		_ = sc.stopSubscription(id)
+		sc.finish(id, nil, ErrWebSocketClosed)
+	}
 
-	if sc.conn != nil {
-		_ = sc.terminate()
-		_ = sc.conn.Close()
-		sc.conn = nil
+	_ = sc.terminate()
+	if conn := sc.setConn(nil); conn != nil {
+		_ = conn.Close()
 	}
 	sc.cancel()
 
 	return sc.Run()
 }
 
+// subscriptionIDs returns the IDs of the subscriptions of sc, and of the
+// operations sent by execute, so that they're stopped without holding the lock.
+func (sc *SubscriptionClient) subscriptionIDs() []string {
+	sc.subscribersMu.Lock()
+	defer sc.subscribersMu.Unlock()
+	ids := make([]string, 0, len(sc.subscriptions))
+	for id := range sc.subscriptions {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
 // Close closes all subscription channel and websocket as well
 func (sc *SubscriptionClient) Close() (err error) {
 	sc.setIsRunning(false)
-	for id := range sc.subscriptions {
+	for _, id := range sc.subscriptionIDs() {
+		sc.finish(id, nil, ErrWebSocketClosed)
+	}
+	for _, id := range sc.subscriptionIDs() {
 		if err = sc.Unsubscribe(id); err != nil {
-			sc.cancel()
+			if sc.cancel != nil {
+				sc.cancel()
+			}
 			return err
 		}
 	}
-	if sc.conn != nil {
-		_ = sc.terminate()
-		err = sc.conn.Close()
-		sc.conn = nil
+	_ = sc.terminate()
+	if conn := sc.setConn(nil); conn != nil {
+		err = conn.Close()
+	}
+	// sc.cancel is only set once sc runs.
+	if sc.cancel != nil {
+		sc.cancel()
 	}
-	sc.cancel()
 
 	return
 }
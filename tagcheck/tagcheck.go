@@ -0,0 +1,286 @@
+// Package tagcheck defines an Analyzer checking the query structs used with
+// package graphql, reporting at build time what would otherwise fail when the
+// query is constructed or its response decoded:
+//
+//   - graphql tags with malformed selections, e.g. `graphql:"user(login: $login"`,
+//   - variables referenced by the tags of a query but missing from the variables
+//     map literal of the call executing it, and variables it doesn't use,
+//   - fields of types the response can't be decoded to, such as maps, and
+//     variables of types without a GraphQL name, such as int.
+//
+// It's run by the graphql-tagcheck command, standalone or by go vet.
+package tagcheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/runtimeracer/go-graphql-client/internal/selection"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const doc = `check graphql struct tags and the query structs used with the client
+
+The tagcheck analyzer reports graphql tags with malformed selections,
+variables referenced by query structs but missing from the variables of the
+call executing them (and unused ones), and Go types the client can't handle.`
+
+// Analyzer checks graphql struct tags and the calls of package graphql executing query structs.
+var Analyzer = &analysis.Analyzer{
+	Name:     "tagcheck",
+	Doc:      doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// tagKey is the struct tag key of field selections, as set by graphql.Client.WithTagKey.
+var tagKey string
+
+func init() {
+	Analyzer.Flags.StringVar(&tagKey, "tagkey", "graphql", "struct tag key of the field selections")
+}
+
+// graphqlPath is the import path of package graphql.
+const graphqlPath = "github.com/runtimeracer/go-graphql-client"
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	c := &checker{pass: pass, reported: make(map[*types.Var]bool)}
+	nodeFilter := []ast.Node{(*ast.StructType)(nil), (*ast.CallExpr)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.StructType:
+			c.checkTags(n)
+		case *ast.CallExpr:
+			c.checkCall(n)
+		}
+	})
+	return nil, nil
+}
+
+type checker struct {
+	pass *analysis.Pass
+	// reported holds the fields whose types were reported as unsupported,
+	// so that they're reported once however many calls use them.
+	reported map[*types.Var]bool
+}
+
+// lookupTag returns the field selection of the struct tag tag, as written in Go source.
+func lookupTag(tag string) (string, bool) {
+	return reflect.StructTag(tag).Lookup(tagKey)
+}
+
+// checkTags reports the malformed graphql tags of the struct type st.
+func (c *checker) checkTags(st *ast.StructType) {
+	for _, f := range st.Fields.List {
+		if f.Tag == nil {
+			continue
+		}
+		tag, err := strconv.Unquote(f.Tag.Value)
+		if err != nil {
+			continue
+		}
+		value, ok := lookupTag(tag)
+		if !ok {
+			continue
+		}
+		if _, err := selection.Parse(value); err != nil {
+			c.pass.Reportf(f.Tag.Pos(), "malformed %s tag %q: %v", tagKey, value, err)
+		}
+	}
+}
+
+// checkCall checks the calls of functions of package graphql taking a query
+// struct and its variables, which are the parameters preceding and named variables.
+func (c *checker) checkCall(call *ast.CallExpr) {
+	fn, ok := typeutil.Callee(c.pass.TypesInfo, call).(*types.Func)
+	if !ok || fn.Pkg() == nil || fn.Pkg().Path() != graphqlPath {
+		return
+	}
+	params := fn.Type().(*types.Signature).Params()
+	for i := 1; i < params.Len() && i < len(call.Args); i++ {
+		if params.At(i).Name() == "variables" {
+			c.checkQuery(call, call.Args[i-1], call.Args[i])
+			return
+		}
+	}
+}
+
+func (c *checker) checkQuery(call *ast.CallExpr, query, variables ast.Expr) {
+	used := make(map[string]bool)
+	c.walk(c.pass.TypesInfo.TypeOf(query), call, used, make(map[types.Type]bool))
+
+	keys, ok := c.variableKeys(variables)
+	if !ok {
+		return
+	}
+	var missing []string
+	for name := range used {
+		if _, ok := keys[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		c.pass.Reportf(variables.Pos(), "variable $%s used by the query is missing from the variables", name)
+	}
+	for name, value := range keys {
+		if !used[name] {
+			c.pass.Reportf(value.Pos(), "variable $%s isn't used by the query", name)
+			continue
+		}
+		c.checkVariable(name, value)
+	}
+}
+
+// variableKeys returns the values of the variables expression by name,
+// and whether they're all known, as for map literals with constant keys.
+func (c *checker) variableKeys(variables ast.Expr) (map[string]ast.Expr, bool) {
+	keys := make(map[string]ast.Expr)
+	if tv, ok := c.pass.TypesInfo.Types[variables]; ok && tv.IsNil() {
+		return keys, true
+	}
+	lit, ok := astutil.Unparen(variables).(*ast.CompositeLit)
+	if !ok {
+		return nil, false
+	}
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			return nil, false
+		}
+		key := c.pass.TypesInfo.Types[kv.Key].Value
+		if key == nil || key.Kind() != constant.String {
+			return nil, false
+		}
+		keys[constant.StringVal(key)] = kv.Value
+	}
+	return keys, true
+}
+
+// checkVariable reports the values of variables whose Go types give no GraphQL type.
+func (c *checker) checkVariable(name string, value ast.Expr) {
+	tv := c.pass.TypesInfo.Types[value]
+	if tv.IsNil() {
+		c.pass.Reportf(value.Pos(), "variable $%s is an untyped nil, whose GraphQL type is unknown; use a typed nil pointer such as (*graphql.String)(nil)", name)
+		return
+	}
+	t := tv.Type
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+			continue
+		case *types.Slice:
+			t = u.Elem()
+			continue
+		case *types.Array:
+			t = u.Elem()
+			continue
+		}
+		break
+	}
+	if basic, ok := t.(*types.Basic); ok && basic.Kind() != types.String {
+		c.pass.Reportf(value.Pos(), "variable $%s of Go type %s has no GraphQL type; use a named type such as graphql.Int", name, tv.Type)
+	}
+}
+
+// walk collects the variables used by the query struct type t into used,
+// and reports its fields of types responses can't be decoded to.
+func (c *checker) walk(t types.Type, call *ast.CallExpr, used map[string]bool, seen map[types.Type]bool) {
+	if t == nil || seen[t] {
+		return
+	}
+	seen[t] = true
+	switch u := types.Unalias(t).(type) {
+	case *types.Pointer:
+		c.walk(u.Elem(), call, used, seen)
+	case *types.Slice:
+		c.walk(u.Elem(), call, used, seen)
+	case *types.Named:
+		if isScalar(u) {
+			return
+		}
+		c.walk(u.Underlying(), call, used, seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			f := u.Field(i)
+			if !f.Exported() {
+				continue
+			}
+			if tag, ok := lookupTag(u.Tag(i)); ok {
+				sel, err := selection.Parse(tag)
+				if err != nil {
+					continue // Reported by checkTags.
+				}
+				for _, v := range sel.Variables {
+					used[v] = true
+				}
+			}
+			if why := unsupported(f.Type()); why != "" {
+				c.reportField(f, call, why)
+				continue
+			}
+			c.walk(f.Type(), call, used, seen)
+		}
+	}
+}
+
+// reportField reports the field f of unsupported type, where it's declared if
+// that's in the analyzed package, and at call otherwise.
+func (c *checker) reportField(f *types.Var, call *ast.CallExpr, why string) {
+	if c.reported[f] {
+		return
+	}
+	c.reported[f] = true
+	if f.Pkg() == c.pass.Pkg {
+		c.pass.Reportf(f.Pos(), "field %s of type %s can't be decoded: %s", f.Name(), f.Type(), why)
+		return
+	}
+	c.pass.Reportf(call.Pos(), "field %s of type %s of the query can't be decoded: %s", f.Name(), f.Type(), why)
+}
+
+// unsupported returns why responses can't be decoded to the type t, or "" if they can.
+func unsupported(t types.Type) string {
+	if named, ok := types.Unalias(t).(*types.Named); ok && isScalar(named) {
+		return ""
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Map:
+		return "maps aren't supported, use a struct"
+	case *types.Chan, *types.Signature:
+		return "it's not a data type"
+	case *types.Pointer:
+		return unsupported(u.Elem())
+	case *types.Slice:
+		return unsupported(u.Elem())
+	case *types.Array:
+		return unsupported(u.Elem())
+	case *types.Basic:
+		if u.Info()&types.IsComplex != 0 || u.Kind() == types.UnsafePointer {
+			return "it's not a JSON type"
+		}
+	}
+	return ""
+}
+
+// isScalar reports whether the pointer type of t implements json.Unmarshaler,
+// which makes it a scalar whose fields aren't part of the query.
+func isScalar(t *types.Named) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, t.Obj().Pkg(), "UnmarshalJSON")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 1 && sig.Results().Len() == 1 && strings.HasSuffix(sig.Results().At(0).Type().String(), "error")
+}
@@ -0,0 +1,12 @@
+package tagcheck_test
+
+import (
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client/tagcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), tagcheck.Analyzer, "a")
+}
@@ -0,0 +1,58 @@
+package a
+
+import (
+	"context"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+type Repository struct {
+	Name   graphql.String
+	Topics map[string]graphql.String // want `field Topics of type map\[string\]github.com/runtimeracer/go-graphql-client.String can't be decoded: maps aren't supported, use a struct`
+}
+
+type UserQuery struct {
+	User struct {
+		Name         graphql.String
+		CreatedAt    time.Time
+		Repositories []Repository `graphql:"repositories(first: $first, orderBy: {field: NAME, direction: ASC})"`
+		Tags         *[]graphql.String
+		Location     [2]graphql.Int
+		Scores       *[]map[string]graphql.Int // want `field Scores of type \*\[\]map\[string\]github.com/runtimeracer/go-graphql-client.Int can't be decoded: maps aren't supported, use a struct`
+	} `graphql:"user(login: $login) @include(if: $withUser)"`
+}
+
+type Malformed struct {
+	A graphql.String `graphql:"user(login: $login"` // want `malformed graphql tag "user\(login: \$login": expected argument name or "\)" at offset 18`
+	B graphql.String `graphql:"user(login: )"`      // want `malformed graphql tag "user\(login: \)": expected value at offset 12`
+	C graphql.String `graphql:"... on"`             // want `malformed graphql tag "... on": expected type condition at offset 6`
+	D graphql.String `graphql:"a: b c"`             // want `malformed graphql tag "a: b c": unexpected "c" at offset 5`
+	E graphql.String `graphql:"user(name: \"x)"`    // want `malformed graphql tag "user\(name: \\"x\)": unterminated string at offset 11`
+	F graphql.String `json:"f"`
+}
+
+func calls(ctx context.Context, client *graphql.Client, variables map[string]interface{}) {
+	var q UserQuery
+	client.Query(ctx, &q, map[string]interface{}{
+		"login":    graphql.String("gopher"),
+		"first":    graphql.Int(10),
+		"withUser": true, // want `variable \$withUser of Go type bool has no GraphQL type; use a named type such as graphql.Int`
+	})
+	client.Query(ctx, &q, map[string]interface{}{ // want `variable \$first used by the query is missing from the variables` `variable \$withUser used by the query is missing from the variables`
+		"login": graphql.String("gopher"),
+		"order": graphql.String("ASC"), // want `variable \$order isn't used by the query`
+	})
+	client.Query(ctx, &q, nil) // want `variable \$first used by the query is missing from the variables` `variable \$login used by the query is missing from the variables` `variable \$withUser used by the query is missing from the variables`
+	client.Query(ctx, &q, variables)
+
+	var m struct {
+		Rename struct {
+			Name graphql.String
+		} `graphql:"rename(id: $id, name: $name)"`
+	}
+	client.NamedMutate(ctx, "Rename", &m, map[string]interface{}{
+		"id":   nil, // want `variable \$id is an untyped nil, whose GraphQL type is unknown; use a typed nil pointer such as \(\*graphql.String\)\(nil\)`
+		"name": (*graphql.String)(nil),
+	})
+}
@@ -0,0 +1,22 @@
+// Package graphql is a stub of the client for the tests of tagcheck.
+package graphql
+
+import "context"
+
+type (
+	ID     interface{}
+	Int    int32
+	String string
+)
+
+type Option func()
+
+type Client struct{}
+
+func (c *Client) Query(ctx context.Context, q interface{}, variables map[string]interface{}, options ...Option) error {
+	return nil
+}
+
+func (c *Client) NamedMutate(ctx context.Context, name string, m interface{}, variables map[string]interface{}, options ...Option) error {
+	return nil
+}
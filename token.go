@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// TokenProvider returns the bearer token authenticating a request, consulted
+// for every request so that rotated tokens are picked up. refresh is true
+// after the server rejected the previous token with 401 Unauthorized, when
+// a fresh token must be fetched instead of any cached one.
+//
+// An oauth2.TokenSource, which refreshes expired tokens itself, is adapted as:
+//
+//	ts := oauth2.ReuseTokenSource(nil, src)
+//	provider := func(ctx context.Context, refresh bool) (string, error) {
+//		if refresh {
+//			ts = oauth2.ReuseTokenSource(nil, src)
+//		}
+//		t, err := ts.Token()
+//		if err != nil {
+//			return "", err
+//		}
+//		return t.AccessToken, nil
+//	}
+type TokenProvider func(ctx context.Context, refresh bool) (string, error)
+
+// WithTokenProvider makes the client send the token of provider in the
+// Authorization header of every request. If the server responds with
+// 401 Unauthorized, the operation is retried once with a refreshed token,
+// unless it has uploads, whose files have been consumed.
+func (c *Client) WithTokenProvider(provider TokenProvider) *Client {
+	c.tokenProvider = provider
+	return c
+}
+
+// authenticate returns the handler executing operations with next,
+// authenticated with the token of c.tokenProvider.
+func (c *Client) authenticate(next OperationHandler) OperationHandler {
+	return func(ctx context.Context, op *Operation) (*Response, error) {
+		resp, err := c.sendWithToken(ctx, next, op, false)
+		var netErr *NetworkError
+		if errors.As(err, &netErr) && netErr.StatusCode() == http.StatusUnauthorized &&
+			len(extractUploads(op.Variables)) == 0 {
+			return c.sendWithToken(ctx, next, op, true)
+		}
+		return resp, err
+	}
+}
+
+func (c *Client) sendWithToken(ctx context.Context, next OperationHandler, op *Operation, refresh bool) (*Response, error) {
+	token, err := c.tokenProvider(ctx, refresh)
+	if err != nil {
+		return nil, err
+	}
+	authenticated := *op
+	authenticated.Header = op.Header.Clone()
+	if authenticated.Header == nil {
+		authenticated.Header = make(http.Header)
+	}
+	authenticated.Header.Set("Authorization", "Bearer "+token)
+	return next(ctx, &authenticated)
+}
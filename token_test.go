@@ -0,0 +1,56 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithTokenProvider(t *testing.T) {
+	valid := "token-1"
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, req.Header.Get("Authorization"))
+		if req.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	fetched := 0
+	var refreshed []bool
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithTokenProvider(func(ctx context.Context, refresh bool) (string, error) {
+			refreshed = append(refreshed, refresh)
+			if refresh || fetched == 0 {
+				fetched++
+			}
+			return "token-" + string(rune('0'+fetched)), nil
+		})
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// The token is rotated, so the cached one is rejected and refreshed.
+	valid = "token-2"
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got Authorization headers: %q, want: %q", got, want)
+	}
+	if got, want := len(refreshed), 3; got != want || !refreshed[2] {
+		t.Errorf("got refresh flags: %v, want the last of %d refreshing", refreshed, want)
+	}
+}
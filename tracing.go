@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Tracing is the "tracing" extension of a response, in the Apollo Tracing format
+// (https://github.com/apollographql/apollo-tracing), reporting where the server
+// spent the time to execute the operation.
+type Tracing struct {
+	Version   int
+	StartTime time.Time
+	EndTime   time.Time
+	Duration  time.Duration
+
+	Parsing    TracingPhase
+	Validation TracingPhase
+	Execution  struct {
+		Resolvers []ResolverTrace
+	}
+}
+
+// TracingPhase is the timing of a phase of the execution of an operation.
+type TracingPhase struct {
+	// StartOffset is the time elapsed from the start of the operation to the start of the phase.
+	StartOffset time.Duration
+	Duration    time.Duration
+}
+
+// ResolverTrace is the timing of the resolver of a field.
+type ResolverTrace struct {
+	Path       Path
+	ParentType string
+	FieldName  string
+	ReturnType string
+	// StartOffset is the time elapsed from the start of the operation to the start of the resolver.
+	StartOffset time.Duration
+	Duration    time.Duration
+}
+
+// Tracing returns the "tracing" extension of the response, or nil if there is none,
+// e.g. with a response returned by Client.QueryWithResponse from a server having
+// Apollo Tracing enabled.
+func (r *Response) Tracing() (*Tracing, error) {
+	extensions, ok := r.Extensions.(map[string]interface{})
+	if !ok || extensions["tracing"] == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(extensions["tracing"])
+	if err != nil {
+		return nil, err
+	}
+	var tracing Tracing
+	if err := json.Unmarshal(b, &tracing); err != nil {
+		return nil, err
+	}
+	return &tracing, nil
+}
@@ -0,0 +1,77 @@
+package graphql_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestResponse_Tracing(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"users": [{"name": "Gopher"}]}, "extensions": {"tracing": {
+			"version": 1,
+			"startTime": "2021-03-01T12:00:00.000Z",
+			"endTime": "2021-03-01T12:00:00.150Z",
+			"duration": 150000000,
+			"parsing": {"startOffset": 10000, "duration": 20000},
+			"validation": {"startOffset": 30000, "duration": 40000},
+			"execution": {"resolvers": [{
+				"path": ["users", 0, "name"],
+				"parentType": "User",
+				"fieldName": "name",
+				"returnType": "String!",
+				"startOffset": 100000,
+				"duration": 2500000
+			}]}
+		}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var q struct {
+		Users []struct {
+			Name string
+		}
+	}
+	resp, err := client.QueryWithResponse(context.Background(), &q, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing, err := resp.Tracing()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracing == nil {
+		t.Fatal("got no tracing")
+	}
+	if got, want := tracing.Duration, 150*time.Millisecond; got != want {
+		t.Errorf("got duration: %v, want: %v", got, want)
+	}
+	if got, want := tracing.EndTime.Sub(tracing.StartTime), 150*time.Millisecond; got != want {
+		t.Errorf("got end time - start time: %v, want: %v", got, want)
+	}
+	if got, want := tracing.Validation, (graphql.TracingPhase{StartOffset: 30 * time.Microsecond, Duration: 40 * time.Microsecond}); got != want {
+		t.Errorf("got validation: %+v, want: %+v", got, want)
+	}
+	want := []graphql.ResolverTrace{{
+		Path:        graphql.Path{"users", 0, "name"},
+		ParentType:  "User",
+		FieldName:   "name",
+		ReturnType:  "String!",
+		StartOffset: 100 * time.Microsecond,
+		Duration:    2500 * time.Microsecond,
+	}}
+	if got := tracing.Execution.Resolvers; !reflect.DeepEqual(got, want) {
+		t.Errorf("got resolvers: %+v, want: %+v", got, want)
+	}
+
+	resp.Extensions = nil
+	if tracing, err := resp.Tracing(); tracing != nil || err != nil {
+		t.Errorf("got tracing: %v, %v, want none", tracing, err)
+	}
+}
@@ -0,0 +1,133 @@
+package graphql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// DialFunc connects to the address on the named network, as net.Dialer.DialContext does.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// WithDialer makes the client connect to the GraphQL server with dial,
+// e.g. to reach in-cluster sidecars or test fixtures.
+//
+// Like the other transport options, it only applies to a client using
+// an *http.Client whose Transport is nil or an *http.Transport, which
+// is cloned so that the *http.Client isn't modified.
+func (c *Client) WithDialer(dial DialFunc) *Client {
+	if t := c.transport(); t != nil {
+		t.DialContext = dial
+	}
+	return c
+}
+
+// WithUnixSocket makes the client connect to the GraphQL server over the Unix
+// domain socket at path, whatever the host of its URL, e.g. "http://localhost/graphql".
+// See WithDialer for the clients it applies to.
+func (c *Client) WithUnixSocket(path string) *Client {
+	return c.WithDialer(func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", path)
+	})
+}
+
+// WithProxy makes the client send requests through the proxy at proxyURL,
+// whatever the environment variables, e.g. "http://proxy.example.com:3128".
+// See WithDialer for the clients it applies to.
+func (c *Client) WithProxy(proxyURL *url.URL) *Client {
+	return c.WithProxyFunc(http.ProxyURL(proxyURL))
+}
+
+// WithProxyFunc makes the client send each request through the proxy returned by
+// proxy, or directly if it returns a nil URL, as http.Transport.Proxy does.
+// See WithDialer for the clients it applies to.
+func (c *Client) WithProxyFunc(proxy func(*http.Request) (*url.URL, error)) *Client {
+	if t := c.transport(); t != nil {
+		t.Proxy = proxy
+	}
+	return c
+}
+
+// WithTLSConfig makes the client connect to the GraphQL server with config.
+// See WithDialer for the clients it applies to.
+func (c *Client) WithTLSConfig(config *tls.Config) *Client {
+	if t := c.transport(); t != nil {
+		t.TLSClientConfig = config
+	}
+	return c
+}
+
+// WithClientCertificate makes the client present cert to the GraphQL server,
+// for mutual TLS. Certificates are loaded with tls.LoadX509KeyPair or tls.X509KeyPair.
+// See WithDialer for the clients it applies to.
+func (c *Client) WithClientCertificate(cert tls.Certificate) *Client {
+	if config := c.tlsConfig(); config != nil {
+		config.Certificates = append(config.Certificates, cert)
+	}
+	return c
+}
+
+// WithRootCAs makes the client verify the certificate of the GraphQL server
+// with the certificate authorities of pool, instead of those of the system,
+// e.g. for servers using a private CA. See WithDialer for the clients it applies to.
+func (c *Client) WithRootCAs(pool *x509.CertPool) *Client {
+	if config := c.tlsConfig(); config != nil {
+		config.RootCAs = pool
+	}
+	return c
+}
+
+// WithInsecureSkipVerify makes the client accept any certificate presented by the
+// GraphQL server, e.g. a self-signed certificate of a development server. It makes
+// the connection vulnerable to man-in-the-middle attacks, and must not be used
+// in production. See WithDialer for the clients it applies to.
+func (c *Client) WithInsecureSkipVerify() *Client {
+	if config := c.tlsConfig(); config != nil {
+		config.InsecureSkipVerify = true
+	}
+	return c
+}
+
+// tlsConfig returns the TLS configuration of the transport of c, created if needed,
+// or nil if c doesn't use an *http.Transport.
+func (c *Client) tlsConfig() *tls.Config {
+	t := c.transport()
+	if t == nil {
+		return nil
+	}
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	return t.TLSClientConfig
+}
+
+// transport returns the *http.Transport dedicated to c, cloned on first use
+// from the one of its *http.Client or from http.DefaultTransport,
+// or nil if c doesn't use an *http.Transport.
+func (c *Client) transport() *http.Transport {
+	if c.ownTransport != nil {
+		return c.ownTransport
+	}
+	hc, ok := c.httpClient.(*http.Client)
+	if !ok {
+		return nil
+	}
+	var t *http.Transport
+	switch rt := hc.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		return nil
+	}
+	own := *hc
+	own.Transport = t
+	c.httpClient = &own
+	c.ownTransport = t
+	return t
+}
@@ -0,0 +1,128 @@
+package graphql_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_WithUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "graphql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "graphql.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets unsupported:", err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})}
+	go server.Serve(l)
+	defer server.Close()
+
+	httpClient := &http.Client{}
+	client := graphql.NewClient("http://localhost/graphql", httpClient).WithUnixSocket(path)
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	if httpClient.Transport != nil {
+		t.Error("got the transport of the given http.Client modified")
+	}
+}
+
+func TestClient_WithRootCAs(t *testing.T) {
+	var gotClientCert bool
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotClientCert = len(req.TLS.PeerCertificates) > 0
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	// Untrusted certificate.
+	err := graphql.NewClient(server.URL, nil).Query(context.Background(), &q, nil)
+	if err == nil {
+		t.Fatal("got no error, want an unknown authority error")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	client := graphql.NewClient(server.URL, nil).WithRootCAs(pool)
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	if gotClientCert {
+		t.Error("got a client certificate, want none")
+	}
+
+	client = graphql.NewClient(server.URL, nil).WithInsecureSkipVerify().WithClientCertificate(server.TLS.Certificates[0])
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if !gotClientCert {
+		t.Error("got no client certificate")
+	}
+}
+
+func TestClient_WithProxy(t *testing.T) {
+	var gotURL string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotURL = req.URL.String()
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := graphql.NewClient("http://partner.example/graphql", nil).WithProxy(proxyURL)
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got q.User.Name: %q, want: %q", got, want)
+	}
+	if got, want := gotURL, "http://partner.example/graphql"; got != want {
+		t.Errorf("got proxied URL: %q, want: %q", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+package graphql
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upload is a file sent along with an operation, according to the GraphQL multipart
+// request specification (https://github.com/jaydenseric/graphql-multipart-request-spec).
+// Use it, or a pointer to it, as the value of a variable of type Upload!,
+// or anywhere within input objects and lists:
+//
+//	variables := map[string]interface{}{
+//		"file": graphql.Upload{File: f, Name: "report.pdf", ContentType: "application/pdf"},
+//	}
+//
+// Operations with uploads are sent as multipart/form-data POST requests.
+type Upload struct {
+	File        io.Reader
+	Name        string // File name, sent to the server.
+	ContentType string // Defaults to "application/octet-stream".
+}
+
+// MarshalJSON implements json.Marshaler. Uploads are null in the
+// operation, their contents are sent as separate parts of the request.
+func (Upload) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// fileUpload is an Upload found in the variables of an operation.
+type fileUpload struct {
+	path   string // Object path of the variable, e.g. "variables.files.0".
+	upload *Upload
+}
+
+// extractUploads returns all uploads in variables, in a stable order.
+func extractUploads(variables map[string]interface{}) []fileUpload {
+	var uploads []fileUpload
+	for _, name := range sortedKeys(variables) {
+		uploads = appendUploads(uploads, "variables."+name, reflect.ValueOf(variables[name]))
+	}
+	return uploads
+}
+
+var uploadType = reflect.TypeOf(Upload{})
+
+// appendUploads appends the uploads in v, whose object path is path, to uploads.
+func appendUploads(uploads []fileUpload, path string, v reflect.Value) []fileUpload {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return uploads
+		}
+		v = v.Elem()
+	}
+	if v.Type() == uploadType {
+		u := v.Interface().(Upload)
+		return append(uploads, fileUpload{path: path, upload: &u})
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return uploads
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			uploads = appendUploads(uploads, path+"."+k, v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())))
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return uploads // Bytes, encoded as a string.
+		}
+		for i := 0; i < v.Len(); i++ {
+			uploads = appendUploads(uploads, path+"."+strconv.Itoa(i), v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue // Unexported.
+			}
+			name := f.Name
+			if tag, ok := f.Tag.Lookup("json"); ok {
+				tagName := strings.Split(tag, ",")[0]
+				if tagName == "-" {
+					continue
+				}
+				if tagName != "" {
+					name = tagName
+				}
+			}
+			uploads = appendUploads(uploads, path+"."+name, v.Field(i))
+		}
+	}
+	return uploads
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeMultipart writes the operation in and its uploads with mw as a multipart request.
+func writeMultipart(mw *multipart.Writer, codec Codec, in interface{}, uploads []fileUpload) error {
+	operations, err := codec.Marshal(in)
+	if err != nil {
+		return err
+	}
+	if err := mw.WriteField("operations", string(operations)); err != nil {
+		return err
+	}
+	paths := make(map[string][]string, len(uploads))
+	for i, u := range uploads {
+		paths[strconv.Itoa(i)] = []string{u.path}
+	}
+	fileMap, err := codec.Marshal(paths)
+	if err != nil {
+		return err
+	}
+	if err := mw.WriteField("map", string(fileMap)); err != nil {
+		return err
+	}
+
+	for i, u := range uploads {
+		if u.upload.File == nil {
+			return fmt.Errorf("graphql: upload %s has no file", u.path)
+		}
+		contentType := u.upload.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%d"; filename="%s"`, i, escapeQuotes(u.upload.Name)))
+		h.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(part, u.upload.File); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
+
+// newMultipartBody returns a multipart request body streaming the operation in
+// and its uploads, and its content type. The files are copied as the body is read,
+// so they're never buffered in memory. The body is sent with chunked transfer encoding,
+// as its length isn't known in advance.
+func newMultipartBody(codec Codec, in interface{}, uploads []fileUpload) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		// Reading the body fails with the error, if any. If the body is closed
+		// before being fully read, e.g. when the request fails, writing fails too.
+		pw.CloseWithError(writeMultipart(mw, codec, in, uploads))
+	}()
+	return pr, mw.FormDataContentType()
+}
@@ -0,0 +1,101 @@
+package graphql_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+func TestClient_Mutate_upload(t *testing.T) {
+	type Attachment struct {
+		Caption string         `json:"caption"`
+		File    graphql.Upload `json:"file"`
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := req.FormValue("operations"), `{"query":"mutation ($attachments:[Attachment!]!$file:Upload!){upload(file: $file, attachments: $attachments){id}}","variables":{"attachments":[{"caption":"a","file":null}],"file":null}}`; got != want {
+			t.Errorf("got operations: %s, want: %s", got, want)
+		}
+		if got, want := req.FormValue("map"), `{"0":["variables.attachments.0.file"],"1":["variables.file"]}`; got != want {
+			t.Errorf("got map: %s, want: %s", got, want)
+		}
+		for name, want := range map[string]string{"0": "attached", "1": "report"} {
+			f, h, err := req.FormFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mustRead(f); got != want {
+				t.Errorf("got file %s: %q, want: %q", name, got, want)
+			}
+			if name == "1" {
+				if got, want := h.Filename, "report.txt"; got != want {
+					t.Errorf("got file name: %q, want: %q", got, want)
+				}
+				if got, want := h.Header.Get("Content-Type"), "text/plain"; got != want {
+					t.Errorf("got content type: %q, want: %q", got, want)
+				}
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"upload": {"id": "1"}}}`)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var m struct {
+		Upload struct {
+			ID string
+		} `graphql:"upload(file: $file, attachments: $attachments)"`
+	}
+	variables := map[string]interface{}{
+		"file": graphql.Upload{File: strings.NewReader("report"), Name: "report.txt", ContentType: "text/plain"},
+		"attachments": []Attachment{
+			{Caption: "a", File: graphql.Upload{File: strings.NewReader("attached"), Name: "a.bin"}},
+		},
+	}
+	if err := client.Mutate(context.Background(), &m, variables); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Upload.ID, "1"; got != want {
+		t.Errorf("got m.Upload.ID: %q, want: %q", got, want)
+	}
+}
+
+func TestClient_Mutate_uploadReadError(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		if err := req.ParseMultipartForm(1 << 20); err == nil {
+			t.Error("got no error reading the body of a failed upload")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}})
+
+	var m struct {
+		Upload struct {
+			ID string
+		} `graphql:"upload(file: $file)"`
+	}
+	errRead := errors.New("read failed")
+	variables := map[string]interface{}{
+		"file": &graphql.Upload{File: io.MultiReader(strings.NewReader("partial"), errorReader{errRead}), Name: "a.bin"},
+	}
+	if err := client.Mutate(context.Background(), &m, variables); err == nil {
+		t.Error("got no error, want one")
+	}
+}
+
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"context"
+	"maps"
+)
+
+// VariableTransformer rewrites the variables of an operation of type typ, e.g. to
+// inject a tenant ID, convert custom types or encrypt fields, and returns them.
+// It may modify variables, a copy of the map of the call, in place.
+//
+// Variable transformers run before the document of the operation is constructed,
+// so the variables they add are declared by it, with the types of their values.
+type VariableTransformer func(ctx context.Context, typ OperationType, variables map[string]interface{}) (map[string]interface{}, error)
+
+// WithVariableTransformers adds transformers rewriting the variables of the queries
+// and mutations derived from structs, run in order. Transformers failing fail the
+// operation before it's sent. SubscriptionClient.WithVariableTransformers adds them
+// to subscriptions.
+func (c *Client) WithVariableTransformers(transformers ...VariableTransformer) *Client {
+	c.variableTransformers = append(c.variableTransformers, transformers...)
+	return c
+}
+
+// WithVariableTransformers adds transformers rewriting the variables of the
+// subscriptions and live queries of the client, run in order, with a background
+// context, as Client.WithVariableTransformers does.
+func (sc *SubscriptionClient) WithVariableTransformers(transformers ...VariableTransformer) *SubscriptionClient {
+	sc.variableTransformers = append(sc.variableTransformers, transformers...)
+	return sc
+}
+
+// transformVariables returns a copy of variables rewritten by transformers,
+// or variables if there are none.
+func transformVariables(ctx context.Context, transformers []VariableTransformer, typ OperationType, variables map[string]interface{}) (map[string]interface{}, error) {
+	if len(transformers) == 0 {
+		return variables, nil
+	}
+	variables = maps.Clone(variables)
+	if variables == nil {
+		variables = make(map[string]interface{})
+	}
+	for _, transform := range transformers {
+		var err error
+		if variables, err = transform(ctx, typ, variables); err != nil {
+			return nil, err
+		}
+	}
+	return variables, nil
+}
+
+// WithDefaultVariables adds variables, e.g. a locale or tenant ID, to the queries
+// and mutations derived from structs whose selections refer to them, unless the
+// variables of the call have them already. Variables no selection refers to aren't
+// added, as documents mustn't declare unused ones. Defaults are merged before
+// variable transformers run, and calling it again adds to the defaults, replacing
+// those of the same names. SubscriptionClient.WithDefaultVariables adds them to
+// subscriptions.
+func (c *Client) WithDefaultVariables(variables map[string]interface{}) *Client {
+	c.defaultVariables = mergeVariables(c.defaultVariables, variables)
+	return c
+}
+
+// WithDefaultVariables adds default variables to the subscriptions and live
+// queries of the client, as Client.WithDefaultVariables does.
+func (sc *SubscriptionClient) WithDefaultVariables(variables map[string]interface{}) *SubscriptionClient {
+	sc.defaultVariables = mergeVariables(sc.defaultVariables, variables)
+	return sc
+}
+
+// mergeVariables returns a new map with the variables of a and b, those of b
+// replacing those of a, so that clients sharing a aren't affected.
+func mergeVariables(a, b map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(a)+len(b))
+	maps.Copy(merged, a)
+	maps.Copy(merged, b)
+	return merged
+}
+
+// prepareVariables returns the variables of the operation of type typ derived
+// from v, with the default variables of c it refers to, rewritten by the
+// transformers of c.
+func (c *Client) prepareVariables(ctx context.Context, typ OperationType, v interface{}, variables map[string]interface{}) (map[string]interface{}, error) {
+	variables = withDefaultVariables(c.defaultVariables, v, c.decodeOpts.TagKey, variables)
+	return transformVariables(ctx, c.variableTransformers, typ, variables)
+}
+
+// withDefaultVariables returns a copy of variables with the defaults the selection
+// of v refers to and variables misses, or variables if there are none.
+func withDefaultVariables(defaults map[string]interface{}, v interface{}, tagKey string, variables map[string]interface{}) map[string]interface{} {
+	if len(defaults) == 0 {
+		return variables
+	}
+	selection, err := query(v, tagKey)
+	if err != nil {
+		// Constructing the document fails with the error.
+		return variables
+	}
+	var merged map[string]interface{}
+	tokens := documentTokens(selection)
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i] != "$" {
+			continue
+		}
+		name := tokens[i+1]
+		value, ok := defaults[name]
+		if !ok {
+			continue
+		}
+		if _, ok := variables[name]; ok {
+			continue
+		}
+		if merged == nil {
+			merged = maps.Clone(variables)
+			if merged == nil {
+				merged = make(map[string]interface{})
+			}
+		}
+		merged[name] = value
+	}
+	if merged == nil {
+		return variables
+	}
+	return merged
+}
@@ -0,0 +1,188 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+)
+
+// injectTenant is a VariableTransformer injecting the tenant ID of queries and subscriptions.
+func injectTenant(ctx context.Context, typ graphql.OperationType, variables map[string]interface{}) (map[string]interface{}, error) {
+	if typ == graphql.MutationOperation {
+		return nil, errors.New("no mutations")
+	}
+	variables["tenantId"] = graphql.ID("acme")
+	return variables, nil
+}
+
+func TestClient_WithVariableTransformers(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		got = append(got, mustRead(req.Body))
+		w.Header().Set("Content-Type", "application/json")
+		mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+	})
+	upper := func(ctx context.Context, typ graphql.OperationType, variables map[string]interface{}) (map[string]interface{}, error) {
+		if login, ok := variables["login"].(string); ok {
+			variables["login"] = strings.ToUpper(login)
+		}
+		return variables, nil
+	}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithVariableTransformers(injectTenant, upper)
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(login: $login, tenantId: $tenantId)"`
+	}
+	variables := map[string]interface{}{"login": "gopher"}
+	if err := client.Query(context.Background(), &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"query":"query ($login:ID!$tenantId:ID!){user(login: $login, tenantId: $tenantId){name}}","variables":{"login":"GOPHER","tenantId":"acme"}}` + "\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got bodies: %q, want: %q", got, want)
+	}
+	if want := map[string]interface{}{"login": "gopher"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("got variables of the call: %v, want: %v", variables, want)
+	}
+
+	var m struct {
+		UpdateUser struct {
+			Name string
+		} `graphql:"updateUser(name: \"Gopher\")"`
+	}
+	if err := client.Mutate(context.Background(), &m, nil); err == nil || err.Error() != "no mutations" {
+		t.Errorf("got error: %v, want: no mutations", err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %d requests, want: 1", len(got))
+	}
+}
+
+func TestSubscriptionClient_WithVariableTransformers(t *testing.T) {
+	variables := make(chan map[string]interface{}, 1)
+	server := newWebSocketServer(t, func(payload json.RawMessage) []string {
+		var in struct {
+			Variables map[string]interface{}
+		}
+		if err := json.Unmarshal(payload, &in); err != nil {
+			t.Error(err)
+		}
+		variables <- in.Variables
+		return nil
+	})
+	defer server.Close()
+
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http")).
+		WithVariableTransformers(injectTenant)
+	defer sc.Close()
+	var s struct {
+		MessageAdded struct {
+			Text string
+		} `graphql:"messageAdded(tenantId: $tenantId)"`
+	}
+	if _, err := sc.Subscribe(&s, nil, func(message *json.RawMessage, err error) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	go sc.Run()
+
+	select {
+	case got := <-variables:
+		if want := map[string]interface{}{"tenantId": "acme"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got variables: %v, want: %v", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription wasn't started")
+	}
+}
+
+func TestClient_WithDefaultVariables(t *testing.T) {
+	var got []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		body := mustRead(req.Body)
+		got = append(got, body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(body, "viewer") {
+			mustWrite(w, `{"data": {"viewer": {"name": "Gopher"}}}`)
+		} else {
+			mustWrite(w, `{"data": {"user": {"name": "Gopher"}}}`)
+		}
+	})
+	defaults := map[string]interface{}{"tenantId": graphql.ID("acme"), "locale": graphql.String("en")}
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: mux}}).
+		WithDefaultVariables(defaults)
+	defaults["tenantId"] = graphql.ID("other")
+	other := client.Clone().WithDefaultVariables(map[string]interface{}{"locale": graphql.String("fr")})
+
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(login: $login, tenantId: $tenantId)"`
+	}
+	variables := map[string]interface{}{"login": "gopher"}
+	if err := client.Query(context.Background(), &q, variables); err != nil {
+		t.Fatal(err)
+	}
+	var localized struct {
+		Viewer struct {
+			Name string `graphql:"name(locale: $locale)"`
+		}
+	}
+	if err := client.Query(context.Background(), &localized, map[string]interface{}{"locale": graphql.String("de")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Query(context.Background(), &localized, nil); err != nil {
+		t.Fatal(err)
+	}
+	var viewer struct {
+		Viewer struct {
+			Name string
+		}
+	}
+	if err := client.Query(context.Background(), &viewer, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`{"query":"query ($login:ID!$tenantId:ID!){user(login: $login, tenantId: $tenantId){name}}","variables":{"login":"gopher","tenantId":"acme"}}` + "\n",
+		`{"query":"query ($locale:String!){viewer{name(locale: $locale)}}","variables":{"locale":"de"}}` + "\n",
+		`{"query":"query ($locale:String!){viewer{name(locale: $locale)}}","variables":{"locale":"fr"}}` + "\n",
+		`{"query":"{viewer{name}}"}` + "\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got bodies: %q, want: %q", got, want)
+	}
+	if want := map[string]interface{}{"login": "gopher"}; !reflect.DeepEqual(variables, want) {
+		t.Errorf("got variables of the call: %v, want: %v", variables, want)
+	}
+
+	// Plans declare the defaults as sent.
+	document, varTypes, err := client.Plan(&q, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "query ($login:ID!$tenantId:ID!){user(login: $login, tenantId: $tenantId){name}}"; document != want {
+		t.Errorf("got planned document: %q, want: %q", document, want)
+	}
+	if want := map[string]string{"login": "ID!", "tenantId": "ID!"}; !reflect.DeepEqual(varTypes, want) {
+		t.Errorf("got planned variable types: %v, want: %v", varTypes, want)
+	}
+	// Manifests need the defaults among the variables to register the documents sent.
+	m := graphql.NewPersistedManifest()
+	m.AddQuery("", &q, map[string]interface{}{"login": "", "tenantId": graphql.ID("")})
+	if err := client.Clone().WithPersistedOnly(m).Query(context.Background(), &q, variables); err != nil {
+		t.Errorf("got error of the persisted query: %v", err)
+	}
+}
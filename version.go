@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"runtime/debug"
+)
+
+// modulePath is the import path of this module.
+const modulePath = "github.com/runtimeracer/go-graphql-client"
+
+// libraryName identifies this library in the User-Agent header.
+const libraryName = "go-graphql-client"
+
+// Version is the version of this library, as recorded in the build info
+// of the binary it is linked into. It is "devel" if that information is
+// unavailable, e.g., when running the library's own tests.
+var Version = moduleVersion()
+
+// moduleVersion reports the version of this module the running binary was built with.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "devel"
+}
+
+// defaultUserAgent is the User-Agent sent by Client unless configured otherwise.
+// E.g., "go-graphql-client/v0.2.0".
+var defaultUserAgent = libraryName + "/" + Version
@@ -0,0 +1,125 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+// ErrWebSocketClosed is returned for the operations sent over the websocket
+// connection of a SubscriptionClient which was closed or reset before they completed.
+// They aren't sent again by the new connection, so that mutations aren't repeated.
+var ErrWebSocketClosed = errors.New("graphql: websocket connection closed before the operation completed")
+
+// ErrWebSocketCompleted is returned for the operations sent over the websocket
+// connection of a SubscriptionClient which the server completed without a result.
+var ErrWebSocketCompleted = errors.New("graphql: websocket operation completed without a result")
+
+// WithWebSocketTransport routes the queries and mutations of the client over
+// the websocket connection of sc, as supported by the graphql-ws protocol,
+// which saves the TCP and TLS setup of every request to chatty clients and passes
+// gateways letting only websockets through. sc must be running, or be run eventually:
+//
+//	sc := graphql.NewSubscriptionClient("wss://example.com/graphql")
+//	go sc.Run()
+//	client := graphql.NewClient("https://example.com/graphql", nil).WithWebSocketTransport(sc)
+//
+// The operations still go through the middleware of the client, but their
+// headers aren't sent, as the connection params of sc authenticate them.
+// Operations with uploads are sent over HTTP.
+func (c *Client) WithWebSocketTransport(sc *SubscriptionClient) *Client {
+	c.websocket = sc
+	return c
+}
+
+// webSocketResult is the outcome of an operation sent over a websocket connection.
+type webSocketResult struct {
+	resp *Response
+	err  error
+}
+
+// execute sends op over the websocket connection of sc, and waits for its response.
+func (sc *SubscriptionClient) execute(ctx context.Context, op *Operation) (*Response, error) {
+	id := uuid.New().String()
+	sub := &subscription{
+		query:      op.Query,
+		variables:  op.Variables,
+		extensions: op.Extensions,
+		result:     make(chan webSocketResult, 1),
+	}
+	// Unless sc is running, the operation is started once it runs.
+	sc.subscribersMu.Lock()
+	sc.subscriptions[id] = sub
+	var err error
+	if sc.isRunning {
+		err = sc.startSubscription(id, sub)
+	}
+	sc.subscribersMu.Unlock()
+	if err != nil {
+		sc.finish(id, nil, err)
+	}
+	select {
+	case r := <-sub.result:
+		return r.resp, r.err
+	case <-ctx.Done():
+		if sc.finish(id, nil, ctx.Err()) {
+			_ = sc.stopSubscription(id)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// finish removes the operation id sent by execute from the subscriptions of sc,
+// handing over its response or error. It reports whether it was still pending.
+func (sc *SubscriptionClient) finish(id string, resp *Response, err error) bool {
+	sc.subscribersMu.Lock()
+	sub, ok := sc.subscriptions[id]
+	ok = ok && sub.result != nil
+	if ok {
+		delete(sc.subscriptions, id)
+	}
+	sc.subscribersMu.Unlock()
+	if !ok {
+		return false
+	}
+	sub.result <- webSocketResult{resp: resp, err: err}
+	return true
+}
+
+// deliver hands the payload of a data or error message to the operation id sent by execute.
+func (sc *SubscriptionClient) deliver(id string, typ OperationMessageType, payload json.RawMessage) {
+	resp, err := decodePayload(typ, payload)
+	if err != nil {
+		sc.finish(id, nil, err)
+		return
+	}
+	sc.finish(id, resp, nil)
+}
+
+// decodePayload decodes the payload of a data message, or of an error message,
+// which is an error or a list of errors rather than an execution result.
+// Payloads with neither data nor errors fail with a *DecodeError.
+func decodePayload(typ OperationMessageType, payload json.RawMessage) (*Response, error) {
+	var resp Response
+	err := json.Unmarshal(payload, &resp)
+	if typ == GQL_ERROR && (err != nil || resp.Data == nil && len(resp.Errors) == 0) {
+		var errs Errors
+		if err = json.Unmarshal(payload, &errs); err != nil {
+			var e Error
+			if err = json.Unmarshal(payload, &e); err == nil && e.Message != "" {
+				errs = Errors{e}
+			}
+		}
+		resp = Response{Errors: errs}
+	}
+	if err != nil {
+		return nil, &DecodeError{err: newMalformedResponseError(payload, err)}
+	}
+	if resp.Data == nil && len(resp.Errors) == 0 {
+		// Neither data nor errors, as unmarshalGraphQLResult fails.
+		return nil, &DecodeError{err: newMalformedResponseError(payload, nil)}
+	}
+	return &resp, nil
+}
@@ -0,0 +1,277 @@
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/runtimeracer/go-graphql-client"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// newWebSocketServer returns a graphql-ws server answering every started
+// operation with the data messages returned by respond, and completing it.
+func newWebSocketServer(t *testing.T, respond func(payload json.RawMessage) []string) *httptest.Server {
+	return newWebSocketMessageServer(t, func(payload json.RawMessage) []graphql.OperationMessage {
+		var msgs []graphql.OperationMessage
+		for _, data := range respond(payload) {
+			msgs = append(msgs, graphql.OperationMessage{Type: graphql.GQL_DATA, Payload: json.RawMessage(data)})
+		}
+		return append(msgs, graphql.OperationMessage{Type: graphql.GQL_COMPLETE})
+	})
+}
+
+// newWebSocketMessageServer returns a graphql-ws server answering every started
+// operation with the messages returned by respond, given the ID of the operation.
+func newWebSocketMessageServer(t *testing.T, respond func(payload json.RawMessage) []graphql.OperationMessage) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := websocket.Accept(w, req, &websocket.AcceptOptions{Subprotocols: []string{"graphql-ws"}})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		ctx := req.Context()
+		for {
+			var msg graphql.OperationMessage
+			if err := wsjson.Read(ctx, conn, &msg); err != nil {
+				return
+			}
+			switch msg.Type {
+			case graphql.GQL_CONNECTION_INIT:
+				wsjson.Write(ctx, conn, graphql.OperationMessage{Type: graphql.GQL_CONNECTION_ACK})
+			case graphql.GQL_START:
+				for _, out := range respond(msg.Payload) {
+					out.ID = msg.ID
+					wsjson.Write(ctx, conn, out)
+				}
+			case graphql.GQL_CONNECTION_TERMINATE:
+				return
+			}
+		}
+	}))
+}
+
+func TestClient_WithWebSocketTransport(t *testing.T) {
+	var queries []string
+	server := newWebSocketServer(t, func(payload json.RawMessage) []string {
+		var in struct {
+			Query     string
+			Variables map[string]interface{}
+		}
+		if err := json.Unmarshal(payload, &in); err != nil {
+			t.Error(err)
+		}
+		queries = append(queries, in.Query)
+		if strings.HasPrefix(in.Query, "mutation") {
+			return []string{`{"data": {"updateUser": {"name": "Gopher"}}, "errors": [{"message": "name is taken"}]}`}
+		}
+		return []string{`{"data": {"user": {"name": "Gopher"}}}`}
+	})
+	defer server.Close()
+
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer sc.Close()
+	go sc.Run()
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.NotFoundHandler()}}).WithWebSocketTransport(sc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	if err := client.Query(ctx, &q, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := q.User.Name, "Gopher"; got != want {
+		t.Errorf("got name: %q, want: %q", got, want)
+	}
+
+	var m struct {
+		UpdateUser struct {
+			Name string
+		} `graphql:"updateUser(name: $name)"`
+	}
+	err := client.Mutate(ctx, &m, map[string]interface{}{"name": graphql.String("Gopher")})
+	if err == nil || err.Error() != "name is taken" {
+		t.Errorf("got error: %v, want: name is taken", err)
+	}
+	if got, want := m.UpdateUser.Name, "Gopher"; got != want {
+		t.Errorf("got name: %q, want: %q", got, want)
+	}
+	if got, want := len(queries), 2; got != want {
+		t.Errorf("got %d operations, want: %d", got, want)
+	}
+}
+
+func TestClient_WithWebSocketTransport_errorMessages(t *testing.T) {
+	server := newWebSocketMessageServer(t, func(payload json.RawMessage) []graphql.OperationMessage {
+		var in struct {
+			Variables map[string]string
+		}
+		if err := json.Unmarshal(payload, &in); err != nil {
+			t.Error(err)
+		}
+		switch in.Variables["case"] {
+		case "error":
+			return []graphql.OperationMessage{{Type: graphql.GQL_ERROR, Payload: json.RawMessage(`{"message": "not authorized"}`)}}
+		case "errors":
+			return []graphql.OperationMessage{{Type: graphql.GQL_ERROR, Payload: json.RawMessage(`[{"message": "a"}, {"message": "b"}]`)}}
+		case "empty":
+			return []graphql.OperationMessage{{Type: graphql.GQL_DATA, Payload: json.RawMessage(`{"message": "unexpected"}`)}}
+		}
+		// Completed without data.
+		return []graphql.OperationMessage{{Type: graphql.GQL_COMPLETE}}
+	})
+	defer server.Close()
+
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer sc.Close()
+	go sc.Run()
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.NotFoundHandler()}}).WithWebSocketTransport(sc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var q struct {
+		User struct {
+			Name string
+		} `graphql:"user(case: $case)"`
+	}
+	query := func(c string) error {
+		return client.Query(ctx, &q, map[string]interface{}{"case": graphql.String(c)})
+	}
+
+	var errs graphql.Errors
+	if err := query("error"); !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "not authorized" {
+		t.Errorf("got error: %v, want: not authorized", err)
+	}
+	if err := query("errors"); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("got error: %v, want: 2 errors", err)
+	}
+	var decodeErr *graphql.DecodeError
+	var malformed *graphql.MalformedResponseError
+	if err := query("empty"); !errors.As(err, &decodeErr) || !errors.As(err, &malformed) {
+		t.Errorf("got error: %v, want: a *DecodeError of a *MalformedResponseError", err)
+	}
+	if err := query("complete"); !errors.Is(err, graphql.ErrWebSocketCompleted) {
+		t.Errorf("got error: %v, want: %v", err, graphql.ErrWebSocketCompleted)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Errorf("operations waited for the context: %v", err)
+	}
+}
+
+func TestSubscriptionClient_Close_inFlight(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := newWebSocketMessageServer(t, func(payload json.RawMessage) []graphql.OperationMessage {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	defer server.Close()
+
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	go sc.Run()
+	client := graphql.NewClient("/graphql", &http.Client{Transport: localRoundTripper{handler: http.NotFoundHandler()}}).WithWebSocketTransport(sc)
+
+	// Operations are sent while sc is closed. Those sent after it's closed time out.
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				var q struct {
+					User struct {
+						Name string
+					}
+				}
+				err := client.Query(ctx, &q, nil)
+				cancel()
+				if !errors.Is(err, graphql.ErrWebSocketClosed) && !errors.Is(err, context.DeadlineExceeded) {
+					t.Errorf("got error: %v, want: %v", err, graphql.ErrWebSocketClosed)
+				}
+			}
+		}()
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no operation was started")
+	}
+	sc.Close()
+	wg.Wait()
+}
+
+func TestSubscriptionClient_Live(t *testing.T) {
+	server := newWebSocketServer(t, func(payload json.RawMessage) []string {
+		var in struct {
+			Query string
+		}
+		if err := json.Unmarshal(payload, &in); err != nil {
+			t.Error(err)
+		}
+		if got, want := in.Query, "query @live{user{name}}"; got != want {
+			t.Errorf("got query: %q, want: %q", got, want)
+		}
+		return []string{
+			`{"data": {"user": {"name": "Gopher"}}}`,
+			`{"data": {"user": {"name": "Gordon"}}}`,
+		}
+	})
+	defer server.Close()
+
+	sc := graphql.NewSubscriptionClient("ws" + strings.TrimPrefix(server.URL, "http"))
+	defer sc.Close()
+	names := make(chan string, 2)
+	var q struct {
+		User struct {
+			Name string
+		}
+	}
+	_, err := sc.Live(q, nil, func(message *json.RawMessage, err error) error {
+		if err != nil {
+			t.Error(err)
+			return nil
+		}
+		var data struct {
+			User struct {
+				Name string
+			}
+		}
+		if err := json.Unmarshal(*message, &data); err != nil {
+			t.Error(err)
+		}
+		names <- data.User.Name
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go sc.Run()
+
+	got := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		select {
+		case name := <-names:
+			got[name] = true
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for live results")
+		}
+	}
+	if !got["Gopher"] || !got["Gordon"] {
+		t.Errorf("got results: %v, want Gopher and Gordon", got)
+	}
+}